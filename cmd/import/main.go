@@ -0,0 +1,140 @@
+// cmd/import/main.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"schedule-app/internal/ical"
+	"schedule-app/internal/models"
+	"schedule-app/internal/spreadsheet"
+	"schedule-app/internal/storage"
+	"strings"
+)
+
+// Импорт событий из файла в хранилище:
+//
+//	go run ./cmd/import -file schedule.ics -storage sqlite
+//	go run ./cmd/import -file timetable.xlsx -template grid.json -dry-run
+//
+// Формат определяется по расширению файла или флагом -format. Для .xlsx
+// нужен шаблон сетки расписания (spreadsheet.GridTemplate в JSON). Повторный
+// импорт того же файла обновляет ранее загруженные события. Хранилище JSON
+// не должно одновременно использоваться запущенным сервером
+func main() {
+	file := flag.String("file", "", "импортируемый файл")
+	format := flag.String("format", "", "формат файла: ics, csv или xlsx (по умолчанию - по расширению)")
+	templatePath := flag.String("template", "", "JSON-файл с шаблоном сетки расписания (для xlsx)")
+	bellsPath := flag.String("bells", "", "JSON-файл с расписанием звонков для пар без времени в шаблоне (для xlsx)")
+	dryRun := flag.Bool("dry-run", false, "только проверить файл, не сохраняя события (для csv и xlsx)")
+	backend := flag.String("storage", "json", "хранилище событий: json или sqlite")
+	storagePath := flag.String("data", "", "путь к файлу данных (по умолчанию data/events.json или data/events.db)")
+	timeZone := flag.String("tz", "Europe/Moscow", "часовой пояс (IANA) для времени без часового пояса")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("Укажите импортируемый файл: -file")
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	location, err := models.LoadLocation(*timeZone)
+	if err != nil {
+		log.Fatalf("Неизвестный часовой пояс %s: %v", *timeZone, err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Не удалось открыть %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	store, err := storage.Open(*backend, *storagePath)
+	if err != nil {
+		log.Fatalf("Ошибка при инициализации хранилища: %v", err)
+	}
+	if closer, ok := store.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	switch *format {
+	case "ics", "ical":
+		result, err := ical.Import(store, f, location)
+		if err != nil {
+			log.Fatalf("Ошибка при импорте %s: %v", *file, err)
+		}
+		for _, itemErr := range result.Errors {
+			log.Printf("Строка %d (%s %q): %s", itemErr.Line, itemErr.UID, itemErr.Summary, itemErr.Message)
+		}
+		log.Printf("Импортировано событий: %d новых, %d обновлено, %d с ошибками", result.Created, result.Updated, len(result.Errors))
+
+	case "csv":
+		result, err := spreadsheet.ImportCSV(store, f, spreadsheet.CSVOptions{Location: location, DryRun: *dryRun})
+		if err != nil {
+			log.Fatalf("Ошибка при импорте %s: %v", *file, err)
+		}
+		reportSpreadsheet(result)
+
+	case "xlsx":
+		opts := spreadsheet.XLSXOptions{Location: location, DryRun: *dryRun}
+		if *templatePath == "" {
+			log.Fatal("Для импорта xlsx укажите шаблон сетки: -template")
+		}
+		data, err := os.ReadFile(*templatePath)
+		if err != nil {
+			log.Fatalf("Не удалось прочитать шаблон %s: %v", *templatePath, err)
+		}
+		if err := json.Unmarshal(data, &opts.Template); err != nil {
+			log.Fatalf("Ошибка при разборе шаблона %s: %v", *templatePath, err)
+		}
+		if *bellsPath != "" {
+			opts.Bells = &models.BellSchedule{}
+			data, err := os.ReadFile(*bellsPath)
+			if err != nil {
+				log.Fatalf("Не удалось прочитать расписание звонков %s: %v", *bellsPath, err)
+			}
+			if err := json.Unmarshal(data, opts.Bells); err != nil {
+				log.Fatalf("Ошибка при разборе расписания звонков %s: %v", *bellsPath, err)
+			}
+			if err := opts.Bells.Validate(); err != nil {
+				log.Fatalf("Неверное расписание звонков %s: %v", *bellsPath, err)
+			}
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			log.Fatalf("Не удалось прочитать %s: %v", *file, err)
+		}
+		result, err := spreadsheet.ImportXLSX(store, f, info.Size(), opts)
+		if err != nil {
+			log.Fatalf("Ошибка при импорте %s: %v", *file, err)
+		}
+		reportSpreadsheet(result)
+
+	default:
+		log.Fatalf("Неизвестный формат файла: %s", *format)
+	}
+}
+
+// reportSpreadsheet выводит итог импорта таблицы
+func reportSpreadsheet(result *spreadsheet.ImportResult) {
+	for _, rowErr := range result.Errors {
+		log.Printf("Строка %d %s: %s", rowErr.Row, rowErr.Column, rowErr.Message)
+	}
+
+	switch {
+	case result.DryRun:
+		for _, event := range result.Events {
+			log.Printf("%s %s - %s %v", event.Title, event.StartTime.Format("Mon 02.01.2006 15:04"), event.EndTime.Format("15:04"), event.Tags)
+		}
+		log.Printf("Проверка: %d событий, %d ошибок; события не сохранены", len(result.Events), len(result.Errors))
+	case len(result.Errors) > 0 && result.Created+result.Updated+result.Unchanged == 0:
+		log.Fatalf("Импорт отменен: %d ошибок", len(result.Errors))
+	default:
+		log.Printf("Импортировано событий: %d новых, %d обновлено, %d без изменений", result.Created, result.Updated, result.Unchanged)
+	}
+}
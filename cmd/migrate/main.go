@@ -0,0 +1,245 @@
+// cmd/migrate/main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+)
+
+// Однократный перенос данных из JSON-файлов в базу SQLite: ресурсов
+// расписания, событий, профилей доступности, ссылок для записи
+// и состояния рассылки напоминаний. Повторный запуск безопасен: уже
+// перенесенные записи обновляются
+func main() {
+	from := flag.String("from", "data/events.json", "исходный JSON-файл с событиями")
+	to := flag.String("to", "data/events.db", "целевая база SQLite")
+	flag.Parse()
+
+	// NewStorage создает пустой файл, если его нет, поэтому отсутствие
+	// исходного файла проверяется заранее
+	if _, err := os.Stat(*from); err != nil {
+		log.Fatalf("Не удалось прочитать %s: %v", *from, err)
+	}
+
+	// Исходное хранилище открывается так же, как сервером: к снимку
+	// применяется журнал изменений, а дубликаты ID получают новые ID
+	source, err := storage.NewStorage(*from)
+	if err != nil {
+		log.Fatalf("Ошибка при открытии %s: %v", *from, err)
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(filepath.Dir(*to), 0755); err != nil {
+		log.Fatalf("Ошибка при создании директории данных: %v", err)
+	}
+
+	store, err := storage.NewSQLiteStore(*to)
+	if err != nil {
+		log.Fatalf("Ошибка при открытии базы данных: %v", err)
+	}
+	defer store.Close()
+
+	// Ресурсы переносятся до событий: база проверяет ссылки событий на них
+	steps := []struct {
+		name    string
+		migrate func(source, target storage.Backend) (int, int, error)
+	}{
+		{"ресурсов", migrateResources},
+		{"событий", migrateEvents},
+		{"профилей доступности", migrateProfiles},
+		{"ссылок для записи", migrateBookingLinks},
+		{"напоминаний", migrateReminders},
+	}
+	for _, step := range steps {
+		created, updated, err := step.migrate(source, store)
+		if err != nil {
+			log.Fatalf("Ошибка при переносе %s: %v", step.name, err)
+		}
+		log.Printf("Перенесено %s: %d новых, %d обновлено", step.name, created, updated)
+	}
+
+	log.Printf("Перенос завершен (%s -> %s)", *from, *to)
+}
+
+// migrateResources переносит ресурсы всех видов; существующий ресурс
+// с тем же ID заменяется независимо от его версии
+func migrateResources(source, target storage.Backend) (int, int, error) {
+	created, updated := 0, 0
+	for _, kind := range models.ResourceKinds {
+		resources, err := source.ListResources(kind)
+		if err != nil {
+			return created, updated, err
+		}
+		existing, err := target.ListResources(kind)
+		if err != nil {
+			return created, updated, err
+		}
+		stored := make(map[string]bool, len(existing))
+		for _, resource := range existing {
+			stored[resource.ID] = true
+		}
+
+		for _, resource := range resources {
+			if !stored[resource.ID] {
+				if err := target.CreateResource(resource); err != nil {
+					return created, updated, fmt.Errorf("ресурс %s: %w", resource.ID, err)
+				}
+				created++
+				continue
+			}
+
+			_, err := target.ModifyResource(kind, resource.ID, func(current *models.Resource) error {
+				version := current.Version
+				*current = *resource.Clone()
+				current.Version = version
+				return nil
+			})
+			if err != nil {
+				return created, updated, fmt.Errorf("ресурс %s: %w", resource.ID, err)
+			}
+			updated++
+		}
+	}
+
+	return created, updated, nil
+}
+
+// migrateEvents переносит события; повторный перенос заменяет событие
+// в базе независимо от его версии
+func migrateEvents(source, target storage.Backend) (int, int, error) {
+	events, err := source.List()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	created, updated := 0, 0
+	for _, event := range events {
+		isNew, err := storage.Upsert(target, event)
+		if err != nil {
+			return created, updated, fmt.Errorf("событие %s: %w", event.ID, err)
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	return created, updated, nil
+}
+
+// migrateProfiles переносит профили доступности. SaveProfile сверяет
+// версию, поэтому профиль сохраняется с версией, хранимой в базе
+func migrateProfiles(source, target storage.Backend) (int, int, error) {
+	profiles, err := source.ListProfiles()
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := target.ListProfiles()
+	if err != nil {
+		return 0, 0, err
+	}
+	versions := make(map[string]int64, len(existing))
+	for _, profile := range existing {
+		versions[profile.ID] = profile.Version
+	}
+
+	created, updated := 0, 0
+	for _, profile := range profiles {
+		version, exists := versions[profile.ID]
+		profile.Version = version
+		if err := target.SaveProfile(profile); err != nil {
+			return created, updated, fmt.Errorf("профиль %s: %w", profile.ID, err)
+		}
+		if exists {
+			updated++
+		} else {
+			created++
+		}
+	}
+
+	return created, updated, nil
+}
+
+// migrateBookingLinks переносит ссылки для записи так же, как профили
+func migrateBookingLinks(source, target storage.Backend) (int, int, error) {
+	links, err := source.ListBookingLinks()
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := target.ListBookingLinks()
+	if err != nil {
+		return 0, 0, err
+	}
+	versions := make(map[string]int64, len(existing))
+	for _, link := range existing {
+		versions[link.ID] = link.Version
+	}
+
+	created, updated := 0, 0
+	for _, link := range links {
+		version, exists := versions[link.ID]
+		link.Version = version
+		if err := target.SaveBookingLink(link); err != nil {
+			return created, updated, fmt.Errorf("ссылка %s: %w", link.ID, err)
+		}
+		if exists {
+			updated++
+		} else {
+			created++
+		}
+	}
+
+	return created, updated, nil
+}
+
+// migrateReminders переносит сработавшие напоминания и момент, до которого
+// они разосланы, чтобы после переключения на базу рассылка не повторилась.
+// Более поздний момент в базе сохраняется
+func migrateReminders(source, target storage.Backend) (int, int, error) {
+	reminders, err := source.ListReminders()
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := target.ListReminders()
+	if err != nil {
+		return 0, 0, err
+	}
+	stored := make(map[string]bool, len(existing))
+	for _, reminder := range existing {
+		stored[reminder.ID] = true
+	}
+
+	created, updated := 0, 0
+	for _, reminder := range reminders {
+		if err := target.SaveReminder(reminder); err != nil {
+			return created, updated, fmt.Errorf("напоминание %s: %w", reminder.ID, err)
+		}
+		if stored[reminder.ID] {
+			updated++
+		} else {
+			created++
+		}
+	}
+
+	checkpoint, err := source.ReminderCheckpoint()
+	if err != nil {
+		return created, updated, err
+	}
+	current, err := target.ReminderCheckpoint()
+	if err != nil {
+		return created, updated, err
+	}
+	if checkpoint.After(current) {
+		if err := target.SetReminderCheckpoint(checkpoint); err != nil {
+			return created, updated, err
+		}
+	}
+
+	return created, updated, nil
+}
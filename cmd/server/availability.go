@@ -0,0 +1,225 @@
+// cmd/server/availability.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// profile возвращает профиль доступности по ID. Пока общий профиль
+// не сохранен, вместо него используется models.DefaultAvailability
+func (s *server) profile(id string) (*models.AvailabilityProfile, error) {
+	profile, err := s.profiles.GetProfile(id)
+	if errors.Is(err, storage.ErrNotFound) && id == models.DefaultProfileID {
+		return models.DefaultAvailability(), nil
+	}
+	return profile, err
+}
+
+// writeProfileError отвечает на ошибку s.profile: отсутствие профиля -
+// статусом status с сообщением message, сбой хранилища - 500
+func writeProfileError(w http.ResponseWriter, err error, status int, message string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(w, status, message)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "Не удалось получить профиль доступности")
+}
+
+// profilesHandler возвращает список сохраненных профилей доступности
+func (s *server) profilesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	profiles, err := s.profiles.ListProfiles()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить профили доступности")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"profiles": profiles,
+		"count":    len(profiles),
+	})
+}
+
+// profileByIDHandler обрабатывает запросы к профилю доступности по ID
+func (s *server) profileByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/availability/profiles/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusBadRequest, "ID профиля не указан")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, err := s.profile(id)
+		if err != nil {
+			writeProfileError(w, err, http.StatusNotFound, "Профиль доступности не найден")
+			return
+		}
+		w.Header().Set("ETag", etag(profile.Version))
+		writeJSON(w, http.StatusOK, profile)
+	case http.MethodPut:
+		s.saveProfile(w, r, id)
+	case http.MethodDelete:
+		s.deleteProfile(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+	}
+}
+
+// saveProfile создает профиль или заменяет его целиком. Замена существующего
+// профиля, как и изменение событий, требует заголовка If-Match
+func (s *server) saveProfile(w http.ResponseWriter, r *http.Request, id string) {
+	var profile models.AvailabilityProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON: "+err.Error())
+		return
+	}
+	profile.ID = id
+	profile.Version = 0
+
+	current, err := s.profiles.GetProfile(id)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить профиль доступности")
+		return
+	}
+	created := err != nil
+	if !created {
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+		profile.Version = current.Version
+	}
+
+	if err := s.profiles.SaveProfile(&profile); err != nil {
+		writeStoreError(w, err, "Не удалось сохранить профиль доступности")
+		return
+	}
+
+	status, message := http.StatusOK, "Профиль доступности успешно обновлен"
+	if created {
+		status, message = http.StatusCreated, "Профиль доступности успешно создан"
+	}
+	w.Header().Set("ETag", etag(profile.Version))
+	writeJSON(w, status, map[string]interface{}{
+		"message": message,
+		"profile": profile,
+	})
+}
+
+// deleteProfile удаляет профиль доступности
+func (s *server) deleteProfile(w http.ResponseWriter, r *http.Request, id string) {
+	current, err := s.profiles.GetProfile(id)
+	if err != nil {
+		writeProfileError(w, err, http.StatusNotFound, "Профиль доступности не найден")
+		return
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return
+	}
+
+	if err := s.profiles.DeleteProfile(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось удалить профиль доступности")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Профиль доступности успешно удален",
+		"id":      id,
+	})
+}
+
+// availabilityHandler возвращает свободное рабочее время за период
+// ?from=...&to=...: рабочее время профиля ?profile= (по умолчанию общий)
+// за вычетом событий хранилища. Занятость отбирается так же, как для
+// /api/freebusy (?ignoreTags=, ?group=, ?teacher=, ?room=); ?buffer=
+// оставляет свободные минуты до и после каждого события
+func (s *server) availabilityHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	query := r.URL.Query()
+	profileID := query.Get("profile")
+	if profileID == "" {
+		profileID = models.DefaultProfileID
+	}
+	profile, err := s.profile(profileID)
+	if err != nil {
+		writeProfileError(w, err, http.StatusNotFound, "Профиль доступности не найден")
+		return
+	}
+
+	// Даты без времени трактуются в поясе профиля, если ?tz= не указан
+	loc := profile.Location(s.location)
+	if query.Get("tz") != "" {
+		if loc, err = s.requestLocation(r); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	from, to, err := parseRange(r, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var buffer time.Duration
+	if value := query.Get("buffer"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			writeError(w, http.StatusBadRequest, "Неверный отступ: ожидается число минут")
+			return
+		}
+		buffer = time.Duration(minutes) * time.Minute
+	}
+
+	busy, err := s.busyIntervals(requestBusyFilter(r), from, to, buffer)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return
+	}
+
+	working := profile.Windows(from, to, s.location)
+	available := models.Subtract(working, busy)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"profile":          profile.ID,
+		"from":             from.In(loc),
+		"to":               to.In(loc),
+		"working":          localIntervals(working, loc),
+		"available":        localIntervals(available, loc),
+		"workingMinutes":   totalMinutes(working),
+		"availableMinutes": totalMinutes(available),
+	})
+}
+
+// localIntervals переводит интервалы в часовой пояс loc для ответа;
+// nil превращается в пустой список
+func localIntervals(intervals []models.Interval, loc *time.Location) []models.Interval {
+	local := make([]models.Interval, len(intervals))
+	for i, interval := range intervals {
+		local[i] = models.Interval{Start: interval.Start.In(loc), End: interval.End.In(loc)}
+	}
+	return local
+}
+
+// totalMinutes возвращает суммарную длительность интервалов в минутах
+func totalMinutes(intervals []models.Interval) int {
+	var total time.Duration
+	for _, interval := range intervals {
+		total += interval.End.Sub(interval.Start)
+	}
+	return int(total / time.Minute)
+}
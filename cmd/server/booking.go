@@ -0,0 +1,303 @@
+// cmd/server/booking.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"schedule-app/internal/models"
+	"strings"
+	"time"
+)
+
+// bookingLinksHandler возвращает список ссылок для самостоятельной записи
+func (s *server) bookingLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	links, err := s.bookings.ListBookingLinks()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить ссылки для записи")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"links": links,
+		"count": len(links),
+	})
+}
+
+// bookingLinkByIDHandler обрабатывает запросы к ссылке для записи по ID
+func (s *server) bookingLinkByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/booking-links/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusBadRequest, "ID ссылки не указан")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		link, err := s.bookings.GetBookingLink(id)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "Ссылка для записи не найдена")
+			return
+		}
+		w.Header().Set("ETag", etag(link.Version))
+		writeJSON(w, http.StatusOK, link)
+	case http.MethodPut:
+		s.saveBookingLink(w, r, id)
+	case http.MethodDelete:
+		s.deleteBookingLink(w, r, id)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+	}
+}
+
+// saveBookingLink создает ссылку или заменяет ее целиком (с заголовком
+// If-Match, как и профиль доступности)
+func (s *server) saveBookingLink(w http.ResponseWriter, r *http.Request, id string) {
+	var link models.BookingLink
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON: "+err.Error())
+		return
+	}
+	link.ID = id
+	link.Version = 0
+
+	if _, err := s.profile(link.ProfileID()); err != nil {
+		writeProfileError(w, err, http.StatusBadRequest, "Профиль доступности не найден: "+link.ProfileID())
+		return
+	}
+
+	current, err := s.bookings.GetBookingLink(id)
+	created := err != nil
+	if !created {
+		if !checkIfMatch(w, r, current.Version) {
+			return
+		}
+		link.Version = current.Version
+	}
+
+	if err := s.bookings.SaveBookingLink(&link); err != nil {
+		writeStoreError(w, err, "Не удалось сохранить ссылку для записи")
+		return
+	}
+
+	status, message := http.StatusOK, "Ссылка для записи успешно обновлена"
+	if created {
+		status, message = http.StatusCreated, "Ссылка для записи успешно создана"
+	}
+	w.Header().Set("ETag", etag(link.Version))
+	writeJSON(w, status, map[string]interface{}{
+		"message": message,
+		"link":    link,
+	})
+}
+
+// deleteBookingLink удаляет ссылку для записи; созданные по ней события остаются
+func (s *server) deleteBookingLink(w http.ResponseWriter, r *http.Request, id string) {
+	current, err := s.bookings.GetBookingLink(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ссылка для записи не найдена")
+		return
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return
+	}
+
+	if err := s.bookings.DeleteBookingLink(id); err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось удалить ссылку для записи")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Ссылка для записи успешно удалена",
+		"id":      id,
+	})
+}
+
+// bookHandler - публичная страница записи /api/book/{id}: GET возвращает
+// описание ссылки и свободное время, POST записывает на выбранное время.
+// Настройки ссылки (профиль, тег, ограничения) посетителю не показываются
+func (s *server) bookHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/book/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusBadRequest, "ID ссылки не указан")
+		return
+	}
+
+	link, err := s.bookings.GetBookingLink(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ссылка для записи не найдена")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.bookingPage(w, link)
+	case http.MethodPost:
+		s.book(w, r, link)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+	}
+}
+
+// bookingSlots возвращает свободное время ссылки относительно момента now
+// и пояс, в котором считаются дни записи
+func (s *server) bookingSlots(link *models.BookingLink, now time.Time) ([]models.Slot, *time.Location, error) {
+	profile, err := s.profile(link.ProfileID())
+	if err != nil {
+		return nil, nil, fmt.Errorf("профиль доступности %s: %w", link.ProfileID(), err)
+	}
+	loc := link.Location(profile.Location(s.location))
+
+	from, to := link.Window(now, loc)
+	if !from.Before(to) {
+		return nil, loc, nil
+	}
+
+	// Записи за день считаются с его начала, даже если утро уже прошло
+	year, month, day := from.In(loc).Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	buffer := time.Duration(link.Buffer) * time.Minute
+	events, err := s.store.Range(dayStart.Add(-buffer), to.Add(buffer))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var busy []*models.Event
+	for _, event := range events {
+		if (busyFilter{}).busy(event) {
+			busy = append(busy, event)
+		}
+	}
+
+	working := profile.Windows(from, to, s.location)
+	slots := link.Slots(working, models.BusyIntervals(busy, buffer), link.Booked(events, loc), loc)
+	return slots, loc, nil
+}
+
+// bookingPage отвечает на GET /api/book/{id}: свободное время по порядку
+func (s *server) bookingPage(w http.ResponseWriter, link *models.BookingLink) {
+	slots, loc, err := s.bookingSlots(link, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить свободное время")
+		return
+	}
+
+	intervals := make([]models.Interval, len(slots))
+	for i, slot := range slots {
+		intervals[i] = models.Interval{Start: slot.Start.In(loc), End: slot.End.In(loc)}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          link.ID,
+		"title":       link.Title,
+		"description": link.Description,
+		"duration":    link.Duration,
+		"timeZone":    loc.String(),
+		"slots":       intervals,
+		"count":       len(intervals),
+	})
+}
+
+// bookingRequest - тело запроса на запись по ссылке
+type bookingRequest struct {
+	Start   string `json:"start"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Comment string `json:"comment"`
+}
+
+// book записывает посетителя на время start: создает событие с тегом ссылки.
+// Время должно быть одним из предложенных вариантов; занятость и число
+// записей за день повторно проверяются под блокировкой хранилища, поэтому
+// двое посетителей не могут записаться на одно время (второй получит 409)
+func (s *server) book(w http.ResponseWriter, r *http.Request, link *models.BookingLink) {
+	var requestData bookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	name := strings.TrimSpace(requestData.Name)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Имя обязательно")
+		return
+	}
+	email := strings.TrimSpace(requestData.Email)
+	if email != "" {
+		if _, err := mail.ParseAddress(email); err != nil {
+			writeError(w, http.StatusBadRequest, "Неверный адрес электронной почты")
+			return
+		}
+	}
+	if requestData.Start == "" {
+		writeError(w, http.StatusBadRequest, "Время начала обязательно")
+		return
+	}
+
+	slots, loc, err := s.bookingSlots(link, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить свободное время")
+		return
+	}
+	start, err := parseTimeParam(requestData.Start, loc, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offered := false
+	for _, slot := range slots {
+		if slot.Start.Equal(start) {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		writeError(w, http.StatusConflict, "Выбранное время недоступно для записи")
+		return
+	}
+	end := start.Add(time.Duration(link.Duration) * time.Minute)
+
+	details := "Имя: " + name
+	if email != "" {
+		details += "\nEmail: " + email
+	}
+	if comment := strings.TrimSpace(requestData.Comment); comment != "" {
+		details += "\n\n" + comment
+	}
+	event := models.NewEvent(link.Title+": "+name, start.In(loc), end.In(loc),
+		[]string{link.EventTag()}, models.EventDetails{Description: details})
+	event.TimeZone = loc.String()
+	if err := event.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, to := link.BookingRange(start, end, loc)
+	err = s.store.CreateChecked(event, from, to, func(existing []*models.Event) error {
+		return link.CheckBooking(start, end, existing, loc)
+	})
+	switch {
+	case errors.Is(err, models.ErrSlotTaken), errors.Is(err, models.ErrDayFull):
+		writeError(w, http.StatusConflict, "Не удалось записаться: "+err.Error())
+		return
+	case err != nil:
+		writeStoreError(w, err, "Не удалось записаться")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Вы успешно записаны",
+		"id":      event.ID,
+		"title":   link.Title,
+		"start":   event.StartTime,
+		"end":     event.EndTime,
+	})
+}
@@ -0,0 +1,127 @@
+// cmd/server/booking_test.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newBookingServer создает сервер в памяти с профилем, открытым каждый день
+// с 00:00 до 23:00 по UTC, и ссылкой для записи link
+func newBookingServer(t *testing.T, link *models.BookingLink) http.Handler {
+	t.Helper()
+	store := storage.NewMemoryStore()
+
+	hours := []models.ClockRange{{Start: "00:00", End: "23:00"}}
+	profile := &models.AvailabilityProfile{
+		ID:       models.DefaultProfileID,
+		TimeZone: "UTC",
+		Weekly:   map[string][]models.ClockRange{"mon": hours, "tue": hours, "wed": hours, "thu": hours, "fri": hours, "sat": hours, "sun": hours},
+	}
+	if err := store.SaveProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveBookingLink(link); err != nil {
+		t.Fatal(err)
+	}
+
+	return newServer(store, time.UTC).routes()
+}
+
+// bookingSlots возвращает начала предложенных вариантов записи по ссылке id
+func bookingSlots(t *testing.T, handler http.Handler, id string) []time.Time {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/book/"+id, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/book/%s: код %d: %s", id, rec.Code, rec.Body)
+	}
+
+	var page struct {
+		Slots []models.Interval `json:"slots"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	starts := make([]time.Time, len(page.Slots))
+	for i, slot := range page.Slots {
+		starts[i] = slot.Start
+	}
+	return starts
+}
+
+// bookConcurrently одновременно отправляет запись на каждое из starts
+// и возвращает отсортированные коды ответов
+func bookConcurrently(handler http.Handler, id string, starts ...time.Time) []int {
+	codes := make([]int, len(starts))
+	var wg sync.WaitGroup
+	for i, start := range starts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := `{"name":"Иван","start":"` + start.Format(time.RFC3339) + `"}`
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/book/"+id, strings.NewReader(body)))
+			codes[i] = rec.Code
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(codes)
+	return codes
+}
+
+func TestBookSameSlotConcurrently(t *testing.T) {
+	handler := newBookingServer(t, &models.BookingLink{ID: "consult", Title: "Консультация", Duration: 30, WindowDays: 3})
+
+	slots := bookingSlots(t, handler, "consult")
+	if len(slots) == 0 {
+		t.Fatal("нет вариантов записи")
+	}
+
+	want := []int{http.StatusCreated, http.StatusConflict}
+	if codes := bookConcurrently(handler, "consult", slots[0], slots[0]); !reflect.DeepEqual(codes, want) {
+		t.Errorf("коды ответов %v, ожидалось %v", codes, want)
+	}
+	if after := bookingSlots(t, handler, "consult"); len(after) > 0 && after[0].Equal(slots[0]) {
+		t.Error("занятое время по-прежнему предлагается")
+	}
+}
+
+func TestBookMaxPerDay(t *testing.T) {
+	handler := newBookingServer(t, &models.BookingLink{ID: "consult", Title: "Консультация", Duration: 30, WindowDays: 3, MaxPerDay: 1})
+
+	// Два варианта одного дня: вторая запись превысила бы MaxPerDay
+	slots := bookingSlots(t, handler, "consult")
+	var day []time.Time
+	for _, start := range slots {
+		if len(day) == 0 || start.Format(models.DateLayout) == day[0].Format(models.DateLayout) {
+			day = append(day, start)
+		}
+		if len(day) == 2 {
+			break
+		}
+	}
+	if len(day) < 2 {
+		t.Fatalf("нет двух вариантов записи в один день: %v", slots)
+	}
+
+	want := []int{http.StatusCreated, http.StatusConflict}
+	if codes := bookConcurrently(handler, "consult", day...); !reflect.DeepEqual(codes, want) {
+		t.Errorf("коды ответов %v, ожидалось %v", codes, want)
+	}
+	for _, start := range bookingSlots(t, handler, "consult") {
+		if start.Format(models.DateLayout) == day[0].Format(models.DateLayout) {
+			t.Fatalf("после записи предлагается время %v в заполненный день", start)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// cmd/server/conflicts.go
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTagList разбирает список тегов через запятую
+func parseTagList(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// conflictError - двойное бронирование, найденное при сохранении события
+// без ?force=true; writeStoreError отвечает на нее кодом 409 со списком конфликтов
+type conflictError struct {
+	conflicts []models.Conflict
+}
+
+func (e *conflictError) Error() string {
+	return "ресурсы уже заняты в это время"
+}
+
+// parseForce разбирает параметр ?force=true, подтверждающий сохранение
+// события, несмотря на двойное бронирование. При ошибке отправляет ответ
+// и возвращает false
+func parseForce(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	value := r.URL.Query().Get("force")
+	if value == "" {
+		return false, true
+	}
+
+	force, err := strconv.ParseBool(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверное значение force: ожидается true или false")
+		return false, false
+	}
+	return force, true
+}
+
+// conflictWindow возвращает окно событий, которые нужны checkConflicts
+// для проверки event (см. storage.ConflictWindow)
+func (s *server) conflictWindow(event *models.Event) (time.Time, time.Time) {
+	return storage.ConflictWindow(event, s.exclusiveTags, s.semester)
+}
+
+// checkConflicts проверяет, не занимает ли event ресурсы (или исключительные
+// теги) событий existing в то же время. Вызывается из проверки CreateChecked,
+// ModifyChecked или Split, поэтому между проверкой и записью хранилище
+// не меняется. Событие с ID из ignore не считается конфликтующим. При двойном
+// бронировании без force возвращает *conflictError; с force отмечает
+// в event.ConflictOverride, что пересечения подтверждены
+func (s *server) checkConflicts(event *models.Event, existing []*models.Event, force bool, ignore ...string) error {
+	conflicts := storage.Conflicts(event, existing, s.exclusiveTags, s.semester, ignore...)
+	if len(conflicts) > 0 && !force {
+		return &conflictError{conflicts: conflicts}
+	}
+
+	event.ConflictOverride = len(conflicts) > 0
+	return nil
+}
+
+// checkReflowConflicts возвращает проверку для storage.ApplyBells: ищет
+// пересечения занятий, перенесенных по новому расписанию звонков, с событиями
+// на их новом месте и собирает их в found (пересечение двух перенесенных
+// занятий - один раз). При пересечениях без force возвращает *conflictError;
+// с force отмечает перенесенные занятия ConflictOverride, как checkConflicts
+func (s *server) checkReflowConflicts(force bool, found *[]models.Conflict) storage.BatchCheck {
+	return func(changed []*models.Event, rangeEvents func(from, to time.Time) ([]*models.Event, error)) error {
+		seen := make(map[[2]string]bool)
+		for _, event := range changed {
+			from, to := s.conflictWindow(event)
+			existing, err := rangeEvents(from, to)
+			if err != nil {
+				return err
+			}
+
+			conflicts := storage.Conflicts(event, existing, s.exclusiveTags, s.semester, event.ID)
+			for _, conflict := range conflicts {
+				pair := [2]string{conflict.Event.ID, conflict.With.ID}
+				if seen[[2]string{pair[1], pair[0]}] {
+					continue
+				}
+				seen[pair] = true
+				*found = append(*found, conflict)
+			}
+			event.ConflictOverride = len(conflicts) > 0
+		}
+
+		if len(*found) > 0 && !force {
+			return &conflictError{conflicts: *found}
+		}
+		return nil
+	}
+}
+
+// checkUpdateConflicts проверяет пересечения измененного события, если запрос
+// меняет время, ресурсы или теги. Иначе событие сохраняет прежнюю отметку
+// ConflictOverride: правка описания не требует ?force=true
+func (s *server) checkUpdateConflicts(req *updateRequest, event *models.Event, existing []*models.Event, force bool, ignore string) error {
+	if !req.reschedules() {
+		return nil
+	}
+	return s.checkConflicts(event, existing, force, ignore)
+}
+
+// updateConflictWindow возвращает окно событий для checkUpdateConflicts:
+// пустое, если запрос не меняет пересечений candidate - события
+// с примененными изменениями
+func (s *server) updateConflictWindow(req *updateRequest, candidate *models.Event) (time.Time, time.Time) {
+	if !req.reschedules() {
+		return time.Time{}, time.Time{}
+	}
+	return s.conflictWindow(candidate)
+}
+
+// reschedules сообщает, меняет ли запрос поля, от которых зависят
+// пересечения: время, повторение, четность недели, ресурсы или теги
+func (req *updateRequest) reschedules() bool {
+	return req.StartTime != nil || req.EndTime != nil || req.AllDay != nil ||
+		req.Recurrence != nil || req.TimeZone != nil || req.WeekParity != nil ||
+		req.Slot != nil || req.Date != nil || req.Tags != nil ||
+		req.Groups != nil || req.Teachers != nil || req.Rooms != nil
+}
+
+// conflictDay - пересечения событий за один календарный день
+type conflictDay struct {
+	Date           string                  `json:"date"`
+	Clusters       []models.OverlapCluster `json:"clusters"`
+	OverlapMinutes int                     `json:"overlapMinutes"`
+}
+
+// conflictsHandler возвращает отчет о пересечениях событий за период
+// (?from=...&to=... или ?date=, как для /api/events): группы пересекающихся
+// событий по дням начала группы с суммарным временем пересечений. Отчет можно
+// сузить до тега (?tag=) или ресурса (?group=, ?teacher=, ?room=). События
+// на весь день не учитываются, если не указан ?allDay=true
+func (s *server) conflictsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, ok := s.requestEvents(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	includeAllDay, _ := strconv.ParseBool(query.Get("allDay"))
+	tag := query.Get("tag")
+
+	var selected []*models.Event
+	for _, event := range events {
+		if event.AllDay && !includeAllDay {
+			continue
+		}
+		if tag != "" && !hasTag(event, tag) {
+			continue
+		}
+		if !usesRequestedResources(event, query) {
+			continue
+		}
+		selected = append(selected, event)
+	}
+
+	days := []*conflictDay{}
+	pairs, total := 0, 0
+	for _, cluster := range models.FindOverlaps(selected) {
+		date := cluster.Start.In(loc).Format(models.DateLayout)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, &conflictDay{Date: date})
+		}
+		day := days[len(days)-1]
+		day.Clusters = append(day.Clusters, cluster)
+		day.OverlapMinutes += cluster.OverlapMinutes
+
+		pairs += len(cluster.Pairs)
+		total += cluster.OverlapMinutes
+	}
+
+	clusters := 0
+	for _, day := range days {
+		clusters += len(day.Clusters)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"days":           days,
+		"clusterCount":   clusters,
+		"pairCount":      pairs,
+		"overlapMinutes": total,
+	})
+}
+
+// hasTag сообщает, есть ли у события тег (без учета регистра)
+func hasTag(event *models.Event, tag string) bool {
+	for _, eventTag := range event.Tags {
+		if strings.EqualFold(eventTag, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesRequestedResources сообщает, ссылается ли событие на все ресурсы,
+// указанные в параметрах ?group=, ?teacher= и ?room=
+func usesRequestedResources(event *models.Event, query url.Values) bool {
+	for _, kind := range models.ResourceKinds {
+		if id := query.Get(string(kind)); id != "" && !event.Uses(kind, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// cmd/server/events_test.go
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventIfMatch(t *testing.T) {
+	store := storage.NewMemoryStore()
+	handler := newServer(store, time.UTC).routes()
+
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	event := models.NewEvent("Лекция", start, start.Add(90*time.Minute), nil, models.EventDetails{})
+	if err := store.Create(event); err != nil {
+		t.Fatal(err)
+	}
+	// Версия 2: ETag версии 1 устарел
+	if _, err := store.Modify(event.ID, func(event *models.Event) error {
+		event.Place = "Ауд. 101"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		want    int
+	}{
+		{name: "update without If-Match", method: http.MethodPut, want: http.StatusPreconditionRequired},
+		{name: "update with stale version", method: http.MethodPut, ifMatch: etag(1), want: http.StatusPreconditionFailed},
+		{name: "delete without If-Match", method: http.MethodDelete, want: http.StatusPreconditionRequired},
+		{name: "delete with stale version", method: http.MethodDelete, ifMatch: etag(1), want: http.StatusPreconditionFailed},
+		{name: "update with current version", method: http.MethodPut, ifMatch: etag(2), want: http.StatusOK},
+		{name: "delete with current version", method: http.MethodDelete, ifMatch: etag(3), want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := ""
+			if tt.method == http.MethodPut {
+				body = `{"title":"Лекция (перенесена)"}`
+			}
+			req := httptest.NewRequest(tt.method, "/api/events/"+event.ID, strings.NewReader(body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("код %d, ожидалось %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusPreconditionFailed && rec.Header().Get("ETag") != etag(2) {
+				t.Errorf("ETag %q, ожидалось %q", rec.Header().Get("ETag"), etag(2))
+			}
+		})
+	}
+
+	if _, err := store.Get(event.ID); err == nil {
+		t.Error("событие не удалено")
+	}
+}
@@ -0,0 +1,282 @@
+// cmd/server/freebusy.go
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"schedule-app/internal/models"
+	"time"
+)
+
+// Значения по умолчанию для поиска свободного времени
+const (
+	defaultSlotStep  = 15 * time.Minute
+	defaultSlotLimit = 10
+	maxSlotWindow    = 92 * 24 * time.Hour
+)
+
+// busyFilter отбирает события, которые занимают время. События на весь день
+// (праздники, дедлайны) время не занимают; события с тегами из ignoreTags
+// не учитываются. Если заданы ресурсы, учитываются только события хотя бы
+// с одним из них
+type busyFilter struct {
+	ignoreTags []string
+	resources  []models.ResourceRef
+}
+
+// busy сообщает, занимает ли событие время
+func (f busyFilter) busy(event *models.Event) bool {
+	if event.AllDay {
+		return false
+	}
+	for _, tag := range f.ignoreTags {
+		if hasTag(event, tag) {
+			return false
+		}
+	}
+	if len(f.resources) == 0 {
+		return true
+	}
+	for _, ref := range f.resources {
+		if event.Uses(ref.Kind, ref.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// busyIntervals возвращает занятое время в окне [from, to) с отступом buffer
+// вокруг каждого события
+func (s *server) busyIntervals(filter busyFilter, from, to time.Time, buffer time.Duration) ([]models.Interval, error) {
+	events, err := s.store.Range(from.Add(-buffer), to.Add(buffer))
+	if err != nil {
+		return nil, err
+	}
+
+	var busy []*models.Event
+	for _, event := range events {
+		if filter.busy(event) {
+			busy = append(busy, event)
+		}
+	}
+	return models.BusyIntervals(busy, buffer), nil
+}
+
+// freeBusyHandler возвращает занятые интервалы за период ?from=...&to=...:
+// пересекающиеся события объединяются. ?ignoreTags= (через запятую)
+// исключает события с этими тегами, ?group=, ?teacher= и ?room= ограничивают
+// занятость событиями этих ресурсов
+func (s *server) freeBusyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, to, err := parseRange(r, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	busy, err := s.busyIntervals(requestBusyFilter(r), from, to, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return
+	}
+	busy = models.Clip(busy, from, to)
+	busyMinutes := totalMinutes(busy)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":        from.In(loc),
+		"to":          to.In(loc),
+		"busy":        localIntervals(busy, loc),
+		"busyMinutes": busyMinutes,
+		"freeMinutes": int(to.Sub(from)/time.Minute) - busyMinutes,
+	})
+}
+
+// requestBusyFilter строит отбор занятости из параметров ?ignoreTags=
+// (через запятую), ?group=, ?teacher= и ?room=
+func requestBusyFilter(r *http.Request) busyFilter {
+	query := r.URL.Query()
+	filter := busyFilter{ignoreTags: parseTagList(query.Get("ignoreTags"))}
+	for _, kind := range models.ResourceKinds {
+		if id := query.Get(string(kind)); id != "" {
+			filter.resources = append(filter.resources, models.ResourceRef{Kind: kind, ID: id})
+		}
+	}
+	return filter
+}
+
+// slotsRequest - тело запроса на поиск свободного времени
+type slotsRequest struct {
+	// Duration, Buffer и Step - в минутах. Buffer - свободное время, которое
+	// должно остаться до и после соседних событий
+	Duration int `json:"duration"`
+	Buffer   int `json:"buffer"`
+	Step     int `json:"step"`
+	// From и To - окно поиска; дата без времени в To включает весь день
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Profile - профиль доступности, рабочее время которого задает поиск
+	// вместо WorkingHours и Weekdays
+	Profile string `json:"profile"`
+	// WorkingHours - рабочие часы каждого дня, по умолчанию 09:00-18:00
+	WorkingHours struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	} `json:"workingHours"`
+	// Weekdays - дни недели (1 - понедельник, 7 - воскресенье), по умолчанию все
+	Weekdays   []int    `json:"weekdays"`
+	IgnoreTags []string `json:"ignoreTags"`
+	// Groups, Teachers и Rooms - ресурсы, которые должны быть свободны
+	// одновременно; без них учитываются все события
+	Groups   []string `json:"groups"`
+	Teachers []string `json:"teachers"`
+	Rooms    []string `json:"rooms"`
+	TimeZone string   `json:"timeZone"`
+	Limit    int      `json:"limit"`
+}
+
+// findSlotsHandler ищет свободное время заданной длительности по событиям
+// хранилища и возвращает варианты в порядке предпочтения (см. models.FindSlots)
+func (s *server) findSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	var requestData slotsRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	loc := s.location
+	if requestData.TimeZone != "" {
+		var err error
+		if loc, err = models.LoadLocation(requestData.TimeZone); err != nil {
+			writeError(w, http.StatusBadRequest, "Неизвестный часовой пояс: "+requestData.TimeZone)
+			return
+		}
+	}
+
+	if requestData.From == "" || requestData.To == "" {
+		writeError(w, http.StatusBadRequest, "Поля from и to обязательны")
+		return
+	}
+	from, err := parseTimeParam(requestData.From, loc, false)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseTimeParam(requestData.To, loc, true)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if to.Sub(from) > maxSlotWindow {
+		writeError(w, http.StatusBadRequest, "Окно поиска не может быть длиннее 92 дней")
+		return
+	}
+
+	query := models.SlotQuery{
+		From:     from,
+		To:       to,
+		Duration: time.Duration(requestData.Duration) * time.Minute,
+		DayStart: requestData.WorkingHours.Start,
+		DayEnd:   requestData.WorkingHours.End,
+		Step:     time.Duration(requestData.Step) * time.Minute,
+		Location: loc,
+	}
+	if query.DayStart == "" {
+		query.DayStart = "09:00"
+	}
+	if query.DayEnd == "" {
+		query.DayEnd = "18:00"
+	}
+	if requestData.Step == 0 {
+		query.Step = defaultSlotStep
+	}
+	for _, day := range requestData.Weekdays {
+		if day < 1 || day > 7 {
+			writeError(w, http.StatusBadRequest, "День недели должен быть от 1 (понедельник) до 7 (воскресенье)")
+			return
+		}
+		query.Weekdays = append(query.Weekdays, time.Weekday(day%7))
+	}
+	if requestData.Profile != "" {
+		profile, err := s.profile(requestData.Profile)
+		if err != nil {
+			writeProfileError(w, err, http.StatusBadRequest, "Профиль доступности не найден: "+requestData.Profile)
+			return
+		}
+		// Пустой, но не nil список: профиль без рабочего времени в окне
+		// не должен заменяться рабочими часами по умолчанию
+		query.Windows = append([]models.Interval{}, profile.Windows(from, to, s.location)...)
+	}
+	if requestData.Buffer < 0 {
+		writeError(w, http.StatusBadRequest, "Отступ не может быть отрицательным")
+		return
+	}
+	if err := query.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := busyFilter{ignoreTags: requestData.IgnoreTags}
+	refs := map[models.ResourceKind][]string{
+		models.ResourceGroup:   requestData.Groups,
+		models.ResourceTeacher: requestData.Teachers,
+		models.ResourceRoom:    requestData.Rooms,
+	}
+	for _, kind := range models.ResourceKinds {
+		for _, id := range refs[kind] {
+			filter.resources = append(filter.resources, models.ResourceRef{Kind: kind, ID: id})
+		}
+	}
+	if err := s.checkResourceRefs(requestData.Groups, requestData.Teachers, requestData.Rooms); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	buffer := time.Duration(requestData.Buffer) * time.Minute
+	busy, err := s.busyIntervals(filter, from, to, buffer)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return
+	}
+
+	slots, err := models.FindSlots(busy, query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := requestData.Limit
+	if limit <= 0 {
+		limit = defaultSlotLimit
+	}
+	total := len(slots)
+	if len(slots) > limit {
+		slots = slots[:limit]
+	}
+	for i := range slots {
+		slots[i].Start = slots[i].Start.In(loc)
+		slots[i].End = slots[i].End.In(loc)
+	}
+	if slots == nil {
+		slots = []models.Slot{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"slots": slots,
+		"count": len(slots),
+		"total": total,
+	})
+}
@@ -2,58 +2,222 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"schedule-app/internal/ical"
+	"schedule-app/internal/markdown"
 	"schedule-app/internal/models"
+	"schedule-app/internal/reminders"
+	"schedule-app/internal/spreadsheet"
 	"schedule-app/internal/storage"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
 func main() {
-	// Инициализация хранилища
-	storagePath := "data/events.json"
-	if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
-		log.Fatalf("Ошибка при создании директории данных: %v", err)
+	backend := flag.String("storage", "json", "хранилище событий: json, sqlite или memory")
+	storagePath := flag.String("data", "", "путь к файлу данных (по умолчанию data/events.json или data/events.db)")
+	port := flag.String("addr", ":8080", "адрес HTTP-сервера")
+	timeZone := flag.String("tz", "Europe/Moscow", "часовой пояс по умолчанию (IANA) для событий и дат в запросах")
+	semesterPath := flag.String("semester", "", "JSON-файл с учебным семестром: {\"start\", \"end\", \"holidays\"}")
+	bellsPath := flag.String("bells", "", "JSON-файл с расписанием звонков: {\"slots\": [{\"number\", \"start\", \"end\"}]}")
+	exclusiveTags := flag.String("exclusive-tags", "", "теги через запятую, события с которыми не могут пересекаться по времени (например, экзамен)")
+	remindersInterval := flag.Duration("reminders-interval", reminders.DefaultInterval, "период проверки напоминаний; 0 отключает рассылку")
+	remindersWebhook := flag.String("reminders-webhook", "", "адрес, на который напоминания отправляются POST-запросом в формате JSON (кроме записи в журнал)")
+	flag.Parse()
+
+	location, err := models.LoadLocation(*timeZone)
+	if err != nil {
+		log.Fatalf("Неизвестный часовой пояс %s: %v", *timeZone, err)
+	}
+
+	var semester *models.Semester
+	if *semesterPath != "" {
+		if semester, err = loadSemester(*semesterPath); err != nil {
+			log.Fatalf("Ошибка при загрузке семестра: %v", err)
+		}
 	}
 
-	store, err := storage.NewStorage(storagePath)
+	// Инициализация хранилища
+	store, err := storage.Open(*backend, *storagePath)
 	if err != nil {
 		log.Fatalf("Ошибка при инициализации хранилища: %v", err)
 	}
+	store.SetSemester(semester)
+
+	srv := newServer(store, location)
+	srv.semester = semester
+	srv.exclusiveTags = parseTagList(*exclusiveTags)
+	if *bellsPath != "" {
+		if err := srv.loadBells(*bellsPath); err != nil {
+			log.Fatalf("Ошибка при загрузке расписания звонков: %v", err)
+		}
+	}
+
+	// Планировщик напоминаний пишет их в журнал и, если задан адрес,
+	// отправляет на него
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	if *remindersInterval > 0 {
+		var notifier reminders.Notifier = reminders.LogNotifier{}
+		if *remindersWebhook != "" {
+			notifier = reminders.Multi(notifier, reminders.NewWebhookNotifier(*remindersWebhook))
+		}
+		scheduler := reminders.NewScheduler(store, store, notifier)
+		scheduler.Interval = *remindersInterval
+		go func() {
+			defer close(schedulerDone)
+			scheduler.Run(schedulerCtx)
+		}()
+	} else {
+		close(schedulerDone)
+	}
+
+	// Middleware для логирования и CORS
+	handler := corsMiddleware(loggingMiddleware(srv.routes()))
+	httpServer := &http.Server{Addr: *port, Handler: handler}
+
+	// Корректное завершение по сигналу: хранилище успевает сохранить данные
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			log.Printf("Ошибка при остановке сервера: %v", err)
+		}
+	}()
+
+	// Запуск сервера
+	log.Printf("Сервер запущен на http://localhost%s", *port)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	// Хранилище закрывается после того, как планировщик закончит рассылку
+	stopScheduler()
+	<-schedulerDone
+
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Ошибка при закрытии хранилища: %v", err)
+		}
+	}
+	log.Printf("Сервер остановлен")
+}
+
+// loadSemester читает описание учебного семестра из JSON-файла
+func loadSemester(path string) (*models.Semester, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var semester models.Semester
+	if err := json.Unmarshal(data, &semester); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &semester, nil
+}
 
-	// Сохраняем хранилище в глобальной переменной
-	globalStore = store
+// server содержит зависимости HTTP-обработчиков
+type server struct {
+	store storage.EventStore
+
+	// resources, profiles, bookings и reminders - хранилища ресурсов
+	// расписания, профилей доступности, ссылок для записи и сработавших
+	// напоминаний
+	resources storage.ResourceStore
+	profiles  storage.ProfileStore
+	bookings  storage.BookingStore
+	reminders storage.ReminderStore
+
+	// location - часовой пояс по умолчанию для новых событий и для дат
+	// в запросах без параметра ?tz=
+	location *time.Location
+
+	// bells - расписание звонков (nil, если не задано), bellsPath - файл,
+	// в который сохраняется расписание при изменении через API
+	bellsMu   sync.RWMutex
+	bells     *models.BellSchedule
+	bellsPath string
+
+	// exclusiveTags - теги, события с которыми, как и события с общими
+	// ресурсами, не могут пересекаться по времени
+	exclusiveTags []string
+
+	// semester - учебный семестр (nil, если не задан); хранилище получает
+	// его же через SetSemester
+	semester *models.Semester
+}
+
+// newServer создает сервер поверх переданного хранилища
+func newServer(backend storage.Backend, location *time.Location) *server {
+	return &server{
+		store:     backend,
+		resources: backend,
+		profiles:  backend,
+		bookings:  backend,
+		reminders: backend,
+		location:  location,
+	}
+}
 
-	// Настройка маршрутов с использованием роутера
+// routes настраивает маршруты сервера
+func (s *server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// API маршруты
 	mux.HandleFunc("/api/health", healthCheck)
-	mux.HandleFunc("/api/events", eventsHandler)
-	mux.HandleFunc("/api/events/", eventByIDHandler)
-	mux.HandleFunc("/api/events/date/", eventsByDateHandler)
-	mux.HandleFunc("/api/events/search/", eventsSearchHandler)
+	mux.HandleFunc("/api/events", s.eventsHandler)
+	mux.HandleFunc("/api/events/", s.eventByIDHandler)
+	mux.HandleFunc("/api/events/date/", s.eventsByDateHandler)
+	mux.HandleFunc("/api/events/search/", s.eventsSearchHandler)
+	mux.HandleFunc("/api/calendar.ics", s.calendarHandler)
+	mux.HandleFunc("/api/calendar/tag/", s.calendarByTagHandler)
+	mux.HandleFunc("/api/import/ics", s.importICSHandler)
+	mux.HandleFunc("/api/import/csv", s.importCSVHandler)
+	mux.HandleFunc("/api/import/xlsx", s.importXLSXHandler)
+	mux.HandleFunc("/api/semester/week", s.semesterWeekHandler)
+	mux.HandleFunc("/api/bells", s.bellsHandler)
+	mux.HandleFunc("/api/conflicts", s.conflictsHandler)
+	mux.HandleFunc("/api/freebusy", s.freeBusyHandler)
+	mux.HandleFunc("/api/slots/find", s.findSlotsHandler)
+	mux.HandleFunc("/api/availability", s.availabilityHandler)
+	mux.HandleFunc("/api/availability/profiles", s.profilesHandler)
+	mux.HandleFunc("/api/availability/profiles/", s.profileByIDHandler)
+	mux.HandleFunc("/api/booking-links", s.bookingLinksHandler)
+	mux.HandleFunc("/api/booking-links/", s.bookingLinkByIDHandler)
+	mux.HandleFunc("/api/book/", s.bookHandler)
+	mux.HandleFunc("/api/reminders", s.remindersHandler)
+	mux.HandleFunc("/api/reminders/upcoming", s.upcomingRemindersHandler)
+	mux.HandleFunc("/api/reminders/", s.reminderByIDHandler)
+	for _, kind := range models.ResourceKinds {
+		mux.HandleFunc("/api/"+resourcePaths[kind], s.resourcesHandler(kind))
+		mux.HandleFunc("/api/"+resourcePaths[kind]+"/", s.resourceByIDHandler(kind))
+	}
 
 	// Статические файлы
 	mux.HandleFunc("/", serveStatic)
 
-	// Middleware для логирования и CORS
-	handler := corsMiddleware(loggingMiddleware(mux))
-
-	// Запуск сервера
-	port := ":8080"
-	log.Printf("Сервер запущен на http://localhost%s", port)
-	log.Fatal(http.ListenAndServe(port, handler))
+	return mux
 }
 
-var globalStore *storage.Storage
-
 // ================== Middleware ==================
 
 // loggingMiddleware логирует все запросы
@@ -76,7 +240,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
@@ -108,6 +273,46 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
 
+// writeCSVIfRequested отдает события в CSV, если запрошен параметр ?format=csv
+// (разделитель задается параметром ?sep=), и сообщает, что ответ отправлен
+func writeCSVIfRequested(w http.ResponseWriter, r *http.Request, events []*models.Event) bool {
+	if r.URL.Query().Get("format") != "csv" {
+		return false
+	}
+
+	comma, err := parseSeparator(r.URL.Query().Get("sep"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return true
+	}
+	if comma == 0 {
+		comma = ','
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="events.csv"`)
+	if err := spreadsheet.WriteCSV(w, events, comma); err != nil {
+		log.Printf("Ошибка при отправке CSV: %v", err)
+	}
+	return true
+}
+
+// parseSeparator разбирает разделитель CSV из параметра запроса: ",", ";"
+// или "tab". Пустое значение дает 0 (разделитель по умолчанию)
+func parseSeparator(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case ",", "comma":
+		return ',', nil
+	case ";", "semicolon":
+		return ';', nil
+	case "tab", "\t":
+		return '\t', nil
+	}
+	return 0, fmt.Errorf("неподдерживаемый разделитель: %s", value)
+}
+
 // parseIDFromPath извлекает ID из URL пути
 func parseIDFromPath(r *http.Request) (string, error) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/events/")
@@ -117,24 +322,85 @@ func parseIDFromPath(r *http.Request) (string, error) {
 	return path, nil
 }
 
-// parseDateFromPath извлекает дату из URL пути
-func parseDateFromPath(r *http.Request) (time.Time, error) {
+// requestLocation возвращает часовой пояс запроса из параметра ?tz=
+// или часовой пояс сервера по умолчанию
+func (s *server) requestLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		return s.location, nil
+	}
+
+	loc, err := models.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестный часовой пояс: %s", name)
+	}
+	return loc, nil
+}
+
+// parseDateFromPath извлекает дату из URL пути; дата относится к часовому поясу loc
+func parseDateFromPath(r *http.Request, loc *time.Location) (time.Time, error) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/events/date/")
 	if path == "" {
-		return time.Now(), nil
+		return time.Now().In(loc), nil
 	}
 
 	// Пробуем несколько форматов даты
-	layouts := []string{"2006-01-02", "2006-01-02T15:04:05", "2006-01-02 15:04:05"}
+	if t, _, err := models.ParseLocalTime(path, loc); err == nil {
+		return t.In(loc), nil
+	}
+
+	// Если не удалось распарсить, возвращаем текущую дату
+	return time.Now().In(loc), fmt.Errorf("неверный формат даты")
+}
 
+// parseTimeParam разбирает момент времени из параметра запроса; время без
+// смещения относится к часовому поясу loc. Для значения в виде даты без
+// времени endOfDay выбирает конец дня вместо его начала
+func parseTimeParam(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	layouts := []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
 	for _, layout := range layouts {
-		if t, err := time.Parse(layout, path); err == nil {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
 			return t, nil
 		}
 	}
 
-	// Если не удалось распарсить, возвращаем текущую дату
-	return time.Now(), fmt.Errorf("неверный формат даты")
+	date, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверный формат времени: %s", value)
+	}
+	if endOfDay {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
+
+// parseRange извлекает окно [from, to) из параметров ?from= и ?to=. Дата без
+// времени в to включает весь день; обязательны оба параметра
+func parseRange(r *http.Request, loc *time.Location) (time.Time, time.Time, error) {
+	fromStr, toStr := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if fromStr == "" || toStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("параметры from и to обязательны")
+	}
+
+	from, err := parseTimeParam(fromStr, loc, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to, err := parseTimeParam(toStr, loc, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("начало диапазона должно быть раньше конца")
+	}
+
+	return from, to, nil
 }
 
 // ================== Обработчики API ==================
@@ -154,19 +420,19 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 // eventsHandler обрабатывает запросы к коллекции событий
-func eventsHandler(w http.ResponseWriter, r *http.Request) {
+func (s *server) eventsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		getAllEvents(w, r)
+		s.getAllEvents(w, r)
 	case http.MethodPost:
-		createEvent(w, r)
+		s.createEvent(w, r)
 	default:
 		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 	}
 }
 
 // eventByIDHandler обрабатывает запросы к конкретному событию по ID
-func eventByIDHandler(w http.ResponseWriter, r *http.Request) {
+func (s *server) eventByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := parseIDFromPath(r)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "ID события не указан")
@@ -177,36 +443,49 @@ func eventByIDHandler(w http.ResponseWriter, r *http.Request) {
 	idParts := strings.Split(id, "/")
 	id = idParts[0]
 
+	// Карточка события с описанием в HTML: /api/events/{id}/details
+	if len(idParts) == 2 && idParts[1] == "details" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+			return
+		}
+		s.getEventDetails(w, r, id)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
-		getEventByID(w, r, id)
+		s.getEventByID(w, r, id)
 	case http.MethodPut:
-		updateEvent(w, r, id)
+		s.updateEvent(w, r, id)
 	case http.MethodDelete:
-		deleteEvent(w, r, id)
+		s.deleteEvent(w, r, id)
 	default:
 		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 	}
 }
 
 // eventsByDateHandler обрабатывает запросы на получение событий по дате
-func eventsByDateHandler(w http.ResponseWriter, r *http.Request) {
+func (s *server) eventsByDateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 		return
 	}
 
-	date, err := parseDateFromPath(r)
+	loc, err := s.requestLocation(r)
 	if err != nil {
-		// Если дата не указана, используем текущую дату
-		date = time.Now()
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	getEventsByDate(w, r, date)
+	// Если дата не указана или указана неверно, используется текущая дата
+	date, _ := parseDateFromPath(r, loc)
+
+	s.getEventsByDate(w, r, date)
 }
 
 // eventsSearchHandler обрабатывает поиск событий
-func eventsSearchHandler(w http.ResponseWriter, r *http.Request) {
+func (s *server) eventsSearchHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 		return
@@ -218,244 +497,1251 @@ func eventsSearchHandler(w http.ResponseWriter, r *http.Request) {
 		query = r.URL.Query().Get("q")
 	}
 
-	searchEvents(w, r, query)
+	s.searchEvents(w, r, query)
 }
 
-// ================== Реализации CRUD операций ==================
+// semesterWeekHandler возвращает номер учебной недели и ее четность
+// (числитель/знаменатель) для даты ?date=YYYY-MM-DD, по умолчанию - сегодня
+func (s *server) semesterWeekHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
 
-// getAllEvents возвращает все события
-func getAllEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := globalStore.GetAll()
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+	semester := s.semester
+	if semester == nil {
+		writeError(w, http.StatusNotFound, "Учебный семестр не задан")
 		return
 	}
 
-	// Применяем фильтры, если они указаны
-	filteredEvents := events
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Фильтр по дате (параметр запроса ?date=YYYY-MM-DD)
-	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
-		date, err := time.Parse("2006-01-02", dateStr)
-		if err == nil {
-			var dateEvents []*models.Event
-			for _, event := range events {
-				if event.StartTime.Year() == date.Year() &&
-					event.StartTime.Month() == date.Month() &&
-					event.StartTime.Day() == date.Day() {
-					dateEvents = append(dateEvents, event)
-				}
-			}
-			filteredEvents = dateEvents
+	date := time.Now().In(loc)
+	if value := r.URL.Query().Get("date"); value != "" {
+		if date, err = time.ParseInLocation(models.DateLayout, value, loc); err != nil {
+			writeError(w, http.StatusBadRequest, "Неверный формат даты: ожидается YYYY-MM-DD")
+			return
 		}
 	}
 
-	// Фильтр по тегу (параметр запроса ?tag=работа)
-	if tag := r.URL.Query().Get("tag"); tag != "" {
-		var tagEvents []*models.Event
-		for _, event := range filteredEvents {
-			for _, eventTag := range event.Tags {
-				if strings.EqualFold(eventTag, tag) {
-					tagEvents = append(tagEvents, event)
-					break
-				}
-			}
-		}
-		filteredEvents = tagEvents
+	response := map[string]interface{}{
+		"date":       date.Format(models.DateLayout),
+		"semester":   semester,
+		"inSemester": false,
+	}
+	if week, ok := semester.Week(date); ok {
+		parity, _ := semester.Parity(date)
+		response["inSemester"] = true
+		response["week"] = week
+		response["parity"] = parity
+		response["parityName"] = parity.Name()
+		response["holiday"] = semester.IsHoliday(date)
 	}
 
-	// Ограничение количества (параметр запроса ?limit=10)
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit < len(filteredEvents) {
-			filteredEvents = filteredEvents[:limit]
+	writeJSON(w, http.StatusOK, response)
+}
+
+// bellsHandler обрабатывает запросы к расписанию звонков: GET возвращает
+// расписание, PUT заменяет его и переносит занятия, привязанные к парам
+func (s *server) bellsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		bells := s.currentBells()
+		if bells == nil {
+			writeError(w, http.StatusNotFound, "Расписание звонков не задано")
+			return
 		}
+		writeJSON(w, http.StatusOK, bells)
+	case http.MethodPut:
+		s.updateBells(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 	}
-
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"events": filteredEvents,
-		"count":  len(filteredEvents),
-	})
 }
 
-// getEventByID возвращает событие по ID
-func getEventByID(w http.ResponseWriter, r *http.Request, id string) {
-	event, err := globalStore.GetByID(id)
-	if err != nil {
-		writeError(w, http.StatusNotFound, "Событие не найдено")
+// updateBells заменяет расписание звонков: пересчитывает время занятий
+// по новому расписанию одной операцией хранилища и сохраняет расписание
+// в файл -bells. Если перенесенные занятия пересекаются с другими событиями,
+// расписание не меняется без ?force=true (как при сохранении события)
+func (s *server) updateBells(w http.ResponseWriter, r *http.Request) {
+	force, ok := parseForce(w, r)
+	if !ok {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, event)
-}
+	var bells models.BellSchedule
+	if err := json.NewDecoder(r.Body).Decode(&bells); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+	if err := bells.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bells.Sort()
 
-// getEventsByDate возвращает события на указанную дату
-func getEventsByDate(w http.ResponseWriter, r *http.Request, date time.Time) {
-	events, err := globalStore.GetByDate(date)
+	s.bellsMu.Lock()
+	defer s.bellsMu.Unlock()
+
+	conflicts := []models.Conflict{}
+	updated, err := storage.ApplyBells(s.store, &bells, s.checkReflowConflicts(force, &conflicts))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		log.Printf("Ошибка при переносе занятий по расписанию звонков: %v", err)
+		writeStoreError(w, err, "Не удалось перенести занятия по расписанию звонков")
 		return
 	}
 
+	if s.bellsPath != "" {
+		if err := saveBells(s.bellsPath, &bells); err != nil {
+			log.Printf("Ошибка при сохранении расписания звонков: %v", err)
+			// Занятия возвращаются на прежнее время, чтобы они не расходились
+			// с расписанием в файле, которое прочитается при следующем запуске
+			if s.bells != nil {
+				if _, err := storage.ApplyBells(s.store, s.bells, nil); err != nil {
+					log.Printf("Ошибка при возврате занятий к прежнему расписанию звонков: %v", err)
+				}
+			}
+			writeError(w, http.StatusInternalServerError, "Не удалось сохранить расписание звонков")
+			return
+		}
+	}
+	s.bells = &bells
+
 	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"events": events,
-		"date":   date.Format("2006-01-02"),
-		"count":  len(events),
+		"message":   "Расписание звонков обновлено",
+		"bells":     &bells,
+		"updated":   updated,
+		"conflicts": conflicts,
 	})
 }
 
-// searchEvents выполняет поиск событий
-func searchEvents(w http.ResponseWriter, r *http.Request, query string) {
-	if query == "" {
-		writeError(w, http.StatusBadRequest, "Поисковый запрос не может быть пустым")
-		return
+// currentBells возвращает действующее расписание звонков или nil
+func (s *server) currentBells() *models.BellSchedule {
+	s.bellsMu.RLock()
+	defer s.bellsMu.RUnlock()
+	return s.bells
+}
+
+// loadBells читает расписание звонков из файла и переносит занятия, если
+// расписание изменилось с прошлого запуска. Если файла нет, расписание
+// не задано до первого PUT /api/bells, который создаст файл
+func (s *server) loadBells(path string) error {
+	s.bellsPath = path
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	events, err := globalStore.Search(query)
+	var bells models.BellSchedule
+	if err := json.Unmarshal(data, &bells); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := bells.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	bells.Sort()
+	s.bells = &bells
+
+	// Расписание в файле уже действует, поэтому пересечения перенесенных
+	// занятий не останавливают запуск, а попадают в журнал
+	var conflicts []models.Conflict
+	updated, err := storage.ApplyBells(s.store, &bells, s.checkReflowConflicts(true, &conflicts))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Ошибка при выполнении поиска")
-		return
+		return err
+	}
+	if updated > 0 {
+		log.Printf("Время занятий пересчитано по расписанию звонков: %d событий", updated)
 	}
+	for _, conflict := range conflicts {
+		log.Printf("Занятие %s после переноса пересекается с %s", conflict.Event.ID, conflict.With.ID)
+	}
+	return nil
+}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"events": events,
-		"query":  query,
-		"count":  len(events),
-	})
+// saveBells атомарно записывает расписание звонков в файл
+func saveBells(path string, bells *models.BellSchedule) error {
+	data, err := json.MarshalIndent(bells, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
 }
 
-// createEvent создает новое событие
-func createEvent(w http.ResponseWriter, r *http.Request) {
-	// Парсим тело запроса
-	var requestData struct {
-		Title     string    `json:"title"`
-		StartTime time.Time `json:"startTime"`
-		EndTime   time.Time `json:"endTime"`
-		Tags      []string  `json:"tags"`
+// calendarHandler отдает все события в формате iCalendar для подписки
+// из календарных приложений
+func (s *server) calendarHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+	s.exportCalendar(w, "", "Расписание")
+}
+
+// calendarByTagHandler отдает события с тегом в формате iCalendar:
+// /api/calendar/tag/{tag}.ics
+func (s *server) calendarByTagHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 		return
 	}
 
-	// Валидация обязательных полей
-	if requestData.Title == "" {
-		writeError(w, http.StatusBadRequest, "Название события обязательно")
+	tag, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/calendar/tag/"), ".ics")
+	if !ok || tag == "" || strings.Contains(tag, "/") {
+		writeError(w, http.StatusNotFound, "Календарь не найден")
 		return
 	}
 
-	if requestData.StartTime.IsZero() || requestData.EndTime.IsZero() {
-		writeError(w, http.StatusBadRequest, "Время начала и окончания обязательно")
+	s.exportCalendar(w, tag, "Расписание: "+tag)
+}
+
+// maxImportSize ограничивает размер импортируемого файла
+const maxImportSize = 10 << 20
+
+// importICSHandler импортирует события из файла iCalendar. Файл передается
+// телом запроса (text/calendar) или полем file формы multipart/form-data;
+// параметр ?tz= задает пояс для времени без часового пояса
+func (s *server) importICSHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
 		return
 	}
 
-	// Создаем новое событие
-	event := models.NewEvent(
-		requestData.Title,
-		requestData.StartTime,
-		requestData.EndTime,
-		requestData.Tags,
-	)
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	// Валидация события
-	if err := event.Validate(); err != nil {
+	body, err := importBody(w, r)
+	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	defer body.Close()
 
-	// Сохраняем в хранилище
-	if err := globalStore.Create(event); err != nil {
-		writeError(w, http.StatusInternalServerError, "Не удалось создать событие")
+	result, err := ical.Import(s.store, body, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный файл iCalendar: "+err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"message": "Событие успешно создано",
-		"event":   event,
-	})
+	writeJSON(w, http.StatusOK, result)
 }
 
-// updateEvent обновляет существующее событие
-func updateEvent(w http.ResponseWriter, r *http.Request, id string) {
-	// Получаем существующее событие
-	existingEvent, err := globalStore.GetByID(id)
+// importCSVHandler импортирует события из таблицы CSV. Файл передается телом
+// запроса или полем file формы multipart/form-data. Параметры в строке запроса
+// или полях формы: mapping - JSON с сопоставлением полей события столбцам,
+// например {"title": "Предмет", "start": "2"}; sep - разделитель;
+// dryRun=true - только предпросмотр. Часовой пояс задается параметром ?tz=.
+// Если в строках есть ошибки, ничего не сохраняется и возвращается 422
+// с отчетом по строкам
+func (s *server) importCSVHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	body, err := importBody(w, r)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "Событие не найдено")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	defer body.Close()
 
-	// Парсим тело запроса
-	var requestData struct {
-		Title     *string    `json:"title"`
-		StartTime *time.Time `json:"startTime"`
-		EndTime   *time.Time `json:"endTime"`
-		Tags      []string   `json:"tags"`
+	// Параметры формы доступны только после разбора multipart в importBody
+	param := r.URL.Query().Get
+	if r.MultipartForm != nil {
+		param = r.FormValue
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+	opts := spreadsheet.CSVOptions{}
+	if opts.Location, err = s.requestLocation(r); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Comma, err = parseSeparator(param("sep")); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if mapping := param("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			writeError(w, http.StatusBadRequest, "Неверное сопоставление столбцов: ожидается JSON-объект")
+			return
+		}
+	}
+	opts.DryRun, _ = strconv.ParseBool(param("dryRun"))
 
-	// Обновляем только переданные поля (частичное обновление)
-	title := existingEvent.Title
-	if requestData.Title != nil {
-		title = *requestData.Title
+	result, err := spreadsheet.ImportCSV(s.store, body, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный файл CSV: "+err.Error())
+		return
 	}
 
-	startTime := existingEvent.StartTime
-	if requestData.StartTime != nil {
-		startTime = *requestData.StartTime
+	status := http.StatusOK
+	if !result.DryRun && len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
 	}
+	writeJSON(w, status, result)
+}
 
-	endTime := existingEvent.EndTime
-	if requestData.EndTime != nil {
-		endTime = *requestData.EndTime
+// importXLSXHandler импортирует сетку расписания из книги .xlsx. Файл
+// передается телом запроса или полем file формы multipart/form-data, шаблон
+// сетки (spreadsheet.GridTemplate в JSON) - параметром template в строке
+// запроса или поле формы; dryRun=true - только предпросмотр. Как и при импорте
+// CSV, при ошибках ничего не сохраняется и возвращается 422 с отчетом
+func (s *server) importXLSXHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
 	}
 
-	tags := existingEvent.Tags
-	if requestData.Tags != nil {
-		tags = requestData.Tags
+	body, err := importBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+	defer body.Close()
 
-	// Обновляем событие
-	existingEvent.Update(title, startTime, endTime, tags)
+	// Параметры формы доступны только после разбора multipart в importBody
+	param := r.URL.Query().Get
+	if r.MultipartForm != nil {
+		param = r.FormValue
+	}
 
-	// Валидация обновленного события
-	if err := existingEvent.Validate(); err != nil {
+	opts := spreadsheet.XLSXOptions{Bells: s.currentBells()}
+	if err := json.Unmarshal([]byte(param("template")), &opts.Template); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный шаблон сетки: ожидается JSON-объект в параметре template")
+		return
+	}
+	if opts.Location, err = s.requestLocation(r); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	opts.DryRun, _ = strconv.ParseBool(param("dryRun"))
 
-	// Сохраняем изменения
-	if err := globalStore.Update(existingEvent); err != nil {
-		writeError(w, http.StatusInternalServerError, "Не удалось обновить событие")
+	// Архив .xlsx читается с произвольного места, поэтому файл загружается в память
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Не удалось прочитать файл: "+err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "Событие успешно обновлено",
-		"event":   existingEvent,
-	})
-}
+	result, err := spreadsheet.ImportXLSX(s.store, bytes.NewReader(data), int64(len(data)), opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Не удалось импортировать расписание: "+err.Error())
+		return
+	}
 
-// deleteEvent удаляет событие
-func deleteEvent(w http.ResponseWriter, r *http.Request, id string) {
-	// Проверяем, существует ли событие
-	if _, err := globalStore.GetByID(id); err != nil {
+	status := http.StatusOK
+	if !result.DryRun && len(result.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, result)
+}
+
+// importBody возвращает содержимое импортируемого файла: поле file формы
+// multipart/form-data или тело запроса целиком
+func importBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("файл не передан в поле file")
+	}
+	return file, nil
+}
+
+// ================== Реализации CRUD операций ==================
+
+// getAllEvents возвращает все события
+func (s *server) getAllEvents(w http.ResponseWriter, r *http.Request) {
+	events, ok := s.requestEvents(w, r)
+	if !ok {
+		return
+	}
+
+	// Применяем фильтры, если они указаны
+	filteredEvents := events
+
+	// Фильтр по тегу (параметр запроса ?tag=работа)
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		var tagEvents []*models.Event
+		for _, event := range filteredEvents {
+			if hasTag(event, tag) {
+				tagEvents = append(tagEvents, event)
+			}
+		}
+		filteredEvents = tagEvents
+	}
+
+	// Ограничение количества (параметр запроса ?limit=10)
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit < len(filteredEvents) {
+			filteredEvents = filteredEvents[:limit]
+		}
+	}
+
+	if writeCSVIfRequested(w, r, filteredEvents) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": filteredEvents,
+		"count":  len(filteredEvents),
+	})
+}
+
+// requestEvents возвращает события за период из параметров запроса:
+// диапазон ?from=...&to=..., дата ?date=YYYY-MM-DD или, без них, все события
+// с сериями, развернутыми в окне по умолчанию. Фильтры по периоду выполняет
+// хранилище, чтобы экземпляры серий вне окна по умолчанию тоже попали
+// в ответ. При ошибке отправляет ответ и возвращает false
+func (s *server) requestEvents(w http.ResponseWriter, r *http.Request) ([]*models.Event, bool) {
+	var events []*models.Event
+
+	query := r.URL.Query()
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	switch {
+	case query.Get("from") != "" || query.Get("to") != "":
+		from, to, rangeErr := parseRange(r, loc)
+		if rangeErr != nil {
+			writeError(w, http.StatusBadRequest, rangeErr.Error())
+			return nil, false
+		}
+		events, err = s.store.Range(from, to)
+	default:
+		if date, parseErr := time.ParseInLocation("2006-01-02", query.Get("date"), loc); parseErr == nil {
+			events, err = storage.GetByDate(s.store, date)
+		} else {
+			events, err = storage.GetAllOccurrences(s.store, s.semester)
+		}
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return nil, false
+	}
+
+	return events, true
+}
+
+// getEventByID возвращает событие по ID (или экземпляр повторяющейся серии по ID экземпляра)
+func (s *server) getEventByID(w http.ResponseWriter, r *http.Request, id string) {
+	event, err := s.store.Get(id)
+	if err != nil {
+		occurrence, ok := s.findOccurrence(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Событие не найдено")
+			return
+		}
+		event = occurrence
+	}
+
+	w.Header().Set("ETag", etag(event.Version))
+	writeJSON(w, http.StatusOK, event)
+}
+
+// getEventDetails возвращает событие вместе с описанием, преобразованным
+// из Markdown в безопасный HTML
+func (s *server) getEventDetails(w http.ResponseWriter, r *http.Request, id string) {
+	event, err := s.store.Get(id)
+	if err != nil {
+		occurrence, ok := s.findOccurrence(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Событие не найдено")
+			return
+		}
+		event = occurrence
+	}
+
+	w.Header().Set("ETag", etag(event.Version))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"event":           event,
+		"descriptionHtml": markdown.ToHTML(event.Description),
+	})
+}
+
+// exportCalendar отдает хранимые события (серии - целиком, с правилом
+// повторения и исключениями) в формате iCalendar. Непустой tag оставляет
+// только события с этим тегом
+func (s *server) exportCalendar(w http.ResponseWriter, tag, name string) {
+	events, err := s.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return
+	}
+
+	if tag != "" {
+		var tagEvents []*models.Event
+		for _, event := range events {
+			for _, eventTag := range event.Tags {
+				if strings.EqualFold(eventTag, tag) {
+					tagEvents = append(tagEvents, event)
+					break
+				}
+			}
+		}
+		events = tagEvents
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="calendar.ics"`)
+	if err := ical.Encode(w, events, name, s.semester); err != nil {
+		log.Printf("Ошибка при отправке календаря: %v", err)
+	}
+}
+
+// findOccurrence ищет экземпляр повторяющейся серии по ID экземпляра
+func (s *server) findOccurrence(id string) (*models.Event, bool) {
+	seriesID, start, ok := models.ParseOccurrenceID(id)
+	if !ok {
+		return nil, false
+	}
+
+	series, err := s.store.Get(seriesID)
+	if err != nil {
+		return nil, false
+	}
+
+	return series.OccurrenceAt(start)
+}
+
+// parseWeekParity разбирает четность недели из запроса, принимая и русские
+// обозначения; неизвестное значение сохраняется как есть и отклоняется
+// проверкой события
+func parseWeekParity(value string) models.WeekParity {
+	if parity, err := models.ParseWeekParity(value); err == nil {
+		return parity
+	}
+	return models.WeekParity(value)
+}
+
+// parseRecurrence разбирает правило повторения из запроса; пустая строка означает отсутствие правила
+func parseRecurrence(value string) (*models.RecurrenceRule, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	return models.ParseRecurrenceRule(value)
+}
+
+// getEventsByDate возвращает события на указанную дату
+func (s *server) getEventsByDate(w http.ResponseWriter, r *http.Request, date time.Time) {
+	events, err := storage.GetByDate(s.store, date)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить события")
+		return
+	}
+
+	if writeCSVIfRequested(w, r, events) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"date":   date.Format("2006-01-02"),
+		"count":  len(events),
+	})
+}
+
+// searchEvents выполняет поиск событий
+func (s *server) searchEvents(w http.ResponseWriter, r *http.Request, query string) {
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "Поисковый запрос не может быть пустым")
+		return
+	}
+
+	events, err := s.store.Search(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Ошибка при выполнении поиска")
+		return
+	}
+
+	if writeCSVIfRequested(w, r, events) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"query":  query,
+		"count":  len(events),
+	})
+}
+
+// createEvent создает новое событие
+func (s *server) createEvent(w http.ResponseWriter, r *http.Request) {
+	// Парсим тело запроса
+	var requestData struct {
+		Title       string           `json:"title"`
+		StartTime   models.EventTime `json:"startTime"`
+		EndTime     models.EventTime `json:"endTime"`
+		AllDay      bool             `json:"allDay"`
+		Tags        []string         `json:"tags"`
+		Description string           `json:"description"`
+		Location    string           `json:"location"`
+		URL         string           `json:"url"`
+		Recurrence  string           `json:"recurrence"`
+		TimeZone    string           `json:"timeZone"`
+		WeekParity  string           `json:"weekParity"`
+		// Date и Slot задают время занятия парой из расписания звонков
+		// вместо startTime и endTime
+		Date string `json:"date"`
+		Slot int    `json:"slot"`
+		// Groups, Teachers и Rooms - ID учебных групп, преподавателей и аудиторий
+		Groups   []string `json:"groups"`
+		Teachers []string `json:"teachers"`
+		Rooms    []string `json:"rooms"`
+		// Reminders - напоминания в минутах до начала
+		Reminders []int `json:"reminders"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	// Валидация обязательных полей
+	if requestData.Title == "" {
+		writeError(w, http.StatusBadRequest, "Название события обязательно")
+		return
+	}
+
+	// Событие без явного часового пояса относится к поясу сервера; в нем же
+	// трактуются даты без времени
+	timeZone := requestData.TimeZone
+	if timeZone == "" {
+		timeZone = s.location.String()
+	}
+	loc, err := models.LoadLocation(timeZone)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неизвестный часовой пояс: "+timeZone)
+		return
+	}
+
+	// Время занятия по номеру пары берется из расписания звонков; дата -
+	// из date или из startTime
+	if requestData.Slot != 0 {
+		var date time.Time
+		switch {
+		case requestData.Date != "":
+			if date, err = time.ParseInLocation(models.DateLayout, requestData.Date, loc); err != nil {
+				writeError(w, http.StatusBadRequest, "Неверный формат даты: ожидается YYYY-MM-DD")
+				return
+			}
+		case !requestData.StartTime.IsZero():
+			date = requestData.StartTime.In(loc)
+		default:
+			writeError(w, http.StatusBadRequest, "Для занятия по номеру пары укажите дату (date)")
+			return
+		}
+
+		start, end, err := s.slotTimes(requestData.Slot, date, loc)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		requestData.StartTime = models.EventTime{Time: start}
+		requestData.EndTime = models.EventTime{Time: end}
+	}
+
+	// Для события на весь день окончание можно не указывать: оно длится один день
+	if requestData.StartTime.IsZero() || (requestData.EndTime.IsZero() && !requestData.AllDay) {
+		writeError(w, http.StatusBadRequest, "Время начала и окончания обязательно")
+		return
+	}
+
+	recurrence, err := parseRecurrence(requestData.Recurrence)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Неверное правило повторения: "+err.Error())
+		return
+	}
+
+	// Создаем новое событие
+	event := models.NewEvent(
+		requestData.Title,
+		requestData.StartTime.In(loc),
+		requestData.EndTime.In(loc),
+		requestData.Tags,
+		models.EventDetails{
+			Description: requestData.Description,
+			Place:       strings.TrimSpace(requestData.Location),
+			URL:         strings.TrimSpace(requestData.URL),
+		},
+	)
+	event.Recurrence = recurrence
+	event.TimeZone = timeZone
+	event.AllDay = requestData.AllDay
+	event.WeekParity = parseWeekParity(requestData.WeekParity)
+	event.Slot = requestData.Slot
+	event.Groups = requestData.Groups
+	event.Teachers = requestData.Teachers
+	event.Rooms = requestData.Rooms
+	event.Reminders = requestData.Reminders
+	event.NormalizeAllDay()
+
+	// Валидация события
+	if err := event.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Двойное бронирование ресурсов допускается только с ?force=true;
+	// пересечения проверяются под блокировкой хранилища вместе с записью
+	force, ok := parseForce(w, r)
+	if !ok {
+		return
+	}
+	from, to := s.conflictWindow(event)
+	err = s.store.CreateChecked(event, from, to, func(existing []*models.Event) error {
+		return s.checkConflicts(event, existing, force)
+	})
+	if err != nil {
+		writeStoreError(w, err, "Не удалось создать событие")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "Событие успешно создано",
+		"event":   event,
+	})
+}
+
+// Области применения изменений к повторяющимся событиям (параметр ?scope=)
+const (
+	scopeThis      = "this"
+	scopeFollowing = "this-and-following"
+	scopeAll       = "all"
+)
+
+// eventTarget описывает событие, к которому обращается запрос: хранимое событие
+// (одиночное или серию) и, если ID указывал на экземпляр, сам экземпляр серии
+type eventTarget struct {
+	event      *models.Event
+	occurrence *models.Event
+}
+
+// resolveTarget находит событие или экземпляр серии по ID и определяет область
+// изменения. По умолчанию запрос к экземпляру затрагивает только его, а запрос
+// к событию - событие целиком. При ошибке отправляет ответ и возвращает false
+func (s *server) resolveTarget(w http.ResponseWriter, r *http.Request, id string) (*eventTarget, string, bool) {
+	target := &eventTarget{}
+
+	if event, err := s.store.Get(id); err == nil {
+		target.event = event
+	} else if seriesID, start, ok := models.ParseOccurrenceID(id); ok {
+		series, err := s.store.Get(seriesID)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "Событие не найдено")
+			return nil, "", false
+		}
+
+		occurrence, ok := series.OccurrenceAt(start)
+		if !ok {
+			writeError(w, http.StatusNotFound, "Экземпляр события не найден")
+			return nil, "", false
+		}
+
+		target.event = series
+		target.occurrence = occurrence
+	} else {
 		writeError(w, http.StatusNotFound, "Событие не найдено")
+		return nil, "", false
+	}
+
+	scope := r.URL.Query().Get("scope")
+	if scope == "" {
+		scope = scopeAll
+		if target.occurrence != nil {
+			scope = scopeThis
+		}
+	}
+
+	switch scope {
+	case scopeAll:
+	case scopeThis, scopeFollowing:
+		if target.occurrence == nil {
+			writeError(w, http.StatusBadRequest, "Области this и this-and-following требуют ID экземпляра серии")
+			return nil, "", false
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "Неизвестная область изменения: "+scope)
+		return nil, "", false
+	}
+
+	return target, scope, true
+}
+
+// versionMismatchMessage - ответ на изменение устаревшей версии события
+const versionMismatchMessage = "Событие было изменено: версия не совпадает с If-Match"
+
+// etag возвращает значение заголовка ETag для версии события
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// checkIfMatch проверяет заголовок If-Match изменяющего запроса по текущей
+// версии события. Без заголовка запрос отклоняется с кодом 428, при
+// несовпадении версии - с кодом 412. При ошибке отправляет ответ и возвращает false
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		writeError(w, http.StatusPreconditionRequired, "Требуется заголовок If-Match с версией события (ETag)")
+		return false
+	}
+
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	w.Header().Set("ETag", current)
+	writeError(w, http.StatusPreconditionFailed, versionMismatchMessage)
+	return false
+}
+
+// checkVersion возвращает ErrVersionConflict, если событие, полученное
+// в Modify, изменилось после проверки If-Match
+func checkVersion(event *models.Event, version int64) error {
+	if event.Version != version {
+		return storage.ErrVersionConflict
+	}
+	return nil
+}
+
+// writeStoreError отправляет ответ на ошибку сохранения: ошибка проверки
+// данных - 400, двойное бронирование без ?force=true - 409 со списком
+// конфликтов, конфликт версий при одновременном изменении - 412,
+// остальные ошибки - 500 с сообщением message
+func writeStoreError(w http.ResponseWriter, err error, message string) {
+	var validationErr models.ValidationError
+	var conflictErr *conflictError
+	switch {
+	case errors.As(err, &validationErr):
+		writeError(w, http.StatusBadRequest, validationErr.Message)
+	case errors.As(err, &conflictErr):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":     "Ресурсы уже заняты в это время; чтобы сохранить событие, повторите запрос с ?force=true",
+			"conflicts": conflictErr.conflicts,
+			"count":     len(conflictErr.conflicts),
+		})
+	case errors.Is(err, storage.ErrVersionConflict):
+		writeError(w, http.StatusPreconditionFailed, versionMismatchMessage)
+	default:
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
+
+// updateRequest - тело запроса на частичное обновление события
+type updateRequest struct {
+	Title       *string           `json:"title"`
+	StartTime   *models.EventTime `json:"startTime"`
+	EndTime     *models.EventTime `json:"endTime"`
+	AllDay      *bool             `json:"allDay"`
+	Tags        []string          `json:"tags"`
+	Description *string           `json:"description"`
+	Location    *string           `json:"location"`
+	URL         *string           `json:"url"`
+	Recurrence  *string           `json:"recurrence"`
+	TimeZone    *string           `json:"timeZone"`
+	WeekParity  *string           `json:"weekParity"`
+	// Date и Slot переносят занятие на пару из расписания звонков; slot: 0
+	// отвязывает занятие от пар. Явные startTime и endTime без slot тоже
+	// отвязывают занятие от пар
+	Date *string `json:"date"`
+	Slot *int    `json:"slot"`
+	// Groups, Teachers и Rooms заменяют ссылки на ресурсы; пустой список
+	// убирает их, отсутствующее поле оставляет без изменений
+	Groups   []string `json:"groups"`
+	Teachers []string `json:"teachers"`
+	Rooms    []string `json:"rooms"`
+	// Reminders заменяет напоминания так же, как Groups - ресурсы
+	Reminders []int `json:"reminders"`
+}
+
+// apply применяет переданные поля к событию. Новое время начала и окончания
+// отсчитывается от экземпляра base: при изменении серии через ее экземпляр
+// сдвиг переносится на всю серию
+func (req *updateRequest) apply(event, base *models.Event) {
+	title := event.Title
+	if req.Title != nil {
+		title = *req.Title
+	}
+
+	if req.TimeZone != nil {
+		event.TimeZone = *req.TimeZone
+	}
+	if req.AllDay != nil {
+		event.AllDay = *req.AllDay
+	}
+	if req.WeekParity != nil {
+		event.WeekParity = parseWeekParity(*req.WeekParity)
+	}
+	if req.Slot != nil {
+		event.Slot = *req.Slot
+	} else if req.StartTime != nil || req.EndTime != nil {
+		event.Slot = 0
+	}
+	if req.Groups != nil {
+		event.Groups = req.Groups
+	}
+	if req.Teachers != nil {
+		event.Teachers = req.Teachers
+	}
+	if req.Rooms != nil {
+		event.Rooms = req.Rooms
+	}
+	if req.Reminders != nil {
+		event.Reminders = req.Reminders
+	}
+	loc := event.Location()
+
+	startTime := event.StartTime
+	if req.StartTime != nil {
+		startTime = shiftTime(event.StartTime, base.StartTime, req.StartTime.In(loc), event.AllDay, loc)
+	}
+
+	endTime := event.EndTime
+	if req.EndTime != nil {
+		endTime = shiftTime(event.EndTime, base.EndTime, req.EndTime.In(loc), event.AllDay, loc)
+	}
+
+	tags := event.Tags
+	if req.Tags != nil {
+		tags = req.Tags
+	}
+
+	details := event.Details()
+	if req.Description != nil {
+		details.Description = *req.Description
+	}
+	if req.Location != nil {
+		details.Place = strings.TrimSpace(*req.Location)
+	}
+	if req.URL != nil {
+		details.URL = strings.TrimSpace(*req.URL)
+	}
+
+	event.Update(title, startTime, endTime, tags, details)
+	event.NormalizeAllDay()
+}
+
+// resolveSlot заменяет номер пары и дату в запросе временем начала
+// и окончания по расписанию звонков. Без даты занятие остается в день
+// экземпляра base, без номера пары - на паре base
+func (req *updateRequest) resolveSlot(s *server, base *models.Event) error {
+	if req.Slot == nil && req.Date == nil {
+		return nil
+	}
+
+	slot := base.Slot
+	if req.Slot != nil {
+		slot = *req.Slot
+	}
+	if slot == 0 {
+		if req.Slot == nil {
+			return fmt.Errorf("дату без времени можно указать только для занятия по номеру пары (slot)")
+		}
+		return nil
+	}
+
+	loc := base.Location()
+	date := base.StartTime.In(loc)
+	if req.Date != nil {
+		var err error
+		if date, err = time.ParseInLocation(models.DateLayout, *req.Date, loc); err != nil {
+			return fmt.Errorf("неверный формат даты: ожидается YYYY-MM-DD")
+		}
+	}
+
+	start, end, err := s.slotTimes(slot, date, loc)
+	if err != nil {
+		return err
+	}
+	req.StartTime = &models.EventTime{Time: start}
+	req.EndTime = &models.EventTime{Time: end}
+	req.Slot = &slot
+	return nil
+}
+
+// slotTimes возвращает время пары slot в день date по расписанию звонков
+func (s *server) slotTimes(slot int, date time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	bells := s.currentBells()
+	if bells == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("расписание звонков не задано")
+	}
+	return bells.Times(slot, date, loc)
+}
+
+// shiftTime сдвигает t на столько же, на сколько to отличается от from.
+// Для событий на весь день сдвиг считается в календарных днях, чтобы переход
+// на летнее время не смещал границы с полуночи
+func shiftTime(t, from, to time.Time, allDay bool, loc *time.Location) time.Time {
+	if allDay {
+		return t.In(loc).AddDate(0, 0, models.DaysBetween(from.In(loc), to.In(loc)))
+	}
+	return t.Add(to.Sub(from))
+}
+
+// applyRecurrence применяет переданное правило повторения к серии и сдвигает
+// ее исключения вслед за временем начала. Пустая строка превращает серию
+// в одиночное событие
+func (req *updateRequest) applyRecurrence(event *models.Event, oldStart time.Time) error {
+	if req.Recurrence != nil {
+		recurrence, err := parseRecurrence(*req.Recurrence)
+		if err != nil {
+			return models.ValidationError{Field: "recurrence", Message: "Неверное правило повторения: " + err.Error()}
+		}
+		event.Recurrence = recurrence
+	}
+
+	if !event.IsRecurring() {
+		event.ExDates = nil
+		event.Overrides = nil
+		return nil
+	}
+
+	event.ShiftExceptions(oldStart)
+	return nil
+}
+
+// updateEvent обновляет существующее событие. Для повторяющихся событий
+// область изменения задается параметром ?scope=this|this-and-following|all
+func (s *server) updateEvent(w http.ResponseWriter, r *http.Request, id string) {
+	// Получаем существующее событие
+	target, scope, ok := s.resolveTarget(w, r, id)
+	if !ok || !checkIfMatch(w, r, target.event.Version) {
+		return
+	}
+
+	// Парсим тело запроса
+	var requestData updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	slotBase := target.event
+	if target.occurrence != nil {
+		slotBase = target.occurrence
+	}
+	if err := requestData.resolveSlot(s, slotBase); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	switch scope {
+	case scopeThis:
+		s.updateOccurrence(w, r, target, &requestData)
+		return
+	case scopeFollowing:
+		// Хранилище отдает копию серии, поэтому разделение не затрагивает
+		// хранимые данные до сохранения
+		tail, headRemains := target.event.SplitAt(*target.occurrence.OriginalStartTime)
+		if headRemains {
+			s.updateFollowing(w, r, target, tail, &requestData)
+			return
+		}
+		// Разделение на первом экземпляре равносильно изменению всей серии
+	}
+
+	base := target.event
+	if target.occurrence != nil {
+		base = target.occurrence
+	}
+
+	// Окно проверки пересечений определяется по копии с примененными изменениями
+	candidate := target.event.Clone()
+	requestData.apply(candidate, base)
+	if err := requestData.applyRecurrence(candidate, target.event.StartTime); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	force, ok := parseForce(w, r)
+	if !ok {
+		return
+	}
+	from, to := s.updateConflictWindow(&requestData, candidate)
+
+	// Обновляем только переданные поля (частичное обновление). Изменение
+	// выполняется атомарно: если событие не прошло проверку или пересекается
+	// с другими событиями, оно не сохраняется
+	event, err := s.store.ModifyChecked(target.event.ID, from, to, func(event *models.Event, existing []*models.Event) error {
+		if err := checkVersion(event, target.event.Version); err != nil {
+			return err
+		}
+
+		oldStart := event.StartTime
+		requestData.apply(event, base)
+		if err := requestData.applyRecurrence(event, oldStart); err != nil {
+			return err
+		}
+		return s.checkUpdateConflicts(&requestData, event, existing, force, target.event.ID)
+	})
+	if err != nil {
+		writeStoreError(w, err, "Не удалось обновить событие")
 		return
 	}
 
-	// Удаляем событие
-	if err := globalStore.Delete(id); err != nil {
-		writeError(w, http.StatusInternalServerError, "Не удалось удалить событие")
+	w.Header().Set("ETag", etag(event.Version))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Событие успешно обновлено",
+		"event":   event,
+	})
+}
+
+// updateOccurrence изменяет один экземпляр серии, сохраняя его как исключение
+func (s *server) updateOccurrence(w http.ResponseWriter, r *http.Request, target *eventTarget, requestData *updateRequest) {
+	if requestData.Recurrence != nil {
+		writeError(w, http.StatusBadRequest, "Правило повторения нельзя задать для отдельного экземпляра")
+		return
+	}
+
+	force, ok := parseForce(w, r)
+	if !ok {
+		return
+	}
+	candidate := target.occurrence.Clone()
+	requestData.apply(candidate, target.occurrence)
+	from, to := s.updateConflictWindow(requestData, candidate)
+
+	originalStart := *target.occurrence.OriginalStartTime
+	series, err := s.store.ModifyChecked(target.event.ID, from, to, func(series *models.Event, existing []*models.Event) error {
+		if err := checkVersion(series, target.event.Version); err != nil {
+			return err
+		}
+
+		occurrence := target.occurrence
+		requestData.apply(occurrence, target.occurrence)
+		if err := s.checkUpdateConflicts(requestData, occurrence, existing, force, target.event.ID); err != nil {
+			return err
+		}
+		series.SetOverride(occurrence)
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err, "Не удалось обновить событие")
+		return
+	}
+
+	// В ответ отдается копия экземпляра с новой версией серии
+	occurrence, _ := series.OccurrenceAt(originalStart)
+
+	w.Header().Set("ETag", etag(series.Version))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Экземпляр события успешно обновлен",
+		"event":   occurrence,
+	})
+}
+
+// updateFollowing применяет изменения к экземпляру и всем последующим:
+// серия разделяется, изменения получает новая серия tail
+func (s *server) updateFollowing(w http.ResponseWriter, r *http.Request, target *eventTarget, tail *models.Event, requestData *updateRequest) {
+	oldStart := tail.StartTime
+	requestData.apply(tail, target.occurrence)
+	if err := requestData.applyRecurrence(tail, oldStart); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := tail.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	force, ok := parseForce(w, r)
+	if !ok {
+		return
+	}
+
+	// Split сверяет версию серии: если ее изменили после чтения, разделение
+	// отклоняется, и ни одна из серий не сохраняется. Исходная серия будет
+	// сокращена до точки разделения, поэтому с новой серией она не конфликтует
+	from, to := s.updateConflictWindow(requestData, tail)
+	err := s.store.Split(target.event, tail, from, to, func(existing []*models.Event) error {
+		return s.checkUpdateConflicts(requestData, tail, existing, force, target.event.ID)
+	})
+	if err != nil {
+		writeStoreError(w, err, "Не удалось разделить серию событий")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Серия событий успешно разделена",
+		"event":    tail,
+		"previous": target.event,
+	})
+}
+
+// deleteEvent удаляет событие. Для повторяющихся событий область удаления
+// задается параметром ?scope=this|this-and-following|all
+func (s *server) deleteEvent(w http.ResponseWriter, r *http.Request, id string) {
+	// Проверяем, существует ли событие
+	target, scope, ok := s.resolveTarget(w, r, id)
+	if !ok || !checkIfMatch(w, r, target.event.Version) {
+		return
+	}
+
+	var err error
+	switch scope {
+	case scopeThis:
+		// Отменяем экземпляр, добавляя его в исключения серии
+		_, err = s.store.Modify(target.event.ID, func(series *models.Event) error {
+			if err := checkVersion(series, target.event.Version); err != nil {
+				return err
+			}
+			series.CancelOccurrence(*target.occurrence.OriginalStartTime)
+			return nil
+		})
+	case scopeFollowing:
+		// Серия заканчивается перед удаляемым экземпляром
+		if _, headRemains := target.event.SplitAt(*target.occurrence.OriginalStartTime); headRemains {
+			err = s.store.Update(target.event)
+		} else {
+			err = s.store.DeleteIfVersion(target.event.ID, target.event.Version)
+		}
+	default:
+		// Удаляем событие, если его не изменили после проверки If-Match
+		err = s.store.DeleteIfVersion(target.event.ID, target.event.Version)
+	}
+
+	if err != nil {
+		writeStoreError(w, err, "Не удалось удалить событие")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{
 		"message": "Событие успешно удалено",
 		"id":      id,
+		"scope":   scope,
 	})
 }
 
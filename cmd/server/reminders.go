@@ -0,0 +1,138 @@
+// cmd/server/reminders.go
+package main
+
+import (
+	"net/http"
+	"schedule-app/internal/models"
+	"schedule-app/internal/reminders"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultReminderWindow - окно /api/reminders/upcoming без параметров from и to
+const defaultReminderWindow = 24 * time.Hour
+
+// remindersHandler возвращает сработавшие напоминания; ?pending=true
+// оставляет только неподтвержденные
+func (s *server) remindersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	pending := false
+	if value := r.URL.Query().Get("pending"); value != "" {
+		var err error
+		if pending, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, "Неверное значение pending: ожидается true или false")
+			return
+		}
+	}
+
+	list, err := s.reminders.ListReminders()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить напоминания")
+		return
+	}
+	if pending {
+		filtered := list[:0]
+		for _, reminder := range list {
+			if reminder.Pending() {
+				filtered = append(filtered, reminder)
+			}
+		}
+		list = filtered
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reminders": list,
+		"count":     len(list),
+	})
+}
+
+// upcomingRemindersHandler возвращает напоминания со сроком в окне
+// ?from=...&to=... (по умолчанию - ближайшие сутки), включая экземпляры серий
+func (s *server) upcomingRemindersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		return
+	}
+
+	loc, err := s.requestLocation(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from := time.Now()
+	to := from.Add(defaultReminderWindow)
+	if r.URL.Query().Get("from") != "" || r.URL.Query().Get("to") != "" {
+		if from, to, err = parseRange(r, loc); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if to.Sub(from) > maxSlotWindow {
+		writeError(w, http.StatusBadRequest, "Окно не может быть длиннее 92 дней")
+		return
+	}
+
+	list, err := reminders.Upcoming(s.store, s.reminders, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить напоминания")
+		return
+	}
+	for _, reminder := range list {
+		reminder.Start = reminder.Start.In(loc)
+		reminder.Due = reminder.Due.In(loc)
+	}
+	if list == nil {
+		list = []*models.Reminder{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":      from.In(loc),
+		"to":        to.In(loc),
+		"reminders": list,
+		"count":     len(list),
+	})
+}
+
+// reminderByIDHandler обрабатывает запросы к сработавшему напоминанию:
+// GET /api/reminders/{id} и POST /api/reminders/{id}/ack (подтверждение)
+func (s *server) reminderByIDHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/reminders/")
+	id, action, _ := strings.Cut(path, "/")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "ID напоминания не указан")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		reminder, err := s.reminders.GetReminder(id)
+		if err != nil {
+			writeError(w, http.StatusNotFound, "Напоминание не найдено")
+			return
+		}
+		writeJSON(w, http.StatusOK, reminder)
+	case action == "ack" && r.Method == http.MethodPost:
+		if _, err := s.reminders.GetReminder(id); err != nil {
+			writeError(w, http.StatusNotFound, "Напоминание не найдено")
+			return
+		}
+		reminder, err := s.reminders.AcknowledgeReminder(id, time.Now())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "Не удалось подтвердить напоминание")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"message":  "Напоминание подтверждено",
+			"reminder": reminder,
+		})
+	case action == "" || action == "ack":
+		writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+	default:
+		writeError(w, http.StatusNotFound, "Неизвестное действие: "+action)
+	}
+}
@@ -0,0 +1,294 @@
+// cmd/server/resources.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strings"
+)
+
+// resourcePaths - сегменты URL коллекций ресурсов: /api/groups, /api/teachers, /api/rooms
+var resourcePaths = map[models.ResourceKind]string{
+	models.ResourceGroup:   "groups",
+	models.ResourceTeacher: "teachers",
+	models.ResourceRoom:    "rooms",
+}
+
+// resourceRequest - тело запроса на создание или частичное обновление ресурса
+type resourceRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Email       *string `json:"email"`
+	Size        *int    `json:"size"`
+	Capacity    *int    `json:"capacity"`
+}
+
+// apply применяет переданные поля к ресурсу
+func (req *resourceRequest) apply(resource *models.Resource) {
+	if req.Name != nil {
+		resource.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		resource.Description = *req.Description
+	}
+	if req.Email != nil {
+		resource.Email = strings.TrimSpace(*req.Email)
+	}
+	if req.Size != nil {
+		resource.Size = *req.Size
+	}
+	if req.Capacity != nil {
+		resource.Capacity = *req.Capacity
+	}
+}
+
+// resourcesHandler обрабатывает запросы к коллекции ресурсов вида kind
+func (s *server) resourcesHandler(kind models.ResourceKind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			s.listResources(w, r, kind)
+		case http.MethodPost:
+			s.createResource(w, r, kind)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		}
+	}
+}
+
+// resourceByIDHandler обрабатывает запросы к ресурсу вида kind по ID
+// и к его расписанию: /api/{groups|teachers|rooms}/{id}/events
+func (s *server) resourceByIDHandler(kind models.ResourceKind) http.HandlerFunc {
+	prefix := "/api/" + resourcePaths[kind] + "/"
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		idParts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		id := idParts[0]
+		if id == "" {
+			writeError(w, http.StatusBadRequest, "ID не указан")
+			return
+		}
+
+		if len(idParts) == 2 && idParts[1] == "events" {
+			if r.Method != http.MethodGet {
+				writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+				return
+			}
+			s.getResourceEvents(w, r, kind, id)
+			return
+		}
+		if len(idParts) > 1 {
+			writeError(w, http.StatusNotFound, "Не найдено")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.getResource(w, r, kind, id)
+		case http.MethodPut:
+			s.updateResource(w, r, kind, id)
+		case http.MethodDelete:
+			s.deleteResource(w, r, kind, id)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Метод не разрешен")
+		}
+	}
+}
+
+// listResources возвращает ресурсы вида kind; ?q= оставляет ресурсы,
+// в названии которых встречается строка (без учета регистра)
+func (s *server) listResources(w http.ResponseWriter, r *http.Request, kind models.ResourceKind) {
+	resources, err := s.resources.ListResources(kind)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось получить список")
+		return
+	}
+
+	if query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q"))); query != "" {
+		var filtered []*models.Resource
+		for _, resource := range resources {
+			if strings.Contains(strings.ToLower(resource.Name), query) {
+				filtered = append(filtered, resource)
+			}
+		}
+		resources = filtered
+	}
+	if resources == nil {
+		resources = []*models.Resource{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"kind":      kind,
+		"resources": resources,
+		"count":     len(resources),
+	})
+}
+
+// createResource создает ресурс вида kind
+func (s *server) createResource(w http.ResponseWriter, r *http.Request, kind models.ResourceKind) {
+	var requestData resourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	resource := models.NewResource(kind, "")
+	requestData.apply(resource)
+
+	if err := resource.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.resources.CreateResource(resource); err != nil {
+		writeError(w, http.StatusInternalServerError, "Не удалось создать ресурс")
+		return
+	}
+
+	w.Header().Set("ETag", etag(resource.Version))
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":  "Ресурс успешно создан",
+		"resource": resource,
+	})
+}
+
+// getResource возвращает ресурс по ID
+func (s *server) getResource(w http.ResponseWriter, r *http.Request, kind models.ResourceKind, id string) {
+	resource, err := s.resources.GetResource(kind, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ресурс не найден")
+		return
+	}
+
+	w.Header().Set("ETag", etag(resource.Version))
+	writeJSON(w, http.StatusOK, resource)
+}
+
+// updateResource частично обновляет ресурс. Как и для событий, требуется
+// заголовок If-Match с текущей версией
+func (s *server) updateResource(w http.ResponseWriter, r *http.Request, kind models.ResourceKind, id string) {
+	current, err := s.resources.GetResource(kind, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ресурс не найден")
+		return
+	}
+	if !checkIfMatch(w, r, current.Version) {
+		return
+	}
+
+	var requestData resourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		writeError(w, http.StatusBadRequest, "Неверный формат JSON")
+		return
+	}
+
+	resource, err := s.resources.ModifyResource(kind, id, func(resource *models.Resource) error {
+		if resource.Version != current.Version {
+			return storage.ErrVersionConflict
+		}
+		requestData.apply(resource)
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err, "Не удалось обновить ресурс")
+		return
+	}
+
+	w.Header().Set("ETag", etag(resource.Version))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "Ресурс успешно обновлен",
+		"resource": resource,
+	})
+}
+
+// deleteResource удаляет ресурс. Ресурс, на который ссылаются события
+// (в том числе измененные экземпляры серий), не удаляется: ответ 409
+// со списком таких событий
+func (s *server) deleteResource(w http.ResponseWriter, r *http.Request, kind models.ResourceKind, id string) {
+	resource, err := s.resources.GetResource(kind, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ресурс не найден")
+		return
+	}
+	if !checkIfMatch(w, r, resource.Version) {
+		return
+	}
+
+	// Хранилище проверяет ссылки событий под той же блокировкой, что
+	// и удаление: событие не может сослаться на ресурс между проверкой и удалением
+	var inUse *storage.ResourceInUseError
+	err = s.resources.DeleteResource(kind, id)
+	switch {
+	case errors.As(err, &inUse):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":  "Ресурс используется в событиях; сначала уберите ссылки на него",
+			"events": inUse.Events,
+		})
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "Не удалось удалить ресурс")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Ресурс успешно удален",
+		"id":      id,
+	})
+}
+
+// getResourceEvents возвращает расписание ресурса: события и экземпляры
+// серий, в которых участвует группа или преподаватель либо которые
+// проходят в аудитории. Период задается так же, как для /api/events
+func (s *server) getResourceEvents(w http.ResponseWriter, r *http.Request, kind models.ResourceKind, id string) {
+	resource, err := s.resources.GetResource(kind, id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Ресурс не найден")
+		return
+	}
+
+	events, ok := s.requestEvents(w, r)
+	if !ok {
+		return
+	}
+
+	filtered := []*models.Event{}
+	for _, event := range events {
+		if event.Uses(kind, id) {
+			filtered = append(filtered, event)
+		}
+	}
+
+	if writeCSVIfRequested(w, r, filtered) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"resource": resource,
+		"events":   filtered,
+		"count":    len(filtered),
+	})
+}
+
+// checkResourceRefs проверяет, что ресурсы запроса на чтение (поиска
+// свободного времени) существуют. Ссылки сохраняемых событий проверяет само
+// хранилище под блокировкой записи, а ресурс, на который ссылаются события,
+// удалить нельзя, поэтому отдельное чтение здесь не теряет их занятость
+func (s *server) checkResourceRefs(groups, teachers, rooms []string) error {
+	refs := map[models.ResourceKind][]string{
+		models.ResourceGroup:   groups,
+		models.ResourceTeacher: teachers,
+		models.ResourceRoom:    rooms,
+	}
+	for _, kind := range models.ResourceKinds {
+		for _, id := range refs[kind] {
+			if _, err := s.resources.GetResource(kind, id); err != nil {
+				return fmt.Errorf("неизвестный ресурс (%s): %s", kind.Name(), id)
+			}
+		}
+	}
+	return nil
+}
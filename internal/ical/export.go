@@ -0,0 +1,380 @@
+// internal/ical/export.go
+
+// Package ical преобразует события расписания в формат iCalendar (RFC 5545),
+// чтобы на расписание можно было подписаться из календарных приложений
+package ical
+
+import (
+	"bufio"
+	"io"
+	"schedule-app/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ProdID - идентификатор приложения, создавшего календарь (PRODID)
+const ProdID = "-//schedule-app//Расписание//RU"
+
+// Форматы значений DATE-TIME и DATE по RFC 5545
+const (
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+// maxLineOctets - наибольшая длина строки контента без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Encode записывает события в w как календарь VCALENDAR с названием name.
+// Серии выводятся одним VEVENT с RRULE и EXDATE, измененные экземпляры -
+// отдельными VEVENT с RECURRENCE-ID. Для каждого часового пояса событий
+// добавляется VTIMEZONE. Занятия с четностью недели выводятся только
+// в пределах семестра semester (см. Semester.Restrict); nil - без ограничений
+func Encode(w io.Writer, events []*models.Event, name string, semester *models.Semester) error {
+	var restricted []*models.Event
+	for _, event := range events {
+		if event, ok := semester.Restrict(event); ok {
+			restricted = append(restricted, event)
+		}
+	}
+	events = restricted
+
+	bw := bufio.NewWriter(w)
+	enc := &encoder{w: bw}
+
+	enc.line("BEGIN:VCALENDAR")
+	enc.line("VERSION:2.0")
+	enc.line("PRODID:" + ProdID)
+	enc.line("CALSCALE:GREGORIAN")
+	enc.line("METHOD:PUBLISH")
+	if name != "" {
+		enc.line("X-WR-CALNAME:" + escapeText(name))
+	}
+
+	for _, zone := range collectZones(events) {
+		enc.timezone(zone)
+	}
+
+	for _, event := range events {
+		enc.event(event, event)
+		for _, override := range event.Overrides {
+			enc.event(override, event)
+		}
+	}
+
+	enc.line("END:VCALENDAR")
+
+	if enc.err != nil {
+		return enc.err
+	}
+	return bw.Flush()
+}
+
+// encoder записывает строки контента с переносом длинных строк;
+// первая ошибка записи сохраняется, последующие строки пропускаются
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line записывает строку контента, перенося ее по 75 октетов (RFC 5545, 3.1).
+// Перенос не разрывает многобайтовые символы UTF-8
+func (enc *encoder) line(content string) {
+	if enc.err != nil {
+		return
+	}
+
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		// Продолжение начинается с пробела, который входит в длину строки
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+
+	_, enc.err = enc.w.WriteString(b.String())
+}
+
+// event записывает VEVENT события, серии или измененного экземпляра серии.
+// series - серия, к которой относится экземпляр (для остальных - само событие):
+// RECURRENCE-ID записывается в том же виде, что и DTSTART серии
+func (enc *encoder) event(e, series *models.Event) {
+	uid := e.ID
+	if e.RecurringEventID != "" {
+		uid = e.RecurringEventID
+	}
+
+	enc.line("BEGIN:VEVENT")
+	enc.line("UID:" + escapeText(uid))
+	stamp := e.UpdatedAt
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	enc.line("DTSTAMP:" + formatUTC(stamp))
+	if !e.CreatedAt.IsZero() {
+		enc.line("CREATED:" + formatUTC(e.CreatedAt))
+	}
+	if !e.UpdatedAt.IsZero() {
+		enc.line("LAST-MODIFIED:" + formatUTC(e.UpdatedAt))
+	}
+	if e.OriginalStartTime != nil {
+		enc.line("RECURRENCE-ID" + formatTime(series, *e.OriginalStartTime))
+	}
+	enc.line("DTSTART" + formatTime(e, e.StartTime))
+	enc.line("DTEND" + formatTime(e, e.EndTime))
+	enc.line("SUMMARY:" + escapeText(e.Title))
+
+	if e.Description != "" {
+		enc.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if e.Place != "" {
+		enc.line("LOCATION:" + escapeText(e.Place))
+	}
+	if e.URL != "" {
+		enc.line("URL:" + e.URL)
+	}
+	if len(e.Tags) > 0 {
+		tags := make([]string, len(e.Tags))
+		for i, tag := range e.Tags {
+			tags[i] = escapeText(tag)
+		}
+		enc.line("CATEGORIES:" + strings.Join(tags, ","))
+	}
+
+	if e.Recurrence != nil {
+		enc.line("RRULE:" + formatRule(e))
+	}
+	for _, exdate := range e.ExDates {
+		enc.line("EXDATE" + formatTime(e, exdate))
+	}
+
+	// Напоминания - VALARM с триггером относительно начала
+	for _, offset := range e.Reminders {
+		enc.line("BEGIN:VALARM")
+		enc.line("ACTION:DISPLAY")
+		enc.line("DESCRIPTION:" + escapeText(e.Title))
+		enc.line("TRIGGER:-PT" + strconv.Itoa(offset) + "M")
+		enc.line("END:VALARM")
+	}
+
+	enc.line("END:VEVENT")
+}
+
+// timezone записывает VTIMEZONE для часового пояса IANA. Go не раскрывает
+// правила перехода на летнее время, поэтому переходы находятся перебором
+// в окне дат событий и выводятся отдельными наблюдениями STANDARD/DAYLIGHT
+func (enc *encoder) timezone(zone *zoneInfo) {
+	enc.line("BEGIN:VTIMEZONE")
+	enc.line("TZID:" + zone.loc.String())
+
+	transitions := zoneTransitions(zone.loc, zone.from, zone.to)
+	if len(transitions) == 0 {
+		// Пояс без переходов в окне описывается одним наблюдением
+		name, offset := zone.from.In(zone.loc).Zone()
+		enc.observance("STANDARD", zone.from.In(zone.loc), name, offset, offset)
+	}
+	for _, tr := range transitions {
+		kind := "STANDARD"
+		if tr.offsetTo > tr.offsetFrom {
+			kind = "DAYLIGHT"
+		}
+		// DTSTART наблюдения - местное время до перехода
+		onset := tr.at.In(time.FixedZone("", tr.offsetFrom))
+		enc.observance(kind, onset, tr.name, tr.offsetFrom, tr.offsetTo)
+	}
+
+	enc.line("END:VTIMEZONE")
+}
+
+// observance записывает наблюдение STANDARD или DAYLIGHT внутри VTIMEZONE
+func (enc *encoder) observance(kind string, onset time.Time, name string, offsetFrom, offsetTo int) {
+	enc.line("BEGIN:" + kind)
+	enc.line("DTSTART:" + onset.Format(dateTimeLayout))
+	enc.line("TZOFFSETFROM:" + formatOffset(offsetFrom))
+	enc.line("TZOFFSETTO:" + formatOffset(offsetTo))
+	if name != "" && !strings.ContainsAny(name[:1], "+-") {
+		enc.line("TZNAME:" + escapeText(name))
+	}
+	enc.line("END:" + kind)
+}
+
+// zoneInfo - часовой пояс, встречающийся в календаре, и окно дат его событий
+type zoneInfo struct {
+	loc      *time.Location
+	from, to time.Time
+}
+
+// collectZones возвращает часовые пояса IANA, в которых заданы события,
+// с окном дат, достаточным для описания их переходов. Серии без окончания
+// охватываются на два года вперед от текущего момента
+func collectZones(events []*models.Event) []*zoneInfo {
+	zones := make(map[string]*zoneInfo)
+	horizon := time.Now().AddDate(2, 0, 0)
+
+	for _, event := range events {
+		loc, ok := eventZone(event)
+		if !ok {
+			continue
+		}
+
+		from, to := event.StartTime, event.EndTime
+		if event.Recurrence != nil {
+			to = horizon
+			if !event.Recurrence.Until.IsZero() {
+				to = event.Recurrence.Until
+			}
+		}
+		for _, override := range event.Overrides {
+			if override.StartTime.Before(from) {
+				from = override.StartTime
+			}
+			if override.EndTime.After(to) {
+				to = override.EndTime
+			}
+		}
+
+		zone, ok := zones[loc.String()]
+		if !ok {
+			zones[loc.String()] = &zoneInfo{loc: loc, from: from, to: to}
+			continue
+		}
+		if from.Before(zone.from) {
+			zone.from = from
+		}
+		if to.After(zone.to) {
+			zone.to = to
+		}
+	}
+
+	result := make([]*zoneInfo, 0, len(zones))
+	for _, zone := range zones {
+		// Окно расширяется до целых лет, чтобы описать оба перехода года
+		zone.from = time.Date(zone.from.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		zone.to = time.Date(zone.to.Year()+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		result = append(result, zone)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].loc.String() < result[j].loc.String()
+	})
+
+	return result
+}
+
+// transition - смена смещения часового пояса в момент at
+type transition struct {
+	at                   time.Time
+	name                 string
+	offsetFrom, offsetTo int
+}
+
+// zoneTransitions находит переходы часового пояса loc в окне [from, to):
+// смещение проверяется раз в сутки, момент перехода уточняется двоичным поиском
+func zoneTransitions(loc *time.Location, from, to time.Time) []transition {
+	var transitions []transition
+
+	_, prevOffset := from.In(loc).Zone()
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		name, offset := next.In(loc).Zone()
+		if offset == prevOffset {
+			continue
+		}
+
+		// Переход лежит в (day, next]: ищем первую секунду с новым смещением
+		lo, hi := day, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if _, o := mid.In(loc).Zone(); o == prevOffset {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		transitions = append(transitions, transition{at: hi.Truncate(time.Second), name: name, offsetFrom: prevOffset, offsetTo: offset})
+		prevOffset = offset
+	}
+
+	return transitions
+}
+
+// eventZone возвращает часовой пояс IANA события, если он задан
+func eventZone(e *models.Event) (*time.Location, bool) {
+	if e.TimeZone == "" {
+		return nil, false
+	}
+	loc, err := models.LoadLocation(e.TimeZone)
+	if err != nil || loc == time.UTC {
+		return nil, false
+	}
+	return loc, true
+}
+
+// formatTime форматирует значение свойства даты вместе с параметрами:
+// ";VALUE=DATE:20260101" для событий на весь день, ";TZID=Europe/Moscow:..."
+// для событий с часовым поясом и ":...Z" в UTC для остальных
+func formatTime(e *models.Event, t time.Time) string {
+	if e.AllDay {
+		return ";VALUE=DATE:" + t.In(e.Location()).Format(dateLayout)
+	}
+	if loc, ok := eventZone(e); ok {
+		return ";TZID=" + loc.String() + ":" + t.In(loc).Format(dateTimeLayout)
+	}
+	return ":" + formatUTC(t)
+}
+
+// formatRule возвращает RRULE серии. UNTIL у событий на весь день
+// записывается датой, так как тип значения должен совпадать с DTSTART
+func formatRule(e *models.Event) string {
+	rule := e.Recurrence.String()
+	if !e.AllDay || e.Recurrence.Until.IsZero() {
+		return rule
+	}
+
+	until := "UNTIL=" + e.Recurrence.Until.UTC().Format(dateTimeLayout) + "Z"
+	return strings.Replace(rule, until, "UNTIL="+e.Recurrence.Until.In(e.Location()).Format(dateLayout), 1)
+}
+
+// formatUTC форматирует момент времени в UTC (форма DATE-TIME с суффиксом Z)
+func formatUTC(t time.Time) string {
+	return t.UTC().Format(dateTimeLayout) + "Z"
+}
+
+// formatOffset форматирует смещение от UTC в секундах как +HHMM или +HHMMSS
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	t := time.Date(0, 1, 1, 0, 0, offset, 0, time.UTC)
+	if offset%60 != 0 {
+		return sign + t.Format("150405")
+	}
+	return sign + t.Format("1504")
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return textEscaper.Replace(s)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
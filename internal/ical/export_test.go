@@ -0,0 +1,127 @@
+// internal/ical/export_test.go
+package ical
+
+import (
+	"bytes"
+	"schedule-app/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeWeekParity(t *testing.T) {
+	moscow, err := models.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	semester := &models.Semester{
+		Start:    time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC),
+		Holidays: []models.DateRange{{From: time.Date(2026, time.November, 4, 0, 0, 0, 0, time.UTC), To: time.Date(2026, time.November, 4, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	lesson := func(start time.Time, parity models.WeekParity, rule *models.RecurrenceRule) *models.Event {
+		event := models.NewEvent("Лекция", start, start.Add(90*time.Minute), nil, models.EventDetails{})
+		event.TimeZone = "Europe/Moscow"
+		event.WeekParity = parity
+		event.Recurrence = rule
+		return event
+	}
+	weekly := func() *models.RecurrenceRule {
+		return &models.RecurrenceRule{Freq: models.FreqWeekly}
+	}
+
+	tests := []struct {
+		name    string
+		event   *models.Event
+		want    []string
+		notWant []string
+	}{
+		{
+			// Серия начинается на четной неделе 2: экспорт начинается с недели 3
+			// и заканчивается на последней нечетной неделе семестра (17)
+			name:  "odd weeks",
+			event: lesson(time.Date(2026, time.September, 7, 10, 0, 0, 0, moscow), models.WeekOdd, weekly()),
+			want: []string{
+				"DTSTART;TZID=Europe/Moscow:20260914T100000",
+				"RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20261221T070000Z",
+			},
+			notWant: []string{"EXDATE"},
+		},
+		{
+			// Каникулы 4 ноября приходятся на четную неделю 10
+			name:  "even weeks with holiday",
+			event: lesson(time.Date(2026, time.September, 2, 10, 0, 0, 0, moscow), models.WeekEven, weekly()),
+			want: []string{
+				"DTSTART;TZID=Europe/Moscow:20260909T100000",
+				"RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20261230T070000Z",
+				"EXDATE;TZID=Europe/Moscow:20261104T100000",
+			},
+		},
+		{
+			// Из десяти понедельников с 7 сентября нечетными неделями остаются пять
+			name:  "count becomes until",
+			event: lesson(time.Date(2026, time.September, 7, 10, 0, 0, 0, moscow), models.WeekOdd, &models.RecurrenceRule{Freq: models.FreqWeekly, Count: 10}),
+			want: []string{
+				"DTSTART;TZID=Europe/Moscow:20260914T100000",
+				"RRULE:FREQ=WEEKLY;INTERVAL=2;UNTIL=20261109T070000Z",
+			},
+			notWant: []string{"COUNT", "EXDATE"},
+		},
+		{
+			// Каждую неделю: интервал не меняется, каникулы - исключение
+			name:  "every week",
+			event: lesson(time.Date(2026, time.November, 2, 10, 0, 0, 0, moscow), models.WeekEvery, &models.RecurrenceRule{Freq: models.FreqDaily, Count: 5}),
+			want: []string{
+				"DTSTART;TZID=Europe/Moscow:20261102T100000",
+				"RRULE:FREQ=DAILY;UNTIL=20261106T070000Z",
+				"EXDATE;TZID=Europe/Moscow:20261104T100000",
+			},
+			notWant: []string{"INTERVAL"},
+		},
+		{
+			name:  "no parity",
+			event: lesson(time.Date(2026, time.September, 7, 10, 0, 0, 0, moscow), models.WeekAny, weekly()),
+			want: []string{
+				"DTSTART;TZID=Europe/Moscow:20260907T100000",
+				"RRULE:FREQ=WEEKLY",
+			},
+			notWant: []string{"UNTIL", "INTERVAL"},
+		},
+		{
+			name:    "single event in other week",
+			event:   lesson(time.Date(2026, time.September, 7, 10, 0, 0, 0, moscow), models.WeekOdd, nil),
+			notWant: []string{"BEGIN:VEVENT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, []*models.Event{tt.event}, "", semester); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(buf.String(), "\r\n")
+
+			for _, want := range tt.want {
+				if !containsLine(lines, want) {
+					t.Errorf("нет строки %q в календаре:\n%s", want, buf.String())
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(buf.String(), notWant) {
+					t.Errorf("лишнее %q в календаре:\n%s", notWant, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,794 @@
+// internal/ical/import.go
+package ical
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ItemError - ошибка отдельного события календаря при импорте. Остальные
+// события файла импортируются независимо от нее
+type ItemError struct {
+	// Line - номер строки BEGIN:VEVENT в файле
+	Line    int    `json:"line"`
+	UID     string `json:"uid,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult - итог импорта календаря
+type ImportResult struct {
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Errors  []ItemError `json:"errors"`
+}
+
+// Import разбирает календарь iCalendar и сохраняет его события в store.
+// Событие получает ID, производный от UID (см. EventID), поэтому повторный
+// импорт того же файла обновляет события, а не дублирует их. Время без
+// часового пояса и даты событий на весь день относятся к поясу loc.
+// Ошибка возвращается, только если данные не являются календарем; ошибки
+// отдельных событий собираются в ImportResult.Errors
+func Import(store storage.EventStore, r io.Reader, loc *time.Location) (*ImportResult, error) {
+	items, errs, err := decode(r, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Errors: errs}
+	for _, it := range items {
+		created, err := storage.Upsert(store, it.event)
+		if err != nil {
+			result.Errors = append(result.Errors, it.fail(err))
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	if result.Errors == nil {
+		result.Errors = []ItemError{}
+	}
+	return result, nil
+}
+
+// safeIDRe - UID, пригодные для использования в качестве ID события без
+// изменений (в том числе ID, выгруженные самим приложением)
+var safeIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@+-]{0,127}$`)
+
+// EventID возвращает ID события для UID из календаря. Подходящий UID
+// используется как есть, остальные заменяются стабильным хешем: ID входит
+// в URL API и не должен совпадать с форматом ID экземпляра серии
+func EventID(uid string) string {
+	if safeIDRe.MatchString(uid) {
+		if _, _, ok := models.ParseOccurrenceID(uid); !ok {
+			return uid
+		}
+	}
+
+	sum := sha256.Sum256([]byte(uid))
+	return "ics-" + hex.EncodeToString(sum[:16])
+}
+
+// property - строка контента: NAME;PARAM=value:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// component - компонент календаря (VEVENT, VTIMEZONE, ...) с вложенными компонентами
+type component struct {
+	name       string
+	line       int
+	props      []*property
+	components []*component
+}
+
+// get возвращает первое свойство с именем name
+func (c *component) get(name string) *property {
+	for _, prop := range c.props {
+		if prop.name == name {
+			return prop
+		}
+	}
+	return nil
+}
+
+// text возвращает значение текстового свойства без экранирования
+func (c *component) text(name string) string {
+	if prop := c.get(name); prop != nil {
+		return unescapeText(prop.value)
+	}
+	return ""
+}
+
+// item - событие, готовое к сохранению, и его положение в файле для ошибок
+type item struct {
+	event *models.Event
+	line  int
+	uid   string
+}
+
+// fail создает ошибку импорта этого события
+func (it *item) fail(err error) ItemError {
+	return ItemError{Line: it.line, UID: it.uid, Summary: it.event.Title, Message: err.Error()}
+}
+
+// decode разбирает календарь в события. Изменения и отмены экземпляров
+// (RECURRENCE-ID) присоединяются к своим сериям
+func decode(r io.Reader, loc *time.Location) ([]*item, []ItemError, error) {
+	calendars, err := parse(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vevents []*component
+	zones := make(map[string]*time.Location)
+	for _, calendar := range calendars {
+		for _, c := range calendar.components {
+			switch c.name {
+			case "VEVENT":
+				vevents = append(vevents, c)
+			case "VTIMEZONE":
+				if tzid := c.text("TZID"); tzid != "" {
+					if zone := zoneFromComponent(tzid, c); zone != nil {
+						zones[tzid] = zone
+					}
+				}
+			}
+		}
+	}
+
+	d := &decoder{zones: zones, loc: loc}
+	var items []*item
+	var errs []ItemError
+	series := make(map[string]*item)
+
+	// Сначала серии и одиночные события, затем измененные экземпляры
+	for _, c := range vevents {
+		if c.get("RECURRENCE-ID") != nil {
+			continue
+		}
+		if strings.EqualFold(c.text("STATUS"), "CANCELLED") {
+			continue
+		}
+
+		uid := c.text("UID")
+		event, err := d.event(c)
+		if err != nil {
+			errs = append(errs, componentError(c, err))
+			continue
+		}
+		if uid != "" {
+			if _, dup := series[uid]; dup {
+				errs = append(errs, componentError(c, fmt.Errorf("UID %s повторяется в файле", uid)))
+				continue
+			}
+			event.ID = EventID(uid)
+		}
+
+		it := &item{event: event, line: c.line, uid: uid}
+		if uid != "" {
+			series[uid] = it
+		}
+		items = append(items, it)
+	}
+
+	for _, c := range vevents {
+		if c.get("RECURRENCE-ID") == nil {
+			continue
+		}
+		parent, ok := series[c.text("UID")]
+		if !ok || !parent.event.IsRecurring() {
+			errs = append(errs, componentError(c, fmt.Errorf("не найдена серия для RECURRENCE-ID")))
+			continue
+		}
+		if err := d.attachOverride(parent.event, c); err != nil {
+			errs = append(errs, componentError(c, err))
+		}
+	}
+
+	// Проверка выполняется после присоединения экземпляров
+	valid := items[:0]
+	for _, it := range items {
+		if err := it.event.Validate(); err != nil {
+			errs = append(errs, it.fail(err))
+			continue
+		}
+		valid = append(valid, it)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+
+	return valid, errs, nil
+}
+
+// componentError создает ошибку импорта для компонента VEVENT
+func componentError(c *component, err error) ItemError {
+	return ItemError{Line: c.line, UID: c.text("UID"), Summary: c.text("SUMMARY"), Message: err.Error()}
+}
+
+// decoder преобразует компоненты VEVENT в события
+type decoder struct {
+	// zones - часовые пояса из VTIMEZONE по TZID
+	zones map[string]*time.Location
+	// loc - пояс для времени без пояса и дат событий на весь день
+	loc *time.Location
+}
+
+// event создает событие из компонента VEVENT
+func (d *decoder) event(c *component) (*models.Event, error) {
+	startProp := c.get("DTSTART")
+	if startProp == nil {
+		return nil, fmt.Errorf("не указано время начала (DTSTART)")
+	}
+	start, err := d.time(startProp)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := d.end(c, start)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.NewEvent(c.text("SUMMARY"), start.t, end, categories(c), models.EventDetails{
+		Description: c.text("DESCRIPTION"),
+		Place:       c.text("LOCATION"),
+		URL:         strings.TrimSpace(c.text("URL")),
+	})
+	event.AllDay = start.date
+	event.TimeZone = start.zone
+	event.Reminders = reminders(c)
+	if created := c.get("CREATED"); created != nil {
+		if t, err := d.time(created); err == nil {
+			event.CreatedAt = t.t
+		}
+	}
+
+	if ruleProp := c.get("RRULE"); ruleProp != nil {
+		rule, err := d.rule(ruleProp.value, start)
+		if err != nil {
+			return nil, models.ValidationError{Field: "recurrence", Message: "Неверное правило повторения: " + err.Error()}
+		}
+		event.Recurrence = rule
+
+		for _, prop := range c.props {
+			if prop.name != "EXDATE" {
+				continue
+			}
+			exdates, err := d.times(prop, start)
+			if err != nil {
+				return nil, err
+			}
+			event.ExDates = append(event.ExDates, exdates...)
+		}
+	}
+
+	return event, nil
+}
+
+// attachOverride присоединяет к серии измененный или отмененный экземпляр
+func (d *decoder) attachOverride(series *models.Event, c *component) error {
+	seriesStart := eventTime{t: series.StartTime.In(series.Location()), date: series.AllDay}
+	originals, err := d.times(c.get("RECURRENCE-ID"), seriesStart)
+	if err != nil {
+		return err
+	}
+	original := originals[0]
+	if _, ok := series.OccurrenceAt(original); !ok {
+		return fmt.Errorf("RECURRENCE-ID не совпадает ни с одним экземпляром серии")
+	}
+
+	if strings.EqualFold(c.text("STATUS"), "CANCELLED") {
+		series.CancelOccurrence(original)
+		return nil
+	}
+
+	override, err := d.event(c)
+	if err != nil {
+		return err
+	}
+	override.OriginalStartTime = &original
+	series.SetOverride(override)
+	return nil
+}
+
+// reminders возвращает напоминания из вложенных VALARM. Учитываются только
+// триггеры относительно начала события, не позже его начала и не раньше
+// чем за models.MaxReminderOffset минут; лишние сверх models.MaxReminders
+// отбрасываются
+func reminders(c *component) []int {
+	var offsets []int
+	for _, alarm := range c.components {
+		if alarm.name != "VALARM" || len(offsets) == models.MaxReminders {
+			continue
+		}
+		trigger := alarm.get("TRIGGER")
+		if trigger == nil || strings.EqualFold(trigger.params["VALUE"], "DATE-TIME") || strings.EqualFold(trigger.params["RELATED"], "END") {
+			continue
+		}
+		days, rest, err := parseDuration(trigger.value)
+		if err != nil {
+			continue
+		}
+
+		offset := -(time.Duration(days)*24*time.Hour + rest) / time.Minute
+		if offset >= 0 && offset <= models.MaxReminderOffset {
+			offsets = append(offsets, int(offset))
+		}
+	}
+	return offsets
+}
+
+// end вычисляет окончание события из DTEND или DURATION. Без них событие
+// на весь день длится один день, а остальные - ноль минут (RFC 5545, 3.6.1)
+func (d *decoder) end(c *component, start eventTime) (time.Time, error) {
+	if prop := c.get("DTEND"); prop != nil {
+		end, err := d.time(prop)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if start.date {
+			return d.dateIn(end.t, start.t.Location()), nil
+		}
+		return end.t, nil
+	}
+
+	if prop := c.get("DURATION"); prop != nil {
+		days, rest, err := parseDuration(prop.value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return start.t.AddDate(0, 0, days).Add(rest), nil
+	}
+
+	if start.date {
+		return start.t.AddDate(0, 0, 1), nil
+	}
+	return start.t, nil
+}
+
+// rule разбирает RRULE. UNTIL в виде даты или местного времени относится
+// к часовому поясу начала серии, а не к UTC, как считает ParseRecurrenceRule
+func (d *decoder) rule(value string, start eventTime) (*models.RecurrenceRule, error) {
+	var until string
+	parts := strings.Split(value, ";")
+	kept := parts[:0]
+	for _, part := range parts {
+		if key, val, _ := strings.Cut(part, "="); strings.EqualFold(key, "UNTIL") {
+			until = val
+			continue
+		}
+		kept = append(kept, part)
+	}
+
+	rule, err := models.ParseRecurrenceRule(strings.Join(kept, ";"))
+	if err != nil {
+		return nil, err
+	}
+
+	if until != "" {
+		date := len(until) == len(dateLayout)
+		t, err := d.parseValue(until, start.t.Location(), date)
+		if err != nil {
+			return nil, fmt.Errorf("неверный UNTIL: %s", until)
+		}
+		// Дата в UNTIL серии, заданной временем, включает весь этот день
+		if date && !start.date {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		rule.Until = t
+	}
+
+	return rule, nil
+}
+
+// eventTime - разобранное значение DATE или DATE-TIME
+type eventTime struct {
+	t time.Time
+	// date - значение без времени (VALUE=DATE)
+	date bool
+	// zone - имя часового пояса IANA или пустая строка
+	zone string
+}
+
+// time разбирает свойство со значением DATE или DATE-TIME
+func (d *decoder) time(prop *property) (eventTime, error) {
+	values, err := d.times(prop, eventTime{})
+	if err != nil {
+		return eventTime{}, err
+	}
+
+	result := eventTime{t: values[0], date: isDate(prop, strings.Split(prop.value, ",")[0])}
+	if tzid, ok := prop.params["TZID"]; ok {
+		if _, err := models.LoadLocation(zoneName(tzid)); err == nil {
+			result.zone = zoneName(tzid)
+		}
+	} else if result.date || !strings.HasSuffix(prop.value, "Z") {
+		result.zone = d.loc.String()
+	}
+	return result, nil
+}
+
+// times разбирает список значений свойства (EXDATE может содержать несколько
+// дат через запятую). Дата без времени у серии, заданной временем, означает
+// экземпляр этого дня (series - начало серии)
+func (d *decoder) times(prop *property, series eventTime) ([]time.Time, error) {
+	loc := d.loc
+	if tzid, ok := prop.params["TZID"]; ok {
+		zone, err := d.zone(tzid)
+		if err != nil {
+			return nil, err
+		}
+		loc = zone
+	}
+
+	var result []time.Time
+	for _, value := range strings.Split(prop.value, ",") {
+		value = strings.TrimSpace(value)
+		t, err := d.parseValue(value, loc, isDate(prop, value))
+		if err != nil {
+			return nil, fmt.Errorf("неверное значение %s: %s", prop.name, value)
+		}
+		if isDate(prop, value) && !series.t.IsZero() {
+			if series.date {
+				t = d.dateIn(t, series.t.Location())
+			} else {
+				clock := series.t
+				t = time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+			}
+		}
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// parseValue разбирает дату или время: "20260101", "20260101T090000Z" (UTC)
+// или "20260101T090000" (в поясе loc)
+func (d *decoder) parseValue(value string, loc *time.Location, date bool) (time.Time, error) {
+	if date {
+		return time.ParseInLocation(dateLayout, value, loc)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(dateTimeLayout+"Z", value)
+	}
+	return time.ParseInLocation(dateTimeLayout, value, loc)
+}
+
+// dateIn переносит календарную дату t в полночь пояса loc
+func (d *decoder) dateIn(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// zone находит часовой пояс по TZID: сначала как имя IANA, затем по VTIMEZONE файла
+func (d *decoder) zone(tzid string) (*time.Location, error) {
+	if loc, err := models.LoadLocation(zoneName(tzid)); err == nil {
+		return loc, nil
+	}
+	if loc, ok := d.zones[tzid]; ok {
+		return loc, nil
+	}
+	return nil, fmt.Errorf("неизвестный часовой пояс: %s", tzid)
+}
+
+// zoneName извлекает имя IANA из TZID вида "/mozilla.org/20050126_1/Europe/Moscow":
+// подходящим считается самый длинный суффикс пути, который является поясом IANA
+func zoneName(tzid string) string {
+	tzid = strings.Trim(tzid, `"`)
+	if _, err := models.LoadLocation(tzid); err == nil {
+		return tzid
+	}
+	for i := 0; i < len(tzid); i++ {
+		if tzid[i] != '/' {
+			continue
+		}
+		if name := tzid[i+1:]; name != "" {
+			if _, err := models.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+	return tzid
+}
+
+// zoneFromComponent создает часовой пояс с постоянным смещением по VTIMEZONE,
+// чей TZID не является поясом IANA (например, "Russian Standard Time").
+// Используется смещение последнего по времени наблюдения STANDARD, а если
+// их нет - последнего DAYLIGHT
+func zoneFromComponent(tzid string, c *component) *time.Location {
+	var best *component
+	for _, obs := range c.components {
+		if obs.name != "STANDARD" && obs.name != "DAYLIGHT" {
+			continue
+		}
+		switch {
+		case best == nil,
+			obs.name == "STANDARD" && best.name == "DAYLIGHT",
+			obs.name == best.name && obs.text("DTSTART") > best.text("DTSTART"):
+			best = obs
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	offset, err := parseOffset(best.text("TZOFFSETTO"))
+	if err != nil {
+		return nil
+	}
+	return time.FixedZone(tzid, offset)
+}
+
+// isDate сообщает, что значение свойства - дата без времени
+func isDate(prop *property, value string) bool {
+	return strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(dateLayout)
+}
+
+// categories собирает теги из всех свойств CATEGORIES
+func categories(c *component) []string {
+	var tags []string
+	for _, prop := range c.props {
+		if prop.name != "CATEGORIES" {
+			continue
+		}
+		for _, tag := range splitText(prop.value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// durationRe - длительность RFC 5545: P1W, P2D, PT1H30M, -P1D
+var durationRe = regexp.MustCompile(`^([+-]?)P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration разбирает DURATION на число дней (недели переводятся в дни)
+// и остаток: дни прибавляются по календарю, чтобы не зависеть от летнего времени
+func parseDuration(value string) (int, time.Duration, error) {
+	m := durationRe.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if m == nil || m[2]+m[3]+m[4]+m[5]+m[6] == "" {
+		return 0, 0, fmt.Errorf("неверная длительность: %s", value)
+	}
+
+	n := func(s string) int {
+		v, _ := strconv.Atoi(s)
+		return v
+	}
+	days := n(m[2])*7 + n(m[3])
+	rest := time.Duration(n(m[4]))*time.Hour + time.Duration(n(m[5]))*time.Minute + time.Duration(n(m[6]))*time.Second
+	if m[1] == "-" {
+		return -days, -rest, nil
+	}
+	return days, rest, nil
+}
+
+// parseOffset разбирает смещение UTC вида +0300 или -043000 в секунды
+func parseOffset(value string) (int, error) {
+	if len(value) != 5 && len(value) != 7 || (value[0] != '+' && value[0] != '-') {
+		return 0, fmt.Errorf("неверное смещение: %s", value)
+	}
+
+	digits, err := strconv.Atoi(value[1:])
+	if err != nil {
+		return 0, fmt.Errorf("неверное смещение: %s", value)
+	}
+	if len(value) == 5 {
+		digits *= 100
+	}
+
+	offset := digits/10000*3600 + digits/100%100*60 + digits%100
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// parse читает поток iCalendar в дерево компонентов VCALENDAR
+func parse(r io.Reader) ([]*component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var calendars []*component
+	var stack []*component
+	for _, l := range lines {
+		prop, err := parseProperty(l.text)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", l.number, err)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			c := &component{name: strings.ToUpper(prop.value), line: l.number}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.components = append(parent.components, c)
+			} else if c.name != "VCALENDAR" {
+				return nil, fmt.Errorf("строка %d: ожидается BEGIN:VCALENDAR", l.number)
+			} else {
+				calendars = append(calendars, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].name != strings.ToUpper(prop.value) {
+				return nil, fmt.Errorf("строка %d: неожиданный END:%s", l.number, prop.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("строка %d: свойство вне VCALENDAR", l.number)
+			}
+			c := stack[len(stack)-1]
+			c.props = append(c.props, prop)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("не закрыт компонент %s", stack[len(stack)-1].name)
+	}
+	if len(calendars) == 0 {
+		return nil, fmt.Errorf("файл не содержит календарь iCalendar")
+	}
+
+	return calendars, nil
+}
+
+// contentLine - строка контента после склейки переносов и номер ее первой строки
+type contentLine struct {
+	text   string
+	number int
+}
+
+// unfold склеивает перенесенные строки: строка, начинающаяся с пробела
+// или табуляции, продолжает предыдущую (RFC 5545, 3.1)
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var lines []contentLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lines = append(lines, contentLine{text: text, number: number})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении календаря: %w", err)
+	}
+
+	return lines, nil
+}
+
+// parseProperty разбирает строку контента NAME;PARAM=value;PARAM="value":value.
+// Двоеточие и точка с запятой внутри кавычек не разделяют части строки
+func parseProperty(line string) (*property, error) {
+	prop := &property{params: make(map[string]string)}
+
+	quoted := false
+	nameEnd, valueStart := -1, -1
+	for i, ch := range line {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case ch == ';' && !quoted && nameEnd < 0:
+			nameEnd = i
+		case ch == ':' && !quoted:
+			valueStart = i
+		}
+		if valueStart >= 0 {
+			break
+		}
+	}
+	if valueStart < 0 {
+		return nil, fmt.Errorf("неверная строка: %s", line)
+	}
+	if nameEnd < 0 {
+		nameEnd = valueStart
+	}
+
+	prop.name = strings.ToUpper(line[:nameEnd])
+	prop.value = line[valueStart+1:]
+	if prop.name == "" {
+		return nil, fmt.Errorf("неверная строка: %s", line)
+	}
+
+	if nameEnd < valueStart {
+		for _, param := range splitParams(line[nameEnd+1 : valueStart]) {
+			key, val, _ := strings.Cut(param, "=")
+			prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+		}
+	}
+
+	return prop, nil
+}
+
+// splitParams разделяет параметры свойства по точке с запятой вне кавычек
+func splitParams(s string) []string {
+	var params []string
+	quoted, start := false, 0
+	for i, ch := range s {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case ch == ';' && !quoted:
+			params = append(params, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, s[start:])
+}
+
+// splitText разделяет список значений TEXT по запятым, не экранированным
+// обратной косой чертой, и снимает экранирование
+func splitText(s string) []string {
+	var values []string
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			current.WriteByte(s[i])
+			current.WriteByte(s[i+1])
+			i++
+		case s[i] == ',':
+			values = append(values, unescapeText(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	return append(values, unescapeText(current.String()))
+}
+
+// unescapeText снимает экранирование значения TEXT (RFC 5545, 3.3.11)
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
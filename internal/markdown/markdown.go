@@ -0,0 +1,184 @@
+// internal/markdown/markdown.go
+
+// Package markdown преобразует описания событий в формате Markdown в HTML.
+// Поддерживается распространенное подмножество разметки: абзацы, заголовки,
+// списки, цитаты, блоки кода, горизонтальные линии, выделение, код в строке
+// и ссылки. Любой HTML во входном тексте экранируется, а ссылки допускаются
+// только со схемами http, https и mailto, поэтому результат можно вставлять
+// в страницу без дополнительной очистки
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingRe     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	unorderedRe   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe     = regexp.MustCompile(`^\s*\d{1,9}[.)]\s+(.*)$`)
+	quoteRe       = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	ruleRe        = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	fenceRe       = regexp.MustCompile("^\\s*(```|~~~)")
+	codeSpanRe    = regexp.MustCompile("`([^`]+)`")
+	linkRe        = regexp.MustCompile(`\[([^\]]+)\]\(\s*([^\s)]+)\s*\)`)
+	autolinkRe    = regexp.MustCompile(`(?:https?://|mailto:)[^\s<>"]+[^\s<>".,;:!?)\]]`)
+	strongRe      = regexp.MustCompile(`(\*\*|__)([^*_]+?)(\*\*|__)`)
+	emphasisRe    = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	underscoreRe  = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\s][^_]*?)_($|[^\p{L}\p{N}_])`)
+	placeholderRe = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// ToHTML преобразует текст в формате Markdown в безопасный HTML
+func ToHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\x00", "")
+	lines := strings.Split(src, "\n")
+
+	var out strings.Builder
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + inline(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fenceRe.MatchString(line):
+			flush()
+			fence := fenceRe.FindStringSubmatch(line)[1]
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingRe.MatchString(line):
+			flush()
+			m := headingRe.FindStringSubmatch(line)
+			tag := "h" + strconv.Itoa(len(m[1]))
+			out.WriteString("<" + tag + ">" + inline(m[2]) + "</" + tag + ">\n")
+
+		case ruleRe.MatchString(line):
+			flush()
+			out.WriteString("<hr>\n")
+
+		case unorderedRe.MatchString(line), orderedRe.MatchString(line):
+			flush()
+			re, tag := unorderedRe, "ul"
+			if !unorderedRe.MatchString(line) {
+				re, tag = orderedRe, "ol"
+			}
+			out.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && re.MatchString(lines[i]); i++ {
+				out.WriteString("<li>" + inline(re.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+			}
+			i--
+			out.WriteString("</" + tag + ">\n")
+
+		case quoteRe.MatchString(line):
+			flush()
+			var quote []string
+			for ; i < len(lines) && quoteRe.MatchString(lines[i]); i++ {
+				quote = append(quote, quoteRe.FindStringSubmatch(lines[i])[1])
+			}
+			i--
+			out.WriteString("<blockquote>" + ToHTML(strings.Join(quote, "\n")) + "</blockquote>\n")
+
+		default:
+			paragraph = append(paragraph, strings.TrimSpace(line))
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
+// inline размечает текст внутри блока. Код и ссылки заменяются заглушками
+// до экранирования, чтобы выделение не затрагивало их содержимое
+func inline(text string) string {
+	var fragments []string
+	hold := func(fragment string) string {
+		fragments = append(fragments, fragment)
+		return "\x00" + strconv.Itoa(len(fragments)-1) + "\x00"
+	}
+	restore := func(text string) string {
+		return placeholderRe.ReplaceAllStringFunc(text, func(m string) string {
+			n, _ := strconv.Atoi(placeholderRe.FindStringSubmatch(m)[1])
+			return fragments[n]
+		})
+	}
+
+	text = codeSpanRe.ReplaceAllStringFunc(text, func(m string) string {
+		return hold("<code>" + html.EscapeString(codeSpanRe.FindStringSubmatch(m)[1]) + "</code>")
+	})
+
+	text = linkRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := linkRe.FindStringSubmatch(m)
+		// Код в подписи уже заменен заглушкой: подставляем его сразу,
+		// иначе заглушка окажется внутри другого фрагмента
+		label := restore(emphasize(html.EscapeString(parts[1])))
+		if !safeURL(parts[2]) {
+			return hold(label)
+		}
+		return hold(anchor(parts[2], label))
+	})
+
+	text = autolinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		if !safeURL(m) {
+			return hold(html.EscapeString(m))
+		}
+		return hold(anchor(m, html.EscapeString(m)))
+	})
+
+	text = emphasize(html.EscapeString(text))
+	text = strings.ReplaceAll(text, "\n", "<br>\n")
+
+	return restore(text)
+}
+
+// emphasize размечает полужирный текст и курсив в уже экранированном тексте
+func emphasize(text string) string {
+	text = strongRe.ReplaceAllStringFunc(text, func(m string) string {
+		parts := strongRe.FindStringSubmatch(m)
+		if parts[1] != parts[3] {
+			return m
+		}
+		return "<strong>" + parts[2] + "</strong>"
+	})
+	text = emphasisRe.ReplaceAllString(text, "<em>$1</em>")
+	return underscoreRe.ReplaceAllString(text, "$1<em>$2</em>$3")
+}
+
+// anchor создает ссылку, открывающуюся в новой вкладке без передачи referrer
+func anchor(href, label string) string {
+	return `<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer" target="_blank">` + label + `</a>`
+}
+
+// safeURL допускает только абсолютные ссылки со схемами http, https и mailto:
+// javascript:, data: и подобные схемы отбрасываются
+func safeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// internal/markdown/markdown_test.go
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "link",
+			src:  "[Материалы](https://example.com/a?b=1&c=2)",
+			want: `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer" target="_blank">Материалы</a></p>` + "\n",
+		},
+		{
+			name: "javascript link",
+			src:  "[нажми](javascript:alert%28document.cookie%29)",
+			want: "<p>нажми</p>\n",
+		},
+		{
+			name: "raw script",
+			src:  "<script>alert('x')</script>",
+			want: "<p>&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;</p>\n",
+		},
+		{
+			name: "code in link label",
+			src:  "[`cfg`](https://example.com)",
+			want: `<p><a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank"><code>cfg</code></a></p>` + "\n",
+		},
+		{
+			name: "code in unsafe link label",
+			src:  "[`cfg`](data:text/html,x)",
+			want: "<p><code>cfg</code></p>\n",
+		},
+		{
+			name: "emphasis and code",
+			src:  "**Важно:** `a*b*c` и _курсив_",
+			want: "<p><strong>Важно:</strong> <code>a*b*c</code> и <em>курсив</em></p>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ToHTML(tt.src)
+			if got != tt.want {
+				t.Errorf("ToHTML(%q) = %q, ожидалось %q", tt.src, got, tt.want)
+			}
+			if strings.Contains(got, "\x00") {
+				t.Errorf("ToHTML(%q) оставил заглушку в результате", tt.src)
+			}
+		})
+	}
+}
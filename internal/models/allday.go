@@ -0,0 +1,176 @@
+// internal/models/allday.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout - формат даты без времени, в котором передаются границы событий на весь день
+const DateLayout = "2006-01-02"
+
+// EventTime - время начала или окончания события в JSON: момент времени
+// в формате RFC 3339 либо дата без времени ("2006-01-02"). Дата без времени
+// относится к часовому поясу события и превращается в момент методом In
+type EventTime struct {
+	Time     time.Time
+	DateOnly bool
+}
+
+// UnmarshalJSON разбирает время в формате RFC 3339 или дату без времени
+func (t *EventTime) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		*t = EventTime{Time: date, DateOnly: true}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return fmt.Errorf("неверный формат времени %q: ожидается RFC 3339 или YYYY-MM-DD", value)
+	}
+
+	*t = EventTime{Time: parsed}
+	return nil
+}
+
+// IsZero сообщает, что время не задано
+func (t EventTime) IsZero() bool {
+	return t.Time.IsZero()
+}
+
+// In возвращает момент времени; дата без времени означает полночь в поясе loc
+func (t EventTime) In(loc *time.Location) time.Time {
+	if t.DateOnly {
+		return time.Date(t.Time.Year(), t.Time.Month(), t.Time.Day(), 0, 0, 0, 0, loc)
+	}
+	return t.Time
+}
+
+// eventJSON - представление Event без собственных методов сериализации
+type eventJSON Event
+
+// MarshalJSON выводит границы события на весь день как даты без времени
+// в часовом поясе события; дата окончания не включается (как DTEND в iCalendar)
+func (e Event) MarshalJSON() ([]byte, error) {
+	if !e.AllDay {
+		return json.Marshal(eventJSON(e))
+	}
+
+	loc := e.Location()
+	return json.Marshal(struct {
+		eventJSON
+		StartTime string `json:"startTime"`
+		EndTime   string `json:"endTime"`
+	}{
+		eventJSON: eventJSON(e),
+		StartTime: e.StartTime.In(loc).Format(DateLayout),
+		EndTime:   e.EndTime.In(loc).Format(DateLayout),
+	})
+}
+
+// UnmarshalJSON принимает границы события как в формате RFC 3339, так и в виде
+// дат без времени, которые относятся к часовому поясу события
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		eventJSON
+		StartTime EventTime `json:"startTime"`
+		EndTime   EventTime `json:"endTime"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Event(raw.eventJSON)
+	loc := time.UTC
+	if e.TimeZone != "" {
+		if zone, err := LoadLocation(e.TimeZone); err == nil {
+			loc = zone
+		}
+	}
+	e.StartTime = raw.StartTime.In(loc)
+	e.EndTime = raw.EndTime.In(loc)
+
+	return nil
+}
+
+// NormalizeAllDay приводит границы события на весь день к полуночи в часовом
+// поясе события: начало - к началу своего дня, окончание - к началу следующего
+// за последним днем события. Событие длится не меньше одного дня
+func (e *Event) NormalizeAllDay() {
+	if !e.AllDay {
+		return
+	}
+
+	loc := e.Location()
+	e.StartTime = startOfDay(e.StartTime.In(loc))
+
+	end := e.EndTime.In(loc)
+	endDay := startOfDay(end)
+	if end.After(endDay) {
+		endDay = endDay.AddDate(0, 0, 1)
+	}
+	if !endDay.After(e.StartTime) {
+		endDay = e.StartTime.AddDate(0, 0, 1)
+	}
+	e.EndTime = endDay
+}
+
+// CoversDate сообщает, приходится ли событие на календарную дату date
+// (дата берется в собственном часовом поясе date). Событие на весь день
+// сравнивается по датам в своем поясе, остальные - по пересечению с сутками date
+func (e *Event) CoversDate(date time.Time) bool {
+	if !e.AllDay {
+		dayStart := startOfDay(date)
+		return e.Overlaps(dayStart, dayStart.AddDate(0, 0, 1))
+	}
+
+	loc := e.Location()
+	day := civilDate(date)
+	return !day.Before(civilDate(e.StartTime.In(loc))) && day.Before(civilDate(e.EndTime.In(loc)))
+}
+
+// Overlaps сообщает, пересекается ли событие с окном [from, to)
+func (e *Event) Overlaps(from, to time.Time) bool {
+	return overlaps(e.StartTime, e.EndTime, from, to)
+}
+
+// DaysBetween возвращает число календарных дней от даты from до даты to
+// (каждая дата берется в своем часовом поясе)
+func DaysBetween(from, to time.Time) int {
+	return int(civilDate(to).Sub(civilDate(from)).Hours() / 24)
+}
+
+// endFor возвращает время окончания экземпляра, начинающегося в start. Событие
+// на весь день длится целое число дней независимо от перехода на летнее время
+func (e *Event) endFor(start time.Time) time.Time {
+	if e.AllDay {
+		loc := e.Location()
+		return start.In(loc).AddDate(0, 0, DaysBetween(e.StartTime.In(loc), e.EndTime.In(loc)))
+	}
+	return start.Add(e.EndTime.Sub(e.StartTime))
+}
+
+// startOfDay возвращает полночь дня t в часовом поясе t
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// isStartOfDay проверяет, что t приходится ровно на полночь в часовом поясе loc
+func isStartOfDay(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	return t.Equal(startOfDay(t))
+}
+
+// civilDate переносит календарную дату t в UTC, чтобы даты из разных
+// часовых поясов можно было сравнивать и вычитать
+func civilDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,211 @@
+// internal/models/availability.go
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultProfileID - ID общего профиля доступности сервера. Пока он
+// не сохранен, используется DefaultAvailability
+const DefaultProfileID = "default"
+
+// weekdayKeys - ключи дней недели в AvailabilityProfile.Weekly
+var weekdayKeys = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// ClockRange - промежуток времени суток "09:00"-"18:00" по местным часам
+type ClockRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DateHours - рабочие часы в отдельные даты (сессия, перенесенный рабочий
+// день). Пустой список часов означает выходной
+type DateHours struct {
+	Dates DateRange    `json:"dates"`
+	Hours []ClockRange `json:"hours"`
+}
+
+// AvailabilityProfile - профиль доступности: когда человек (или, для профиля
+// DefaultProfileID, все по умолчанию) работает. Рабочие часы задаются по дням
+// недели (ключи mon..sun), перерывы (обед) вычитаются из каждого рабочего
+// дня, в DaysOff работы нет, Overrides заменяют часы в отдельные даты.
+// Часы отсчитываются по местному времени пояса TimeZone
+type AvailabilityProfile struct {
+	ID        string                  `json:"id"`
+	Name      string                  `json:"name,omitempty"`
+	TimeZone  string                  `json:"timeZone,omitempty"`
+	Weekly    map[string][]ClockRange `json:"weekly"`
+	Breaks    []ClockRange            `json:"breaks,omitempty"`
+	DaysOff   []DateRange             `json:"daysOff,omitempty"`
+	Overrides []DateHours             `json:"overrides,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Version увеличивается хранилищем при каждом сохранении профиля (ETag / If-Match)
+	Version int64 `json:"version"`
+}
+
+// DefaultAvailability возвращает профиль по умолчанию: понедельник - пятница
+// с 09:00 до 18:00 с перерывом на обед с 13:00 до 14:00
+func DefaultAvailability() *AvailabilityProfile {
+	workday := []ClockRange{{Start: "09:00", End: "18:00"}}
+	return &AvailabilityProfile{
+		ID:     DefaultProfileID,
+		Name:   "Рабочие часы по умолчанию",
+		Weekly: map[string][]ClockRange{"mon": workday, "tue": workday, "wed": workday, "thu": workday, "fri": workday},
+		Breaks: []ClockRange{{Start: "13:00", End: "14:00"}},
+	}
+}
+
+// Validate проверяет профиль: ID, часовой пояс, ключи дней недели и часы
+func (p *AvailabilityProfile) Validate() error {
+	if strings.TrimSpace(p.ID) == "" || strings.ContainsAny(p.ID, "/?#") {
+		return ValidationError{Field: "id", Message: "ID профиля не может быть пустым и содержать символы / ? #"}
+	}
+	if p.TimeZone != "" {
+		if _, err := LoadLocation(p.TimeZone); err != nil {
+			return ValidationError{Field: "timeZone", Message: "Неизвестный часовой пояс: " + p.TimeZone}
+		}
+	}
+
+	for key, hours := range p.Weekly {
+		if _, ok := weekdayKeys[key]; !ok {
+			return ValidationError{Field: "weekly", Message: "Неизвестный день недели: " + key + " (ожидается mon..sun)"}
+		}
+		if err := validateClockRanges("weekly", hours); err != nil {
+			return err
+		}
+	}
+	if err := validateClockRanges("breaks", p.Breaks); err != nil {
+		return err
+	}
+	for _, override := range p.Overrides {
+		if err := validateClockRanges("overrides", override.Hours); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clone возвращает глубокую копию профиля
+func (p *AvailabilityProfile) Clone() *AvailabilityProfile {
+	clone := *p
+	if p.Weekly != nil {
+		clone.Weekly = make(map[string][]ClockRange, len(p.Weekly))
+		for key, hours := range p.Weekly {
+			clone.Weekly[key] = append([]ClockRange(nil), hours...)
+		}
+	}
+	clone.Breaks = append([]ClockRange(nil), p.Breaks...)
+	clone.DaysOff = append([]DateRange(nil), p.DaysOff...)
+	if p.Overrides != nil {
+		clone.Overrides = make([]DateHours, len(p.Overrides))
+		for i, override := range p.Overrides {
+			clone.Overrides[i] = DateHours{Dates: override.Dates, Hours: append([]ClockRange(nil), override.Hours...)}
+		}
+	}
+	return &clone
+}
+
+// Location возвращает часовой пояс профиля или fallback, если он не задан
+func (p *AvailabilityProfile) Location(fallback *time.Location) *time.Location {
+	if p.TimeZone == "" {
+		return fallback
+	}
+	loc, err := LoadLocation(p.TimeZone)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// Windows возвращает рабочее время профиля в окне [from, to), упорядоченное
+// по началу. fallback - часовой пояс для профиля без TimeZone
+func (p *AvailabilityProfile) Windows(from, to time.Time, fallback *time.Location) []Interval {
+	loc := p.Location(fallback)
+
+	var windows []Interval
+	for day := startOfDay(from.In(loc)); day.Before(to); day = day.AddDate(0, 0, 1) {
+		var breaks []Interval
+		for _, pause := range p.Breaks {
+			if interval, ok := clockInterval(day, pause, loc); ok {
+				breaks = append(breaks, interval)
+			}
+		}
+		sort.Slice(breaks, func(i, j int) bool {
+			return breaks[i].Start.Before(breaks[j].Start)
+		})
+
+		var intervals []Interval
+		for _, hours := range p.hoursOn(day) {
+			if interval, ok := clockInterval(day, hours, loc); ok {
+				intervals = append(intervals, freeGaps(interval, breaks)...)
+			}
+		}
+		windows = append(windows, intervals...)
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return Clip(mergeIntervals(windows), from, to)
+}
+
+// hoursOn возвращает рабочие часы в день day: выходной, часы из последнего
+// подходящего исключения или часы дня недели
+func (p *AvailabilityProfile) hoursOn(day time.Time) []ClockRange {
+	for _, off := range p.DaysOff {
+		if off.Contains(day) {
+			return nil
+		}
+	}
+	for i := len(p.Overrides) - 1; i >= 0; i-- {
+		if p.Overrides[i].Dates.Contains(day) {
+			return p.Overrides[i].Hours
+		}
+	}
+	for key, weekday := range weekdayKeys {
+		if weekday == day.Weekday() {
+			return p.Weekly[key]
+		}
+	}
+	return nil
+}
+
+// clockInterval возвращает промежуток часов hours в день day; false для
+// неверного или пустого промежутка
+func clockInterval(day time.Time, hours ClockRange, loc *time.Location) (Interval, bool) {
+	start, errStart := parseClock(hours.Start)
+	end, errEnd := parseClock(hours.End)
+	if errStart != nil || errEnd != nil || end <= start {
+		return Interval{}, false
+	}
+	return Interval{Start: atClock(day, start, loc), End: atClock(day, end, loc)}, true
+}
+
+// validateClockRanges проверяет формат часов и что каждый промежуток
+// заканчивается позже, чем начинается
+func validateClockRanges(field string, ranges []ClockRange) error {
+	for _, hours := range ranges {
+		start, errStart := parseClock(hours.Start)
+		end, errEnd := parseClock(hours.End)
+		if errStart != nil || errEnd != nil {
+			return ValidationError{Field: field, Message: fmt.Sprintf("Неверные часы %s-%s: ожидается ЧЧ:ММ", hours.Start, hours.End)}
+		}
+		if end <= start {
+			return ValidationError{Field: field, Message: fmt.Sprintf("Промежуток %s-%s должен заканчиваться позже, чем начинается", hours.Start, hours.End)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// internal/models/bells.go
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BellSlot - пара из расписания звонков: номер и время начала и окончания
+// по местным часам в формате "15:04"
+type BellSlot struct {
+	Number int    `json:"number"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+}
+
+// BellSchedule - расписание звонков: время пар по их номерам. Занятие,
+// созданное по номеру пары (Event.Slot), получает время из расписания
+// и переносится вслед за ним при изменении расписания
+type BellSchedule struct {
+	Slots []BellSlot `json:"slots"`
+}
+
+// Validate проверяет расписание звонков: номера пар положительные
+// и не повторяются, пара заканчивается позже, чем начинается
+func (b *BellSchedule) Validate() error {
+	seen := make(map[int]bool, len(b.Slots))
+	for _, slot := range b.Slots {
+		if slot.Number < 1 {
+			return ValidationError{Field: "slots", Message: "Номер пары должен быть положительным"}
+		}
+		if seen[slot.Number] {
+			return ValidationError{Field: "slots", Message: fmt.Sprintf("Пара %d указана дважды", slot.Number)}
+		}
+		seen[slot.Number] = true
+
+		start, errStart := parseClock(slot.Start)
+		end, errEnd := parseClock(slot.End)
+		if errStart != nil || errEnd != nil {
+			return ValidationError{Field: "slots", Message: fmt.Sprintf("Неверное время пары %d: ожидается ЧЧ:ММ", slot.Number)}
+		}
+		if end <= start {
+			return ValidationError{Field: "slots", Message: fmt.Sprintf("Пара %d должна заканчиваться позже, чем начинается", slot.Number)}
+		}
+	}
+	return nil
+}
+
+// Sort упорядочивает пары по номерам
+func (b *BellSchedule) Sort() {
+	sort.Slice(b.Slots, func(i, j int) bool {
+		return b.Slots[i].Number < b.Slots[j].Number
+	})
+}
+
+// Slot возвращает пару по номеру
+func (b *BellSchedule) Slot(number int) (BellSlot, bool) {
+	for _, slot := range b.Slots {
+		if slot.Number == number {
+			return slot, true
+		}
+	}
+	return BellSlot{}, false
+}
+
+// Times возвращает начало и окончание пары number в календарный день date
+// по местным часам часового пояса loc
+func (b *BellSchedule) Times(number int, date time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	slot, ok := b.Slot(number)
+	if !ok {
+		return time.Time{}, time.Time{}, ValidationError{Field: "slot", Message: fmt.Sprintf("Пары %d нет в расписании звонков", number)}
+	}
+
+	start, errStart := parseClock(slot.Start)
+	end, errEnd := parseClock(slot.End)
+	if errStart != nil || errEnd != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("неверное время пары %d", number)
+	}
+
+	return atClock(date, start, loc), atClock(date, end, loc), nil
+}
+
+// ApplyBells пересчитывает время занятий по номерам пар: самого события
+// (для серии - ее начала, с сохранением привязки исключений) и измененных
+// экземпляров серии. Номера пар, которых нет в расписании, не изменяются.
+// Возвращает true, если время хотя бы одного занятия изменилось
+func (e *Event) ApplyBells(b *BellSchedule) bool {
+	changed := false
+
+	if e.Slot > 0 && !e.AllDay {
+		oldStart := e.StartTime
+		loc := e.Location()
+		start, end, err := b.Times(e.Slot, e.StartTime.In(loc), loc)
+		if err == nil && (!start.Equal(e.StartTime) || !end.Equal(e.EndTime)) {
+			e.StartTime, e.EndTime = start, end
+			e.UpdatedAt = time.Now()
+			if e.IsRecurring() {
+				e.ShiftExceptions(oldStart)
+			}
+			changed = true
+		}
+	}
+
+	for _, override := range e.Overrides {
+		if override.ApplyBells(b) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// parseClock разбирает время суток "8:30" в минуты от полуночи
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// atClock возвращает момент в календарный день date, когда местные часы
+// пояса loc показывают minutes минут от полуночи
+func atClock(date time.Time, minutes int, loc *time.Location) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, minutes/60, minutes%60, 0, 0, loc)
+}
@@ -0,0 +1,241 @@
+// internal/models/booking.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBookingWindowDays - наибольшее число дней вперед, на которое можно
+// открыть запись по ссылке
+const MaxBookingWindowDays = 92
+
+// Ошибки записи по ссылке: выбранное время успели занять или в этот день
+// уже набрано MaxPerDay записей
+var (
+	ErrSlotTaken = errors.New("выбранное время уже занято")
+	ErrDayFull   = errors.New("на этот день запись уже закрыта")
+)
+
+// BookingLink - ссылка для самостоятельной записи (как в Calendly): посетитель
+// без авторизации видит свободное время и выбирает вариант, по которому
+// создается событие с тегом ссылки. Свободное время - рабочее время профиля
+// доступности Profile за вычетом событий хранилища с отступом Buffer.
+// Duration, Buffer, Step и MinNotice задаются в минутах
+type BookingLink struct {
+	// ID - короткое имя ссылки в адресе /api/book/{id}
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+
+	Duration int `json:"duration"`
+	Buffer   int `json:"buffer,omitempty"`
+	// Step - шаг начала вариантов; 0 - равен длительности
+	Step int `json:"step,omitempty"`
+	// WindowDays - на сколько дней вперед, включая сегодняшний, открыта запись
+	WindowDays int `json:"windowDays"`
+	// MinNotice - за сколько минут до начала закрывается запись
+	MinNotice int `json:"minNotice,omitempty"`
+	// MaxPerDay - наибольшее число записей в день; 0 - без ограничения
+	MaxPerDay int `json:"maxPerDay,omitempty"`
+
+	// Tag - тег создаваемых событий; по нему же считаются записи за день.
+	// Пустой тег заменяется на "booking:" + ID
+	Tag string `json:"tag,omitempty"`
+	// Profile - ID профиля доступности; пусто - общий профиль DefaultProfileID
+	Profile string `json:"profile,omitempty"`
+	// TimeZone - пояс, по которому считаются дни; пусто - пояс профиля
+	TimeZone string `json:"timeZone,omitempty"`
+
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Version увеличивается хранилищем при каждом сохранении ссылки (ETag / If-Match)
+	Version int64 `json:"version"`
+}
+
+// Validate проверяет параметры ссылки
+func (l *BookingLink) Validate() error {
+	if strings.TrimSpace(l.ID) == "" || strings.ContainsAny(l.ID, "/?# ") {
+		return ValidationError{Field: "id", Message: "ID ссылки не может быть пустым и содержать пробелы и символы / ? #"}
+	}
+	if strings.TrimSpace(l.Title) == "" {
+		return ValidationError{Field: "title", Message: "Название не может быть пустым"}
+	}
+	if utf8.RuneCountInString(l.Title) > MaxResourceNameLength {
+		return ValidationError{Field: "title", Message: fmt.Sprintf("Название не может быть длиннее %d символов", MaxResourceNameLength)}
+	}
+	if utf8.RuneCountInString(l.Description) > MaxDescriptionLength {
+		return ValidationError{Field: "description", Message: fmt.Sprintf("Описание не может быть длиннее %d символов", MaxDescriptionLength)}
+	}
+
+	if l.Duration <= 0 || l.Duration > 24*60 {
+		return ValidationError{Field: "duration", Message: "Длительность должна быть от 1 минуты до 24 часов"}
+	}
+	if l.Buffer < 0 || l.Step < 0 || l.MinNotice < 0 || l.MaxPerDay < 0 {
+		return ValidationError{Field: "buffer", Message: "Отступ, шаг, минимальный срок записи и число записей в день не могут быть отрицательными"}
+	}
+	if l.WindowDays < 1 || l.WindowDays > MaxBookingWindowDays {
+		return ValidationError{Field: "windowDays", Message: fmt.Sprintf("Запись может быть открыта на срок от 1 до %d дней", MaxBookingWindowDays)}
+	}
+	if l.TimeZone != "" {
+		if _, err := LoadLocation(l.TimeZone); err != nil {
+			return ValidationError{Field: "timeZone", Message: "Неизвестный часовой пояс: " + l.TimeZone}
+		}
+	}
+	return nil
+}
+
+// Clone возвращает копию ссылки
+func (l *BookingLink) Clone() *BookingLink {
+	clone := *l
+	return &clone
+}
+
+// EventTag возвращает тег событий, созданных по ссылке
+func (l *BookingLink) EventTag() string {
+	if l.Tag != "" {
+		return l.Tag
+	}
+	return "booking:" + l.ID
+}
+
+// ProfileID возвращает ID профиля доступности ссылки
+func (l *BookingLink) ProfileID() string {
+	if l.Profile != "" {
+		return l.Profile
+	}
+	return DefaultProfileID
+}
+
+// Location возвращает пояс ссылки или fallback (обычно пояс профиля)
+func (l *BookingLink) Location(fallback *time.Location) *time.Location {
+	if l.TimeZone == "" {
+		return fallback
+	}
+	loc, err := LoadLocation(l.TimeZone)
+	if err != nil {
+		return fallback
+	}
+	return loc
+}
+
+// Window возвращает окно записи относительно момента now: от now + MinNotice,
+// округленного вверх до шага, до конца последнего из WindowDays дней по часам
+// пояса loc. Округление сохраняет предложенные варианты неизменными, пока
+// посетитель выбирает время
+func (l *BookingLink) Window(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	earliest := now.Add(time.Duration(l.MinNotice) * time.Minute)
+	day := startOfDay(earliest.In(loc))
+	step := l.step()
+	from := day.Add((earliest.Sub(day) + step - 1) / step * step)
+
+	to := startOfDay(now.In(loc)).AddDate(0, 0, l.WindowDays)
+	return from, to
+}
+
+// step возвращает шаг начала вариантов
+func (l *BookingLink) step() time.Duration {
+	if l.Step > 0 {
+		return time.Duration(l.Step) * time.Minute
+	}
+	return time.Duration(l.Duration) * time.Minute
+}
+
+// Slots возвращает варианты записи в рабочем времени working (см.
+// AvailabilityProfile.Windows), не пересекающиеся с занятым временем busy
+// (см. BusyIntervals, отступ уже учтен), упорядоченные по началу. Дни,
+// в которые набрано MaxPerDay записей (booked - число записей по датам
+// DateLayout в поясе loc), пропускаются
+func (l *BookingLink) Slots(working, busy []Interval, booked map[string]int, loc *time.Location) []Slot {
+	if len(working) == 0 {
+		return nil
+	}
+
+	query := SlotQuery{
+		From:     working[0].Start,
+		To:       working[len(working)-1].End,
+		Duration: time.Duration(l.Duration) * time.Minute,
+		Step:     l.step(),
+		Location: loc,
+		Windows:  working,
+	}
+	found, err := FindSlots(busy, query)
+	if err != nil {
+		return nil
+	}
+
+	slots := found[:0]
+	for _, slot := range found {
+		if !l.dayFull(booked, slot.Start, loc) {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Start.Before(slots[j].Start)
+	})
+	return slots
+}
+
+// Booked возвращает число событий ссылки (с тегом EventTag) по датам
+// DateLayout в поясе loc
+func (l *BookingLink) Booked(events []*Event, loc *time.Location) map[string]int {
+	tag := l.EventTag()
+	booked := make(map[string]int)
+	for _, event := range events {
+		for _, eventTag := range event.Tags {
+			if strings.EqualFold(eventTag, tag) {
+				booked[event.StartTime.In(loc).Format(DateLayout)]++
+				break
+			}
+		}
+	}
+	return booked
+}
+
+// BookingRange возвращает окно, события которого нужны CheckBooking для
+// записи на время [start, end): сутки start в поясе loc, расширенные
+// на отступ вокруг записи
+func (l *BookingLink) BookingRange(start, end time.Time, loc *time.Location) (time.Time, time.Time) {
+	buffer := time.Duration(l.Buffer) * time.Minute
+	from := startOfDay(start.In(loc))
+	to := from.AddDate(0, 0, 1)
+	if early := start.Add(-buffer); early.Before(from) {
+		from = early
+	}
+	if late := end.Add(buffer); late.After(to) {
+		to = late
+	}
+	return from, to
+}
+
+// CheckBooking проверяет, что запись на время [start, end) не пересекается
+// с событиями existing (с учетом отступа; события на весь день, как и для
+// /api/freebusy, время не занимают) и не превышает MaxPerDay. existing -
+// события окна BookingRange
+func (l *BookingLink) CheckBooking(start, end time.Time, existing []*Event, loc *time.Location) error {
+	var busy []*Event
+	for _, event := range existing {
+		if !event.AllDay {
+			busy = append(busy, event)
+		}
+	}
+	for _, interval := range BusyIntervals(busy, time.Duration(l.Buffer)*time.Minute) {
+		if overlaps(start, end, interval.Start, interval.End) {
+			return ErrSlotTaken
+		}
+	}
+
+	if l.dayFull(l.Booked(existing, loc), start, loc) {
+		return ErrDayFull
+	}
+	return nil
+}
+
+// dayFull сообщает, что в день start набрано MaxPerDay записей
+func (l *BookingLink) dayFull(booked map[string]int, start time.Time, loc *time.Location) bool {
+	return l.MaxPerDay > 0 && booked[start.In(loc).Format(DateLayout)] >= l.MaxPerDay
+}
@@ -0,0 +1,78 @@
+// internal/models/conflict.go
+package models
+
+import (
+	"strings"
+)
+
+// ResourceRef - ссылка на ресурс расписания
+type ResourceRef struct {
+	Kind ResourceKind `json:"kind"`
+	ID   string       `json:"id"`
+}
+
+// Conflict - двойное бронирование: экземпляр события Event пересекается
+// по времени с событием With, и оба занимают одни и те же ресурсы
+// или исключительные теги
+type Conflict struct {
+	Event     *Event        `json:"event"`
+	With      *Event        `json:"conflictsWith"`
+	Resources []ResourceRef `json:"resources,omitempty"`
+	Tags      []string      `json:"tags,omitempty"`
+}
+
+// Exclusive сообщает, может ли событие конфликтовать с другими: у него есть
+// ресурсы или хотя бы один из исключительных тегов exclusiveTags
+func (e *Event) Exclusive(exclusiveTags []string) bool {
+	return len(e.Groups) > 0 || len(e.Teachers) > 0 || len(e.Rooms) > 0 ||
+		len(sharedTags(e.Tags, exclusiveTags)) > 0
+}
+
+// Clashes проверяет, занимают ли события (или экземпляры серий) e и other
+// одно и то же время и общие ресурсы. Возвращает общие ресурсы и общие
+// исключительные теги (без учета регистра); пустые срезы - конфликта нет.
+// Занятия противоположной четности недели (числитель и знаменатель)
+// не пересекаются
+func (e *Event) Clashes(other *Event, exclusiveTags []string) ([]ResourceRef, []string) {
+	if !e.Overlaps(other.StartTime, other.EndTime) || !e.WeekParity.Compatible(other.WeekParity) {
+		return nil, nil
+	}
+
+	var resources []ResourceRef
+	for _, kind := range ResourceKinds {
+		for _, id := range e.ResourceIDs(kind) {
+			if other.Uses(kind, id) {
+				resources = append(resources, ResourceRef{Kind: kind, ID: id})
+			}
+		}
+	}
+
+	var tags []string
+	for _, tag := range sharedTags(e.Tags, exclusiveTags) {
+		if len(sharedTags(other.Tags, []string{tag})) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return resources, tags
+}
+
+// Compatible сообщает, могут ли занятия с четностью p и q прийтись на одну
+// неделю: только нечетная и четная недели никогда не совпадают
+func (p WeekParity) Compatible(q WeekParity) bool {
+	return !(p == WeekOdd && q == WeekEven || p == WeekEven && q == WeekOdd)
+}
+
+// sharedTags возвращает теги из tags, которые есть в списке list (без учета регистра)
+func sharedTags(tags, list []string) []string {
+	var shared []string
+	for _, tag := range tags {
+		for _, item := range list {
+			if strings.EqualFold(strings.TrimSpace(tag), strings.TrimSpace(item)) {
+				shared = append(shared, tag)
+				break
+			}
+		}
+	}
+	return shared
+}
@@ -2,7 +2,17 @@
 package models
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
 	"time"
+	"unicode/utf8"
+)
+
+// Ограничения длины текстовых полей события (в символах)
+const (
+	MaxDescriptionLength = 10000
+	MaxPlaceLength       = 200
 )
 
 // Event представляет собой событие в расписании
@@ -12,32 +22,107 @@ type Event struct {
 	StartTime time.Time `json:"startTime"`
 	EndTime   time.Time `json:"endTime"`
 	Tags      []string  `json:"tags"`
+
+	// Description - описание в формате Markdown, Place - место проведения
+	// (аудитория, адрес), URL - ссылка на материалы или онлайн-встречу
+	Description string `json:"description,omitempty"`
+	Place       string `json:"location,omitempty"`
+	URL         string `json:"url,omitempty"`
+
+	// AllDay отмечает событие на весь день (праздник, дедлайн, конференция):
+	// StartTime и EndTime - полночь в часовом поясе события, день окончания
+	// не включается. В JSON границы такого события выводятся как даты без времени
+	AllDay bool `json:"allDay,omitempty"`
+
+	// TimeZone - часовой пояс IANA, в котором задано событие (например, Europe/Moscow)
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// WeekParity привязывает занятие к учебному семестру: при заданном семестре
+	// запросы по датам возвращают только экземпляры в дни семестра вне каникул
+	// и по неделям нужной четности (числитель, знаменатель). Пустое значение -
+	// событие без ограничений
+	WeekParity WeekParity `json:"weekParity,omitempty"`
+
+	// Groups, Teachers и Rooms - ID учебных групп, преподавателей и аудиторий
+	// занятия (см. Resource)
+	Groups   []string `json:"groups,omitempty"`
+	Teachers []string `json:"teachers,omitempty"`
+	Rooms    []string `json:"rooms,omitempty"`
+
+	// Slot - номер пары из расписания звонков, если время занятия задано
+	// парой: при изменении расписания звонков время пересчитывается (см. ApplyBells).
+	// 0 - время задано явно
+	Slot int `json:"slot,omitempty"`
+
+	// ConflictOverride отмечает событие, сохраненное с ?force=true несмотря
+	// на двойное бронирование ресурсов (см. Clashes)
+	ConflictOverride bool `json:"conflictOverride,omitempty"`
+
+	// Reminders - напоминания в минутах до начала (10 - за 10 минут,
+	// 1440 - за сутки); у экземпляров серии - напоминания серии
+	Reminders []int `json:"reminders,omitempty"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Version увеличивается хранилищем при каждом сохранении события
+	// и используется для оптимистической блокировки (ETag / If-Match).
+	// У экземпляров серии - версия самой серии
+	Version int64 `json:"version"`
+
+	// Recurrence задает правило повторения; nil для одиночного события
+	Recurrence *RecurrenceRule `json:"recurrence,omitempty"`
+
+	// ExDates - отмененные экземпляры серии (EXDATE), Overrides - измененные
+	// экземпляры (RECURRENCE-ID), у каждого заполнен OriginalStartTime
+	ExDates   []time.Time `json:"exdates,omitempty"`
+	Overrides []*Event    `json:"overrides,omitempty"`
+
+	// RecurringEventID и OriginalStartTime заполняются у экземпляров серии
+	RecurringEventID  string     `json:"recurringEventId,omitempty"`
+	OriginalStartTime *time.Time `json:"originalStartTime,omitempty"`
+}
+
+// EventDetails - необязательные описательные поля события
+type EventDetails struct {
+	Description string
+	Place       string
+	URL         string
 }
 
 // NewEvent создает новое событие с автоматически сгенерированным ID и временем создания
-func NewEvent(title string, startTime, endTime time.Time, tags []string) *Event {
+func NewEvent(title string, startTime, endTime time.Time, tags []string, details EventDetails) *Event {
 	return &Event{
-		ID:        generateID(),
-		Title:     title,
-		StartTime: startTime,
-		EndTime:   endTime,
-		Tags:      tags,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:          generateID(),
+		Title:       title,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		Tags:        tags,
+		Description: details.Description,
+		Place:       details.Place,
+		URL:         details.URL,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
 	}
 }
 
 // Update обновляет поля события
-func (e *Event) Update(title string, startTime, endTime time.Time, tags []string) {
+func (e *Event) Update(title string, startTime, endTime time.Time, tags []string, details EventDetails) {
 	e.Title = title
 	e.StartTime = startTime
 	e.EndTime = endTime
 	e.Tags = tags
+	e.Description = details.Description
+	e.Place = details.Place
+	e.URL = details.URL
 	e.UpdatedAt = time.Now()
 }
 
+// Details возвращает описательные поля события
+func (e *Event) Details() EventDetails {
+	return EventDetails{Description: e.Description, Place: e.Place, URL: e.URL}
+}
+
 // Validate проверяет корректность данных события
 func (e *Event) Validate() error {
 	if e.Title == "" {
@@ -48,9 +133,198 @@ func (e *Event) Validate() error {
 		return ValidationError{Field: "endTime", Message: "Время окончания не может быть раньше времени начала"}
 	}
 
+	if utf8.RuneCountInString(e.Description) > MaxDescriptionLength {
+		return ValidationError{Field: "description", Message: fmt.Sprintf("Описание не может быть длиннее %d символов", MaxDescriptionLength)}
+	}
+
+	if utf8.RuneCountInString(e.Place) > MaxPlaceLength {
+		return ValidationError{Field: "location", Message: fmt.Sprintf("Место проведения не может быть длиннее %d символов", MaxPlaceLength)}
+	}
+
+	if e.URL != "" && !isWebURL(e.URL) {
+		return ValidationError{Field: "url", Message: "Ссылка должна быть абсолютным адресом http или https"}
+	}
+
+	if e.TimeZone != "" {
+		if _, err := LoadLocation(e.TimeZone); err != nil {
+			return ValidationError{Field: "timeZone", Message: "Неизвестный часовой пояс: " + e.TimeZone}
+		}
+	}
+
+	if e.AllDay {
+		loc := e.Location()
+		if !isStartOfDay(e.StartTime, loc) {
+			return ValidationError{Field: "startTime", Message: "Событие на весь день должно начинаться в полночь"}
+		}
+		if !isStartOfDay(e.EndTime, loc) || !e.EndTime.After(e.StartTime) {
+			return ValidationError{Field: "endTime", Message: "Событие на весь день должно заканчиваться в полночь и длиться хотя бы один день"}
+		}
+	}
+
+	if err := e.WeekParity.validate(); err != nil {
+		return err
+	}
+
+	if err := e.validateResourceRefs(); err != nil {
+		return err
+	}
+
+	if err := e.validateReminders(); err != nil {
+		return err
+	}
+
+	if e.Slot < 0 {
+		return ValidationError{Field: "slot", Message: "Номер пары не может быть отрицательным"}
+	}
+	if e.Slot > 0 && e.AllDay {
+		return ValidationError{Field: "slot", Message: "Событие на весь день не может быть привязано к паре"}
+	}
+
+	if e.Recurrence != nil {
+		if err := e.Recurrence.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, override := range e.Overrides {
+		if err := override.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Clone возвращает глубокую копию события: изменения копии, включая теги,
+// правило повторения и исключения серии, не затрагивают оригинал
+func (e *Event) Clone() *Event {
+	clone := *e
+
+	if e.Tags != nil {
+		clone.Tags = make([]string, len(e.Tags))
+		copy(clone.Tags, e.Tags)
+	}
+
+	clone.Groups = cloneStrings(e.Groups)
+	clone.Teachers = cloneStrings(e.Teachers)
+	clone.Rooms = cloneStrings(e.Rooms)
+	clone.Reminders = cloneInts(e.Reminders)
+	clone.Recurrence = e.Recurrence.Clone()
+	clone.ExDates = append([]time.Time(nil), e.ExDates...)
+
+	if e.Overrides != nil {
+		clone.Overrides = make([]*Event, len(e.Overrides))
+		for i, override := range e.Overrides {
+			clone.Overrides[i] = override.Clone()
+		}
+	}
+
+	if e.OriginalStartTime != nil {
+		originalStart := *e.OriginalStartTime
+		clone.OriginalStartTime = &originalStart
+	}
+
+	return &clone
+}
+
+// IsRecurring сообщает, является ли событие повторяющейся серией
+func (e *Event) IsRecurring() bool {
+	return e.Recurrence != nil
+}
+
+// Occurrences разворачивает событие в экземпляры, пересекающиеся с окном [from, to).
+// Для одиночного события возвращает само событие, если оно попадает в окно
+func (e *Event) Occurrences(from, to time.Time) []*Event {
+	if !e.IsRecurring() {
+		if overlaps(e.StartTime, e.EndTime, from, to) {
+			return []*Event{e}
+		}
+		return nil
+	}
+
+	starts := e.Recurrence.Between(e.seriesStart(), e.EndTime.Sub(e.StartTime), from, to)
+	occurrences := make([]*Event, 0, len(starts))
+	for _, start := range starts {
+		if e.isExcluded(start) || e.findOverride(start) != nil {
+			continue
+		}
+		occurrences = append(occurrences, e.occurrence(start))
+	}
+
+	// Перенесенный экземпляр может попасть в окно, даже если исходный - нет
+	for _, override := range e.Overrides {
+		if overlaps(override.StartTime, override.EndTime, from, to) && e.isOccurrence(*override.OriginalStartTime) {
+			occurrences = append(occurrences, e.overrideCopy(override))
+		}
+	}
+
+	if len(e.Overrides) > 0 {
+		sort.SliceStable(occurrences, func(i, j int) bool {
+			return occurrences[i].StartTime.Before(occurrences[j].StartTime)
+		})
+	}
+
+	return occurrences
+}
+
+// OccurrenceAt возвращает экземпляр серии, начинающийся ровно в start
+func (e *Event) OccurrenceAt(start time.Time) (*Event, bool) {
+	if !e.IsRecurring() {
+		return nil, false
+	}
+
+	start = start.In(e.Location())
+	if !e.isOccurrence(start) || e.isExcluded(start) {
+		return nil, false
+	}
+
+	if override := e.findOverride(start); override != nil {
+		return e.overrideCopy(override), true
+	}
+
+	return e.occurrence(start), true
+}
+
+// isOccurrence проверяет, порождает ли правило серии экземпляр, начинающийся ровно в start
+func (e *Event) isOccurrence(start time.Time) bool {
+	for _, s := range e.Recurrence.Between(e.seriesStart(), 0, start, start.Add(time.Nanosecond)) {
+		if s.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrence создает экземпляр серии с началом в start
+func (e *Event) occurrence(start time.Time) *Event {
+	originalStart := start
+
+	instance := e.cloneBase()
+	instance.ID = OccurrenceID(e.ID, start)
+	instance.StartTime = start
+	instance.EndTime = e.endFor(start)
+	if instance.Tags == nil {
+		instance.Tags = []string{}
+	}
+	instance.RecurringEventID = e.ID
+	instance.OriginalStartTime = &originalStart
+	return instance
+}
+
+// cloneBase возвращает копию события без правила повторения и исключений
+// серии: основу для экземпляра или новой серии
+func (e *Event) cloneBase() *Event {
+	base := *e
+	base.Recurrence, base.ExDates, base.Overrides = nil, nil, nil
+	return base.Clone()
+}
+
+// isWebURL проверяет, что ссылка - абсолютный адрес http или https
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 // ValidationError представляет ошибку валидации
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -60,17 +334,3 @@ type ValidationError struct {
 func (e ValidationError) Error() string {
 	return e.Message
 }
-
-// Вспомогательная функция для генерации ID (упрощенная версия)
-func generateID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
-}
-
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
-}
@@ -0,0 +1,175 @@
+// internal/models/exceptions.go
+package models
+
+import (
+	"time"
+)
+
+// CancelOccurrence отменяет экземпляр серии, начинающийся в start (EXDATE).
+// Изменения этого экземпляра, если они были, удаляются
+func (e *Event) CancelOccurrence(start time.Time) {
+	e.removeOverride(start)
+	if !e.isExcluded(start) {
+		e.ExDates = append(e.ExDates, start)
+	}
+	e.UpdatedAt = time.Now()
+}
+
+// SetOverride сохраняет измененный экземпляр серии (RECURRENCE-ID).
+// Заменяемый экземпляр определяется по OriginalStartTime
+func (e *Event) SetOverride(override *Event) {
+	originalStart := *override.OriginalStartTime
+	override.ID = OccurrenceID(e.ID, originalStart)
+	override.RecurringEventID = e.ID
+	override.Recurrence = nil
+	override.ExDates = nil
+	override.Overrides = nil
+	override.Version = 0
+	override.UpdatedAt = time.Now()
+
+	e.removeOverride(originalStart)
+	e.Overrides = append(e.Overrides, override)
+	e.UpdatedAt = time.Now()
+}
+
+// SplitAt разделяет серию на экземпляре start ("этот и последующие"): текущая
+// серия заканчивается перед start, а возвращаемая новая серия начинается с него.
+// Исключения после точки разделения переходят к новой серии. Второе значение
+// сообщает, остались ли у текущей серии экземпляры; если нет, серия не изменяется
+func (e *Event) SplitAt(start time.Time) (*Event, bool) {
+	before := e.Recurrence.Between(e.seriesStart(), 0, e.StartTime, start)
+
+	rule := e.Recurrence.Clone()
+	if rule.Count > 0 {
+		rule.Count -= len(before)
+	}
+
+	now := time.Now()
+	tail := e.cloneBase()
+	tail.ID = generateID()
+	tail.StartTime = start
+	tail.EndTime = e.endFor(start)
+	tail.Recurrence = rule
+	tail.CreatedAt = now
+	tail.UpdatedAt = now
+	tail.Version = 0
+
+	// Исключения копируются: rekeyOverrides меняет ID измененных экземпляров,
+	// а текущая серия должна остаться прежней
+	if len(before) == 0 {
+		tail.ExDates = append([]time.Time(nil), e.ExDates...)
+		for _, override := range e.Overrides {
+			tail.Overrides = append(tail.Overrides, override.Clone())
+		}
+		tail.rekeyOverrides()
+		return tail, false
+	}
+
+	var exDates []time.Time
+	for _, exDate := range e.ExDates {
+		if exDate.Before(start) {
+			exDates = append(exDates, exDate)
+		} else {
+			tail.ExDates = append(tail.ExDates, exDate)
+		}
+	}
+	e.ExDates = exDates
+
+	var overrides []*Event
+	for _, override := range e.Overrides {
+		if override.OriginalStartTime.Before(start) {
+			overrides = append(overrides, override)
+		} else {
+			tail.Overrides = append(tail.Overrides, override.Clone())
+		}
+	}
+	e.Overrides = overrides
+	tail.rekeyOverrides()
+
+	if e.Recurrence.Count > 0 {
+		e.Recurrence.Count = len(before)
+	} else {
+		e.Recurrence.Until = start.Add(-time.Second)
+	}
+	e.UpdatedAt = time.Now()
+
+	return tail, true
+}
+
+// ShiftExceptions сдвигает отмененные и измененные экземпляры вслед за
+// переносом начала серии с oldStart на StartTime, сохраняя их привязку.
+// Исключения серии на весь день сдвигаются на целое число дней
+func (e *Event) ShiftExceptions(oldStart time.Time) {
+	delta := e.StartTime.Sub(oldStart)
+	shift := func(t time.Time) time.Time {
+		return t.Add(delta)
+	}
+
+	if e.AllDay {
+		loc := e.Location()
+		days := DaysBetween(oldStart.In(loc), e.StartTime.In(loc))
+		shift = func(t time.Time) time.Time {
+			return startOfDay(t.In(loc)).AddDate(0, 0, days)
+		}
+	} else if delta == 0 {
+		return
+	}
+
+	for i := range e.ExDates {
+		e.ExDates[i] = shift(e.ExDates[i])
+	}
+	for _, override := range e.Overrides {
+		originalStart := shift(*override.OriginalStartTime)
+		override.OriginalStartTime = &originalStart
+	}
+	e.rekeyOverrides()
+}
+
+// rekeyOverrides пересчитывает ID измененных экземпляров после смены ID серии
+// или их исходного времени
+func (e *Event) rekeyOverrides() {
+	for _, override := range e.Overrides {
+		override.ID = OccurrenceID(e.ID, *override.OriginalStartTime)
+		override.RecurringEventID = e.ID
+	}
+}
+
+func (e *Event) isExcluded(start time.Time) bool {
+	for _, exDate := range e.ExDates {
+		if exDate.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Event) findOverride(start time.Time) *Event {
+	for _, override := range e.Overrides {
+		if override.OriginalStartTime.Equal(start) {
+			return override
+		}
+	}
+	return nil
+}
+
+func (e *Event) removeOverride(start time.Time) {
+	overrides := e.Overrides[:0]
+	for _, override := range e.Overrides {
+		if !override.OriginalStartTime.Equal(start) {
+			overrides = append(overrides, override)
+		}
+	}
+	e.Overrides = overrides
+	if len(e.Overrides) == 0 {
+		e.Overrides = nil
+	}
+}
+
+// overrideCopy возвращает копию измененного экземпляра для выдачи наружу
+func (e *Event) overrideCopy(override *Event) *Event {
+	occurrence := override.Clone()
+	occurrence.ID = OccurrenceID(e.ID, *override.OriginalStartTime)
+	occurrence.RecurringEventID = e.ID
+	occurrence.Version = e.Version
+	return occurrence
+}
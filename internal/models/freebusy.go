@@ -0,0 +1,240 @@
+// internal/models/freebusy.go
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval - промежуток времени [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// BusyIntervals возвращает занятое событиями время: интервалы событий,
+// расширенные на buffer в обе стороны, объединяются и упорядочиваются.
+// Экземпляры серий должны быть уже развернуты
+func BusyIntervals(events []*Event, buffer time.Duration) []Interval {
+	intervals := make([]Interval, 0, len(events))
+	for _, event := range events {
+		intervals = append(intervals, Interval{
+			Start: event.StartTime.Add(-buffer),
+			End:   event.EndTime.Add(buffer),
+		})
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+	return mergeIntervals(intervals)
+}
+
+// mergeIntervals объединяет пересекающиеся и смежные интервалы,
+// упорядоченные по началу
+func mergeIntervals(intervals []Interval) []Interval {
+	var merged []Interval
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && !interval.Start.After(merged[n-1].End) {
+			if interval.End.After(merged[n-1].End) {
+				merged[n-1].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Clip обрезает упорядоченные интервалы по окну [from, to)
+func Clip(intervals []Interval, from, to time.Time) []Interval {
+	var clipped []Interval
+	for _, interval := range intervals {
+		if !overlaps(interval.Start, interval.End, from, to) {
+			continue
+		}
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.End.After(to) {
+			interval.End = to
+		}
+		clipped = append(clipped, interval)
+	}
+	return clipped
+}
+
+// SlotQuery - параметры поиска свободного времени: окно [From, To),
+// рабочие часы DayStart-DayEnd ("15:04") по часам пояса Location в дни
+// недели Weekdays (пусто - все дни), длительность и шаг начала вариантов.
+// Если заданы Windows (например, рабочее время из профиля доступности),
+// поиск идет в них, а DayStart, DayEnd и Weekdays не используются
+type SlotQuery struct {
+	From     time.Time
+	To       time.Time
+	Duration time.Duration
+	DayStart string
+	DayEnd   string
+	Weekdays []time.Weekday
+	Step     time.Duration
+	Location *time.Location
+	Windows  []Interval
+}
+
+// Slot - найденное свободное время. Snug отмечает вариант, который примыкает
+// к занятому времени или границе рабочего дня и не дробит свободный
+// промежуток; FreeMinutes - длина свободного промежутка, в котором он лежит
+type Slot struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Snug        bool      `json:"snug"`
+	FreeMinutes int       `json:"freeMinutes"`
+}
+
+// Validate проверяет параметры поиска
+func (q *SlotQuery) Validate() error {
+	if q.Duration <= 0 {
+		return ValidationError{Field: "duration", Message: "Длительность должна быть положительной"}
+	}
+	if q.Step <= 0 {
+		return ValidationError{Field: "step", Message: "Шаг должен быть положительным"}
+	}
+	if !q.From.Before(q.To) {
+		return ValidationError{Field: "from", Message: "Начало окна поиска должно быть раньше конца"}
+	}
+	if q.Windows != nil {
+		return nil
+	}
+
+	start, errStart := parseClock(q.DayStart)
+	end, errEnd := parseClock(q.DayEnd)
+	if errStart != nil || errEnd != nil {
+		return ValidationError{Field: "workingHours", Message: "Неверные рабочие часы: ожидается ЧЧ:ММ"}
+	}
+	if end <= start {
+		return ValidationError{Field: "workingHours", Message: "Рабочий день должен заканчиваться позже, чем начинается"}
+	}
+	return nil
+}
+
+// FindSlots ищет варианты времени длительностью q.Duration в рабочие часы,
+// не пересекающиеся с занятыми интервалами busy (упорядоченными, см.
+// BusyIntervals). Варианты начинаются с шагом q.Step от полуночи, а также
+// вплотную к началу и концу каждого свободного промежутка. Сначала идут
+// варианты Snug, затем остальные, внутри - по времени начала
+func FindSlots(busy []Interval, q SlotQuery) ([]Slot, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	windows := q.Windows
+	if windows == nil {
+		windows = q.workingWindows()
+	}
+
+	var slots []Slot
+	for _, gap := range Subtract(windows, busy) {
+		slots = append(slots, q.gapSlots(gap)...)
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].Snug != slots[j].Snug {
+			return slots[i].Snug
+		}
+		return slots[i].Start.Before(slots[j].Start)
+	})
+	return slots, nil
+}
+
+// workingWindows возвращает рабочие часы DayStart-DayEnd в дни Weekdays
+// внутри окна поиска
+func (q *SlotQuery) workingWindows() []Interval {
+	dayStart, _ := parseClock(q.DayStart)
+	dayEnd, _ := parseClock(q.DayEnd)
+
+	var windows []Interval
+	for day := startOfDay(q.From.In(q.Location)); day.Before(q.To); day = day.AddDate(0, 0, 1) {
+		if q.allows(day.Weekday()) {
+			windows = append(windows, Interval{Start: atClock(day, dayStart, q.Location), End: atClock(day, dayEnd, q.Location)})
+		}
+	}
+	return Clip(windows, q.From, q.To)
+}
+
+// allows сообщает, входит ли день недели в поиск
+func (q *SlotQuery) allows(weekday time.Weekday) bool {
+	if len(q.Weekdays) == 0 {
+		return true
+	}
+	for _, allowed := range q.Weekdays {
+		if allowed == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// gapSlots возвращает варианты внутри свободного промежутка gap
+func (q *SlotQuery) gapSlots(gap Interval) []Slot {
+	last := gap.End.Add(-q.Duration)
+	if last.Before(gap.Start) {
+		return nil
+	}
+	free := minutes(gap.End.Sub(gap.Start))
+
+	starts := []time.Time{gap.Start}
+	day := startOfDay(gap.Start.In(q.Location))
+	offset := gap.Start.Sub(day)
+	aligned := day.Add((offset + q.Step - 1) / q.Step * q.Step)
+	for start := aligned; start.Before(last); start = start.Add(q.Step) {
+		if start.After(gap.Start) {
+			starts = append(starts, start)
+		}
+	}
+	if last.After(gap.Start) {
+		starts = append(starts, last)
+	}
+
+	slots := make([]Slot, 0, len(starts))
+	for _, start := range starts {
+		slots = append(slots, Slot{
+			Start:       start,
+			End:         start.Add(q.Duration),
+			Snug:        start.Equal(gap.Start) || start.Equal(last),
+			FreeMinutes: free,
+		})
+	}
+	return slots
+}
+
+// Subtract вычитает из упорядоченных интервалов windows занятые интервалы
+// busy (упорядоченные и объединенные, см. BusyIntervals)
+func Subtract(windows, busy []Interval) []Interval {
+	var free []Interval
+	for _, window := range windows {
+		free = append(free, freeGaps(window, busy)...)
+	}
+	return free
+}
+
+// freeGaps возвращает свободные промежутки окна window между занятыми
+// интервалами busy
+func freeGaps(window Interval, busy []Interval) []Interval {
+	var gaps []Interval
+	cursor := window.Start
+	for _, interval := range busy {
+		if !interval.End.After(cursor) {
+			continue
+		}
+		if !interval.Start.Before(window.End) {
+			break
+		}
+		if interval.Start.After(cursor) {
+			gaps = append(gaps, Interval{Start: cursor, End: interval.Start})
+		}
+		cursor = interval.End
+	}
+	if cursor.Before(window.End) {
+		gaps = append(gaps, Interval{Start: cursor, End: window.End})
+	}
+	return gaps
+}
@@ -0,0 +1,61 @@
+// internal/models/id.go
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// idClock обеспечивает возрастание ID, созданных в одну миллисекунду: 12 бит
+// после метки времени заняты счетчиком (RFC 9562, раздел 6.2, метод 1)
+var idClock struct {
+	sync.Mutex
+	millis int64
+	seq    uint16
+}
+
+// generateID возвращает новый ID события в формате UUIDv7 (RFC 9562): 48 бит
+// времени создания в миллисекундах, счетчик и 62 случайных бита из crypto/rand.
+// Такие ID уникальны без координации между процессами и сортируются по времени
+// создания. ID прежнего формата ("20060102150405-abcdef") остаются допустимыми
+func generateID() string {
+	var b [16]byte
+	// crypto/rand.Read не возвращает ошибок: при сбое источника программа завершается
+	rand.Read(b[8:])
+
+	millis, seq := nextIDTimestamp()
+	binary.BigEndian.PutUint64(b[:8], uint64(millis)<<16)
+	b[6] = 0x70 | byte(seq>>8)&0x0f // версия 7
+	b[7] = byte(seq)
+	b[8] = 0x80 | b[8]&0x3f // вариант RFC 9562
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// nextIDTimestamp возвращает метку времени и счетчик для очередного ID.
+// При переполнении счетчика или переводе часов назад метка берется из будущего,
+// чтобы ID продолжали возрастать
+func nextIDTimestamp() (int64, uint16) {
+	idClock.Lock()
+	defer idClock.Unlock()
+
+	if millis := time.Now().UnixMilli(); millis > idClock.millis {
+		idClock.millis = millis
+		idClock.seq = 0
+	} else if idClock.seq++; idClock.seq > 0x0fff {
+		idClock.millis++
+		idClock.seq = 0
+	}
+
+	return idClock.millis, idClock.seq
+}
+
+// AssignNewID присваивает событию новый ID и пересчитывает ID измененных
+// экземпляров серии
+func (e *Event) AssignNewID() {
+	e.ID = generateID()
+	e.rekeyOverrides()
+}
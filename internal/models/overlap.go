@@ -0,0 +1,164 @@
+// internal/models/overlap.go
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// OverlapPair - два события, пересекающиеся по времени, и общий интервал
+type OverlapPair struct {
+	Events         [2]string `json:"events"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	OverlapMinutes int       `json:"overlapMinutes"`
+}
+
+// OverlapCluster - группа событий, связанных цепочкой пересечений.
+// OverlapMinutes - время, когда одновременно идут хотя бы два события
+// группы (пересечения пар объединяются, а не складываются)
+type OverlapCluster struct {
+	Start          time.Time     `json:"start"`
+	End            time.Time     `json:"end"`
+	Events         []*Event      `json:"events"`
+	Pairs          []OverlapPair `json:"pairs"`
+	OverlapMinutes int           `json:"overlapMinutes"`
+}
+
+// FindOverlaps находит пересекающиеся по времени события (экземпляры серий
+// должны быть уже развернуты) и объединяет их в группы. События обходятся
+// один раз в порядке начала (sweep line): для каждого проверяются только
+// события, которые еще идут в момент его начала. Занятия противоположной
+// четности недели не пересекаются. Группы упорядочены по началу
+func FindOverlaps(events []*Event) []OverlapCluster {
+	sorted := make([]*Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartTime.Before(sorted[j].StartTime)
+	})
+
+	// parent - лес объединения событий в группы по найденным парам
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	type pair struct {
+		a, b       int
+		start, end time.Time
+	}
+	var pairs []pair
+	var active []int
+
+	for i, event := range sorted {
+		// События, закончившиеся к началу текущего, больше ни с чем не пересекутся
+		running := active[:0]
+		for _, j := range active {
+			if sorted[j].EndTime.After(event.StartTime) {
+				running = append(running, j)
+			}
+		}
+		active = running
+
+		for _, j := range active {
+			other := sorted[j]
+			if !event.Overlaps(other.StartTime, other.EndTime) || !event.WeekParity.Compatible(other.WeekParity) {
+				continue
+			}
+
+			end := event.EndTime
+			if other.EndTime.Before(end) {
+				end = other.EndTime
+			}
+			pairs = append(pairs, pair{a: j, b: i, start: event.StartTime, end: end})
+			// Корнем остается событие с меньшим индексом, то есть самое раннее
+			if ri, rj := find(i), find(j); ri < rj {
+				parent[rj] = ri
+			} else {
+				parent[ri] = rj
+			}
+		}
+
+		active = append(active, i)
+	}
+
+	// Корень группы - самое раннее событие, поэтому группы получаются
+	// в порядке начала, а пары внутри группы - в порядке обхода
+	clusters := make(map[int]*OverlapCluster)
+	var roots []int
+	intervals := make(map[int][][2]time.Time)
+	for _, p := range pairs {
+		root := find(p.a)
+		cluster, ok := clusters[root]
+		if !ok {
+			cluster = &OverlapCluster{}
+			clusters[root] = cluster
+			roots = append(roots, root)
+		}
+		cluster.Pairs = append(cluster.Pairs, OverlapPair{
+			Events:         [2]string{sorted[p.a].ID, sorted[p.b].ID},
+			Start:          p.start,
+			End:            p.end,
+			OverlapMinutes: minutes(p.end.Sub(p.start)),
+		})
+		intervals[root] = append(intervals[root], [2]time.Time{p.start, p.end})
+	}
+	for i, event := range sorted {
+		if cluster, ok := clusters[find(i)]; ok {
+			cluster.Events = append(cluster.Events, event)
+			if cluster.Start.IsZero() || event.StartTime.Before(cluster.Start) {
+				cluster.Start = event.StartTime
+			}
+			if event.EndTime.After(cluster.End) {
+				cluster.End = event.EndTime
+			}
+		}
+	}
+
+	sort.Ints(roots)
+	result := make([]OverlapCluster, 0, len(roots))
+	for _, root := range roots {
+		cluster := clusters[root]
+		cluster.OverlapMinutes = minutes(unionDuration(intervals[root]))
+		result = append(result, *cluster)
+	}
+	return result
+}
+
+// unionDuration возвращает длительность объединения интервалов
+func unionDuration(intervals [][2]time.Time) time.Duration {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i][0].Before(intervals[j][0])
+	})
+
+	var total time.Duration
+	var start, end time.Time
+	for i, interval := range intervals {
+		if i > 0 && !interval[0].After(end) {
+			if interval[1].After(end) {
+				end = interval[1]
+			}
+			continue
+		}
+		if i > 0 {
+			total += end.Sub(start)
+		}
+		start, end = interval[0], interval[1]
+	}
+	if len(intervals) > 0 {
+		total += end.Sub(start)
+	}
+	return total
+}
+
+// minutes возвращает длительность в целых минутах
+func minutes(d time.Duration) int {
+	return int(d / time.Minute)
+}
@@ -0,0 +1,140 @@
+// internal/models/overlap_test.go
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindOverlaps(t *testing.T) {
+	day := time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC)
+	at := func(clock string) time.Time {
+		parsed, err := time.Parse("15:04", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return day.Add(time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute)
+	}
+	event := func(id, start, end string, parity WeekParity) *Event {
+		e := NewEvent(id, at(start), at(end), nil, EventDetails{})
+		e.ID = id
+		e.WeekParity = parity
+		return e
+	}
+
+	type cluster struct {
+		events  []string
+		pairs   [][2]string
+		minutes int
+	}
+
+	tests := []struct {
+		name   string
+		events []*Event
+		want   []cluster
+	}{
+		{
+			name: "touching",
+			events: []*Event{
+				event("a", "09:00", "10:00", WeekAny),
+				event("b", "10:00", "11:00", WeekAny),
+			},
+		},
+		{
+			name: "chain",
+			events: []*Event{
+				event("c", "10:15", "11:00", WeekAny),
+				event("a", "09:00", "10:00", WeekAny),
+				event("b", "09:30", "10:30", WeekAny),
+			},
+			want: []cluster{{
+				events:  []string{"a", "b", "c"},
+				pairs:   [][2]string{{"a", "b"}, {"b", "c"}},
+				minutes: 45,
+			}},
+		},
+		{
+			// c касается a и b, но не пересекается с ними
+			name: "touching after overlap",
+			events: []*Event{
+				event("a", "09:00", "10:00", WeekAny),
+				event("b", "09:30", "10:00", WeekAny),
+				event("c", "10:00", "11:00", WeekAny),
+			},
+			want: []cluster{{
+				events:  []string{"a", "b"},
+				pairs:   [][2]string{{"a", "b"}},
+				minutes: 30,
+			}},
+		},
+		{
+			// Пересечения пар объединяются: 09:30-10:30, 10:00-11:00 и 10:00-10:30
+			name: "nested",
+			events: []*Event{
+				event("a", "09:00", "12:00", WeekAny),
+				event("b", "09:30", "10:30", WeekAny),
+				event("c", "10:00", "11:00", WeekAny),
+			},
+			want: []cluster{{
+				events:  []string{"a", "b", "c"},
+				pairs:   [][2]string{{"a", "b"}, {"a", "c"}, {"b", "c"}},
+				minutes: 90,
+			}},
+		},
+		{
+			name: "separate clusters",
+			events: []*Event{
+				event("d", "14:30", "15:30", WeekAny),
+				event("a", "09:00", "10:00", WeekAny),
+				event("c", "14:00", "15:00", WeekAny),
+				event("b", "09:45", "10:00", WeekAny),
+			},
+			want: []cluster{
+				{events: []string{"a", "b"}, pairs: [][2]string{{"a", "b"}}, minutes: 15},
+				{events: []string{"c", "d"}, pairs: [][2]string{{"c", "d"}}, minutes: 30},
+			},
+		},
+		{
+			// Числитель и знаменатель не пересекаются, а занятие каждую неделю - с обоими
+			name: "week parity",
+			events: []*Event{
+				event("odd", "09:00", "10:30", WeekOdd),
+				event("even", "09:00", "10:30", WeekEven),
+				event("every", "10:00", "11:00", WeekEvery),
+			},
+			want: []cluster{{
+				events:  []string{"odd", "even", "every"},
+				pairs:   [][2]string{{"odd", "every"}, {"even", "every"}},
+				minutes: 30,
+			}},
+		},
+		{
+			name: "opposite parity only",
+			events: []*Event{
+				event("odd", "09:00", "10:30", WeekOdd),
+				event("even", "09:00", "10:30", WeekEven),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []cluster
+			for _, c := range FindOverlaps(tt.events) {
+				var ids []string
+				for _, e := range c.Events {
+					ids = append(ids, e.ID)
+				}
+				var pairs [][2]string
+				for _, p := range c.Pairs {
+					pairs = append(pairs, p.Events)
+				}
+				got = append(got, cluster{events: ids, pairs: pairs, minutes: c.OverlapMinutes})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindOverlaps = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
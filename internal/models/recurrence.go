@@ -0,0 +1,560 @@
+// internal/models/recurrence.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency задает частоту повторения (FREQ в RFC 5545)
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+// maxRecurrencePeriods ограничивает число перебираемых периодов,
+// чтобы правило без совпадений не зациклило разворачивание
+const maxRecurrencePeriods = 100000
+
+// untilLayout - формат UNTIL в UTC по RFC 5545
+const untilLayout = "20060102T150405Z"
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum - день недели с необязательным порядковым номером (BYDAY=MO, 2TU, -1FR)
+type WeekdayNum struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// String возвращает день недели в нотации RFC 5545
+func (w WeekdayNum) String() string {
+	code := strings.ToUpper(w.Weekday.String()[:2])
+	if w.Ordinal != 0 {
+		return strconv.Itoa(w.Ordinal) + code
+	}
+	return code
+}
+
+// RecurrenceRule описывает правило повторения события (подмножество RRULE из RFC 5545)
+type RecurrenceRule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      time.Time
+}
+
+// ParseRecurrenceRule разбирает правило в формате RRULE, например
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20260601T000000Z"
+func ParseRecurrenceRule(value string) (*RecurrenceRule, error) {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("правило повторения пустое")
+	}
+
+	rule := &RecurrenceRule{}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("неверная часть правила: %s", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(val))
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("неверный INTERVAL: %s", val)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("неверный COUNT: %s", val)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "BYDAY":
+			for _, item := range strings.Split(val, ",") {
+				day, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(item)
+				if err != nil {
+					return nil, fmt.Errorf("неверный BYMONTHDAY: %s", item)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			// Неделя всегда начинается с понедельника
+			if strings.ToUpper(val) != "MO" {
+				return nil, fmt.Errorf("поддерживается только WKST=MO")
+			}
+		default:
+			return nil, fmt.Errorf("неподдерживаемый параметр правила: %s", key)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	layouts := []string{untilLayout, "20060102T150405", "20060102"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неверный UNTIL: %s", value)
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("неверный BYDAY: %s", value)
+	}
+
+	weekday, ok := weekdayCodes[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("неверный BYDAY: %s", value)
+	}
+
+	day := WeekdayNum{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil {
+			return WeekdayNum{}, fmt.Errorf("неверный BYDAY: %s", value)
+		}
+		day.Ordinal = n
+	}
+
+	return day, nil
+}
+
+// String возвращает правило в формате RRULE
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	return strings.Join(parts, ";")
+}
+
+// MarshalJSON сериализует правило строкой RRULE
+func (r RecurrenceRule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON разбирает правило из строки RRULE
+func (r *RecurrenceRule) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	rule, err := ParseRecurrenceRule(value)
+	if err != nil {
+		return err
+	}
+
+	*r = *rule
+	return nil
+}
+
+// Clone возвращает независимую копию правила (nil для nil)
+func (r *RecurrenceRule) Clone() *RecurrenceRule {
+	if r == nil {
+		return nil
+	}
+
+	rule := *r
+	rule.ByDay = append([]WeekdayNum(nil), r.ByDay...)
+	rule.ByMonthDay = append([]int(nil), r.ByMonthDay...)
+	return &rule
+}
+
+// Validate проверяет корректность правила повторения
+func (r *RecurrenceRule) Validate() error {
+	switch r.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+	case "":
+		return ValidationError{Field: "recurrence", Message: "Не указана частота повторения"}
+	default:
+		return ValidationError{Field: "recurrence", Message: "Неподдерживаемая частота повторения"}
+	}
+
+	if r.Interval < 0 || r.Count < 0 {
+		return ValidationError{Field: "recurrence", Message: "INTERVAL и COUNT не могут быть отрицательными"}
+	}
+
+	if r.Count > 0 && !r.Until.IsZero() {
+		return ValidationError{Field: "recurrence", Message: "COUNT и UNTIL не могут быть указаны одновременно"}
+	}
+
+	for _, day := range r.ByMonthDay {
+		if day == 0 || day < -31 || day > 31 {
+			return ValidationError{Field: "recurrence", Message: "BYMONTHDAY должен быть в диапазоне от -31 до 31"}
+		}
+	}
+
+	for _, day := range r.ByDay {
+		if day.Ordinal == 0 {
+			continue
+		}
+		if r.Freq != FreqMonthly && r.Freq != FreqYearly {
+			return ValidationError{Field: "recurrence", Message: "Номер дня недели в BYDAY допустим только для MONTHLY и YEARLY"}
+		}
+		if day.Ordinal < -53 || day.Ordinal > 53 {
+			return ValidationError{Field: "recurrence", Message: "Номер дня недели в BYDAY вне диапазона"}
+		}
+	}
+
+	return nil
+}
+
+// Between возвращает времена начала повторений серии, начинающейся в dtstart
+// с длительностью экземпляра duration, которые пересекаются с окном [from, to)
+func (r *RecurrenceRule) Between(dtstart time.Time, duration time.Duration, from, to time.Time) []time.Time {
+	var result []time.Time
+	interval := r.interval()
+
+	// Без COUNT можно не перебирать периоды, целиком лежащие до начала окна
+	first := 0
+	if r.Count == 0 {
+		first = r.periodsBefore(dtstart, from.Add(-duration)) / interval
+	}
+
+	count := 0
+	for p := first; p < first+maxRecurrencePeriods; p++ {
+		periodStart := r.periodStart(dtstart, p*interval)
+		if !periodStart.Before(to) {
+			break
+		}
+		if !r.Until.IsZero() && periodStart.After(r.Until) {
+			break
+		}
+
+		for _, start := range r.candidates(dtstart, periodStart) {
+			if start.Before(dtstart) {
+				continue
+			}
+			if !r.Until.IsZero() && start.After(r.Until) {
+				return result
+			}
+			count++
+			if r.Count > 0 && count > r.Count {
+				return result
+			}
+			if overlaps(start, start.Add(duration), from, to) {
+				result = append(result, start)
+			}
+		}
+	}
+
+	return result
+}
+
+func (r *RecurrenceRule) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+// periodsBefore возвращает число целых периодов правила между dtstart и t
+// с запасом в один период
+func (r *RecurrenceRule) periodsBefore(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+
+	var n int
+	switch r.Freq {
+	case FreqDaily:
+		n = int(t.Sub(dtstart).Hours() / 24)
+	case FreqWeekly:
+		n = int(t.Sub(dtstart).Hours() / 24 / 7)
+	case FreqMonthly:
+		t = t.In(dtstart.Location())
+		n = (t.Year()-dtstart.Year())*12 + int(t.Month()) - int(dtstart.Month())
+	case FreqYearly:
+		n = t.In(dtstart.Location()).Year() - dtstart.Year()
+	}
+
+	if n <= 1 {
+		return 0
+	}
+	return n - 1
+}
+
+// periodStart возвращает полночь первого дня n-го периода серии
+func (r *RecurrenceRule) periodStart(dtstart time.Time, n int) time.Time {
+	year, month, day := dtstart.Date()
+	loc := dtstart.Location()
+
+	switch r.Freq {
+	case FreqWeekly:
+		monday := day - mondayOffset(dtstart.Weekday())
+		return time.Date(year, month, monday+7*n, 0, 0, 0, 0, loc)
+	case FreqMonthly:
+		return time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, loc)
+	case FreqYearly:
+		return time.Date(year+n, time.January, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day+n, 0, 0, 0, 0, loc)
+	}
+}
+
+// candidates возвращает отсортированные времена начала повторений внутри периода
+func (r *RecurrenceRule) candidates(dtstart, periodStart time.Time) []time.Time {
+	year, month, day := periodStart.Date()
+	var days []time.Time
+
+	switch r.Freq {
+	case FreqDaily:
+		if r.matchesWeekday(periodStart.Weekday()) && r.matchesMonthDay(periodStart) {
+			days = append(days, periodStart)
+		}
+
+	case FreqWeekly:
+		weekdays := []time.Weekday{dtstart.Weekday()}
+		if len(r.ByDay) > 0 {
+			weekdays = weekdays[:0]
+			for _, wd := range r.ByDay {
+				weekdays = append(weekdays, wd.Weekday)
+			}
+		}
+		for _, wd := range weekdays {
+			d := time.Date(year, month, day+mondayOffset(wd), 0, 0, 0, 0, periodStart.Location())
+			if r.matchesMonthDay(d) {
+				days = append(days, d)
+			}
+		}
+
+	case FreqMonthly:
+		days = r.monthDays(periodStart, dtstart.Day(), true)
+
+	case FreqYearly:
+		switch {
+		case len(r.ByMonthDay) > 0:
+			for m := time.January; m <= time.December; m++ {
+				monthStart := time.Date(year, m, 1, 0, 0, 0, 0, periodStart.Location())
+				days = append(days, r.monthDays(monthStart, 0, false)...)
+			}
+		case len(r.ByDay) > 0:
+			days = r.yearWeekdays(periodStart)
+		default:
+			d := time.Date(year, dtstart.Month(), dtstart.Day(), 0, 0, 0, 0, periodStart.Location())
+			// 29 февраля в невисокосный год пропускается
+			if d.Month() == dtstart.Month() {
+				days = append(days, d)
+			}
+		}
+	}
+
+	hour, minute, sec := dtstart.Clock()
+	result := make([]time.Time, 0, len(days))
+	seen := make(map[time.Time]bool, len(days))
+	for _, d := range days {
+		y, m, dd := d.Date()
+		start := time.Date(y, m, dd, hour, minute, sec, dtstart.Nanosecond(), dtstart.Location())
+		if !seen[start] {
+			seen[start] = true
+			result = append(result, start)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+
+	return result
+}
+
+// monthDays разворачивает BYMONTHDAY/BYDAY внутри месяца; при отсутствии обоих
+// возвращает день defaultDay, если fallback разрешен
+func (r *RecurrenceRule) monthDays(monthStart time.Time, defaultDay int, fallback bool) []time.Time {
+	year, month, _ := monthStart.Date()
+	loc := monthStart.Location()
+	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	var days []time.Time
+
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, md := range r.ByMonthDay {
+			if md < 0 {
+				md = daysInMonth + 1 + md
+			}
+			if md < 1 || md > daysInMonth {
+				continue
+			}
+			d := time.Date(year, month, md, 0, 0, 0, 0, loc)
+			if r.matchesWeekday(d.Weekday()) {
+				days = append(days, d)
+			}
+		}
+	case len(r.ByDay) > 0:
+		for md := 1; md <= daysInMonth; md++ {
+			d := time.Date(year, month, md, 0, 0, 0, 0, loc)
+			nth := (md-1)/7 + 1
+			nthFromEnd := -((daysInMonth-md)/7 + 1)
+			if r.matchesWeekdayNum(d.Weekday(), nth, nthFromEnd) {
+				days = append(days, d)
+			}
+		}
+	case fallback && defaultDay <= daysInMonth:
+		days = append(days, time.Date(year, month, defaultDay, 0, 0, 0, 0, loc))
+	}
+
+	return days
+}
+
+// yearWeekdays разворачивает BYDAY в пределах года, порядковые номера считаются от начала года
+func (r *RecurrenceRule) yearWeekdays(yearStart time.Time) []time.Time {
+	year := yearStart.Year()
+	loc := yearStart.Location()
+	daysInYear := time.Date(year, time.December, 31, 0, 0, 0, 0, loc).YearDay()
+	var days []time.Time
+
+	for yd := 1; yd <= daysInYear; yd++ {
+		d := time.Date(year, time.January, yd, 0, 0, 0, 0, loc)
+		nth := (yd-1)/7 + 1
+		nthFromEnd := -((daysInYear-yd)/7 + 1)
+		if r.matchesWeekdayNum(d.Weekday(), nth, nthFromEnd) {
+			days = append(days, d)
+		}
+	}
+
+	return days
+}
+
+func (r *RecurrenceRule) matchesWeekday(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, day := range r.ByDay {
+		if day.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurrenceRule) matchesWeekdayNum(wd time.Weekday, nth, nthFromEnd int) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday != wd {
+			continue
+		}
+		if day.Ordinal == 0 || day.Ordinal == nth || day.Ordinal == nthFromEnd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RecurrenceRule) matchesMonthDay(d time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, d.Location()).Day()
+	for _, md := range r.ByMonthDay {
+		if md == d.Day() || daysInMonth+1+md == d.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// mondayOffset возвращает номер дня недели, считая понедельник нулевым
+func mondayOffset(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+// overlaps проверяет, пересекается ли интервал [start, end) с окном [from, to).
+// События нулевой длительности считаются попавшими в окно, если начинаются внутри него
+func overlaps(start, end, from, to time.Time) bool {
+	if !start.Before(to) {
+		return false
+	}
+	if end.Equal(start) {
+		return !start.Before(from)
+	}
+	return end.After(from)
+}
+
+// OccurrenceID формирует стабильный ID экземпляра повторяющегося события
+func OccurrenceID(seriesID string, start time.Time) string {
+	return seriesID + "_" + start.UTC().Format(untilLayout)
+}
+
+// ParseOccurrenceID разбирает ID экземпляра на ID серии и время начала
+func ParseOccurrenceID(id string) (string, time.Time, bool) {
+	i := strings.LastIndex(id, "_")
+	if i <= 0 {
+		return "", time.Time{}, false
+	}
+
+	start, err := time.Parse(untilLayout, id[i+1:])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	return id[:i], start, true
+}
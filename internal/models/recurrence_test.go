@@ -0,0 +1,274 @@
+// internal/models/recurrence_test.go
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testLayout - формат времени в ожидаемых результатах: местное время со смещением
+const testLayout = "2006-01-02 15:04 -0700"
+
+func formatStarts(starts []time.Time) []string {
+	result := make([]string, len(starts))
+	for i, start := range starts {
+		result[i] = start.Format(testLayout)
+	}
+	return result
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		dtstart  time.Time
+		from, to time.Time
+		want     []string
+	}{
+		{
+			name:    "count",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{"2026-03-02 09:00 +0000", "2026-03-03 09:00 +0000", "2026-03-04 09:00 +0000"},
+		},
+		{
+			name:    "count counts occurrences before window",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 4, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{"2026-03-04 09:00 +0000"},
+		},
+		{
+			name:    "until is inclusive",
+			rule:    "FREQ=WEEKLY;UNTIL=20260316T090000Z",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{"2026-03-02 09:00 +0000", "2026-03-09 09:00 +0000", "2026-03-16 09:00 +0000"},
+		},
+		{
+			name:    "weekly byday skips days before dtstart",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3",
+			dtstart: utc(2026, time.March, 4, 9),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{"2026-03-04 09:00 +0000", "2026-03-09 09:00 +0000", "2026-03-11 09:00 +0000"},
+		},
+		{
+			name:    "interval",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU",
+			dtstart: utc(2026, time.March, 3, 9),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{"2026-03-03 09:00 +0000", "2026-03-17 09:00 +0000", "2026-03-31 09:00 +0000"},
+		},
+		{
+			name:    "last friday of month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			dtstart: utc(2026, time.January, 30, 9),
+			from:    utc(2026, time.January, 1, 0),
+			to:      utc(2027, time.January, 1, 0),
+			want:    []string{"2026-01-30 09:00 +0000", "2026-02-27 09:00 +0000", "2026-03-27 09:00 +0000"},
+		},
+		{
+			name:    "monthly skips short months",
+			rule:    "FREQ=MONTHLY;COUNT=3",
+			dtstart: utc(2026, time.January, 31, 9),
+			from:    utc(2026, time.January, 1, 0),
+			to:      utc(2027, time.January, 1, 0),
+			want:    []string{"2026-01-31 09:00 +0000", "2026-03-31 09:00 +0000", "2026-05-31 09:00 +0000"},
+		},
+		{
+			name:    "leap day",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: utc(2024, time.February, 29, 9),
+			from:    utc(2024, time.January, 1, 0),
+			to:      utc(2030, time.January, 1, 0),
+			want:    []string{"2024-02-29 09:00 +0000", "2028-02-29 09:00 +0000"},
+		},
+		{
+			name:    "window in the middle",
+			rule:    "FREQ=DAILY",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 10, 0),
+			to:      utc(2026, time.March, 12, 0),
+			want:    []string{"2026-03-10 09:00 +0000", "2026-03-11 09:00 +0000"},
+		},
+		{
+			name:    "occurrence overlapping window start",
+			rule:    "FREQ=DAILY",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 10, 9).Add(30 * time.Minute),
+			to:      utc(2026, time.March, 10, 12),
+			want:    []string{"2026-03-10 09:00 +0000"},
+		},
+		{
+			// Переход на летнее время 29 марта: время по часам сохраняется
+			name:    "spring forward",
+			rule:    "FREQ=WEEKLY;COUNT=3",
+			dtstart: time.Date(2026, time.March, 23, 10, 0, 0, 0, berlin),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.May, 1, 0),
+			want:    []string{"2026-03-23 10:00 +0100", "2026-03-30 10:00 +0200", "2026-04-06 10:00 +0200"},
+		},
+		{
+			// Возврат на зимнее время 25 октября
+			name:    "fall back",
+			rule:    "FREQ=DAILY;COUNT=3",
+			dtstart: time.Date(2026, time.October, 24, 10, 0, 0, 0, berlin),
+			from:    utc(2026, time.October, 1, 0),
+			to:      utc(2026, time.November, 1, 0),
+			want:    []string{"2026-10-24 10:00 +0200", "2026-10-25 10:00 +0100", "2026-10-26 10:00 +0100"},
+		},
+		{
+			name:    "until before dtstart",
+			rule:    "FREQ=DAILY;UNTIL=20260301T000000Z",
+			dtstart: utc(2026, time.March, 2, 9),
+			from:    utc(2026, time.March, 1, 0),
+			to:      utc(2026, time.April, 1, 0),
+			want:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := formatStarts(rule.Between(tt.dtstart, time.Hour, tt.from, tt.to))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between(%s) = %v, ожидалось %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecurrenceRuleErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101T000000Z",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+
+	for _, value := range tests {
+		if _, err := ParseRecurrenceRule(value); err == nil {
+			t.Errorf("ParseRecurrenceRule(%q): ожидалась ошибка", value)
+		}
+	}
+}
+
+func TestOccurrencesExceptions(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	series := NewEvent("Планерка", start, start.Add(time.Hour), nil, EventDetails{})
+	series.Recurrence = &RecurrenceRule{Freq: FreqDaily, Count: 5}
+
+	// 4 марта отменено, 3 марта перенесено на 15:00, 5 марта - на 10 марта
+	series.CancelOccurrence(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.UTC))
+	for day, moved := range map[int]time.Time{
+		3: time.Date(2026, time.March, 3, 15, 0, 0, 0, time.UTC),
+		5: time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC),
+	} {
+		override, ok := series.OccurrenceAt(time.Date(2026, time.March, day, 9, 0, 0, 0, time.UTC))
+		if !ok {
+			t.Fatalf("нет экземпляра %d марта", day)
+		}
+		override.StartTime = moved
+		override.EndTime = moved.Add(time.Hour)
+		series.SetOverride(override)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{
+			name: "whole series",
+			from: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+			want: []string{"2026-03-02 09:00 +0000", "2026-03-03 15:00 +0000", "2026-03-06 09:00 +0000", "2026-03-10 09:00 +0000"},
+		},
+		{
+			name: "moved out of window",
+			from: time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC),
+			want: []string{},
+		},
+		{
+			name: "moved into window",
+			from: time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2026, time.March, 11, 0, 0, 0, 0, time.UTC),
+			want: []string{"2026-03-10 09:00 +0000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrences := series.Occurrences(tt.from, tt.to)
+			starts := make([]time.Time, len(occurrences))
+			for i, occurrence := range occurrences {
+				starts[i] = occurrence.StartTime
+				if occurrence.RecurringEventID != series.ID {
+					t.Errorf("экземпляр %s: RecurringEventID = %q", occurrence.ID, occurrence.RecurringEventID)
+				}
+			}
+			if got := formatStarts(starts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Occurrences = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := series.OccurrenceAt(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.UTC)); ok {
+		t.Error("отмененный экземпляр найден OccurrenceAt")
+	}
+}
+
+func TestSplitAtFirstOccurrence(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	series := NewEvent("Планерка", start, start.Add(time.Hour), nil, EventDetails{})
+	series.Recurrence = &RecurrenceRule{Freq: FreqDaily, Count: 5}
+	series.CancelOccurrence(start.AddDate(0, 0, 2))
+	override, ok := series.OccurrenceAt(start.AddDate(0, 0, 1))
+	if !ok {
+		t.Fatal("нет экземпляра 3 марта")
+	}
+	override.Title = "Планерка (перенесена)"
+	series.SetOverride(override)
+	before := series.Clone()
+
+	// Разделение на первом экземпляре не оставляет текущей серии экземпляров:
+	// новая серия получает копии исключений, а текущая не меняется
+	tail, kept := series.SplitAt(start)
+	if kept {
+		t.Fatal("SplitAt на первом экземпляре: у текущей серии остались экземпляры")
+	}
+	if !reflect.DeepEqual(series, before) {
+		t.Errorf("SplitAt изменил текущую серию: %+v, ожидалось %+v", series, before)
+	}
+	if len(tail.ExDates) != 1 || len(tail.Overrides) != 1 || tail.Overrides[0].RecurringEventID != tail.ID {
+		t.Errorf("исключения новой серии: %v, %+v", tail.ExDates, tail.Overrides)
+	}
+
+	tail.ExDates[0] = start
+	if series.ExDates[0].Equal(start) {
+		t.Error("исключения новой серии разделяют память с текущей")
+	}
+}
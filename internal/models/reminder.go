@@ -0,0 +1,117 @@
+// internal/models/reminder.go
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Ограничения напоминаний события: не больше MaxReminders напоминаний,
+// каждое не раньше чем за MaxReminderOffset минут (четыре недели) до начала
+const (
+	MaxReminders      = 5
+	MaxReminderOffset = 4 * 7 * 24 * 60
+)
+
+// Reminder - напоминание о событии или экземпляре серии: срабатывает в Due,
+// за Offset минут до начала Start. Сработавшие напоминания сохраняются
+// хранилищем вместе с отметками FiredAt и AcknowledgedAt, чтобы после
+// перезапуска сервера они не отправлялись повторно и не терялись
+type Reminder struct {
+	// ID строится из ID события (экземпляра), его начала и Offset (см.
+	// ReminderID): перенос события дает напоминанию новый ID
+	ID               string    `json:"id"`
+	EventID          string    `json:"eventId"`
+	RecurringEventID string    `json:"recurringEventId,omitempty"`
+	Title            string    `json:"title"`
+	Start            time.Time `json:"start"`
+	AllDay           bool      `json:"allDay,omitempty"`
+	Offset           int       `json:"offset"`
+	Due              time.Time `json:"due"`
+
+	FiredAt        *time.Time `json:"firedAt,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt,omitempty"`
+}
+
+// ReminderID возвращает ID напоминания за offset минут до начала start события eventID
+func ReminderID(eventID string, start time.Time, offset int) string {
+	return fmt.Sprintf("%s@%s-%d", eventID, start.UTC().Format(untilLayout), offset)
+}
+
+// Clone возвращает копию напоминания
+func (r *Reminder) Clone() *Reminder {
+	clone := *r
+	if r.FiredAt != nil {
+		firedAt := *r.FiredAt
+		clone.FiredAt = &firedAt
+	}
+	if r.AcknowledgedAt != nil {
+		acknowledgedAt := *r.AcknowledgedAt
+		clone.AcknowledgedAt = &acknowledgedAt
+	}
+	return &clone
+}
+
+// Pending сообщает, что напоминание сработало, но еще не подтверждено
+func (r *Reminder) Pending() bool {
+	return r.FiredAt != nil && r.AcknowledgedAt == nil
+}
+
+// DueReminders возвращает напоминания событий events со сроком в окне
+// [from, to), упорядоченные по сроку. Серии должны быть уже развернуты
+// в экземпляры (см. EventStore.Range); повторяющиеся смещения учитываются один раз
+func DueReminders(events []*Event, from, to time.Time) []*Reminder {
+	var reminders []*Reminder
+	for _, event := range events {
+		seen := make(map[int]bool)
+		for _, offset := range event.Reminders {
+			if seen[offset] {
+				continue
+			}
+			seen[offset] = true
+
+			start := event.StartTime.In(event.Location())
+			due := start.Add(-time.Duration(offset) * time.Minute)
+			if due.Before(from) || !due.Before(to) {
+				continue
+			}
+			reminders = append(reminders, &Reminder{
+				ID:               ReminderID(event.ID, start, offset),
+				EventID:          event.ID,
+				RecurringEventID: event.RecurringEventID,
+				Title:            event.Title,
+				Start:            start,
+				AllDay:           event.AllDay,
+				Offset:           offset,
+				Due:              due,
+			})
+		}
+	}
+
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].Due.Before(reminders[j].Due)
+	})
+	return reminders
+}
+
+// validateReminders проверяет число и смещения напоминаний
+func (e *Event) validateReminders() error {
+	if len(e.Reminders) > MaxReminders {
+		return ValidationError{Field: "reminders", Message: fmt.Sprintf("У события может быть не больше %d напоминаний", MaxReminders)}
+	}
+	for _, offset := range e.Reminders {
+		if offset < 0 || offset > MaxReminderOffset {
+			return ValidationError{Field: "reminders", Message: fmt.Sprintf("Напоминание задается в минутах до начала: от 0 до %d", MaxReminderOffset)}
+		}
+	}
+	return nil
+}
+
+// cloneInts возвращает копию среза чисел (nil для nil)
+func cloneInts(values []int) []int {
+	if values == nil {
+		return nil
+	}
+	return append([]int(nil), values...)
+}
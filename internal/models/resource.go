@@ -0,0 +1,188 @@
+// internal/models/resource.go
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ResourceKind - вид ресурса расписания
+type ResourceKind string
+
+const (
+	ResourceGroup   ResourceKind = "group"
+	ResourceTeacher ResourceKind = "teacher"
+	ResourceRoom    ResourceKind = "room"
+)
+
+// ResourceKinds - все виды ресурсов
+var ResourceKinds = []ResourceKind{ResourceGroup, ResourceTeacher, ResourceRoom}
+
+// MaxResourceNameLength - ограничение длины названия ресурса (в символах)
+const MaxResourceNameLength = 200
+
+// Valid сообщает, что вид ресурса известен
+func (k ResourceKind) Valid() bool {
+	switch k {
+	case ResourceGroup, ResourceTeacher, ResourceRoom:
+		return true
+	default:
+		return false
+	}
+}
+
+// Name возвращает русское название вида ресурса
+func (k ResourceKind) Name() string {
+	switch k {
+	case ResourceGroup:
+		return "группа"
+	case ResourceTeacher:
+		return "преподаватель"
+	case ResourceRoom:
+		return "аудитория"
+	default:
+		return string(k)
+	}
+}
+
+// Resource - участник или место занятий: учебная группа, преподаватель
+// или аудитория. События ссылаются на ресурсы по ID (Event.Groups,
+// Event.Teachers, Event.Rooms), поэтому переименование ресурса не требует
+// изменения событий
+type Resource struct {
+	ID   string       `json:"id"`
+	Kind ResourceKind `json:"kind"`
+	Name string       `json:"name"`
+
+	// Description - необязательное описание: кафедра, корпус, направление
+	Description string `json:"description,omitempty"`
+	// Email - адрес преподавателя
+	Email string `json:"email,omitempty"`
+	// Size - число студентов группы, Capacity - число мест в аудитории
+	Size     int `json:"size,omitempty"`
+	Capacity int `json:"capacity,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Version увеличивается хранилищем при каждом сохранении ресурса (ETag / If-Match)
+	Version int64 `json:"version"`
+}
+
+// NewResource создает ресурс с автоматически сгенерированным ID
+func NewResource(kind ResourceKind, name string) *Resource {
+	return &Resource{
+		ID:        generateID(),
+		Kind:      kind,
+		Name:      name,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Validate проверяет корректность данных ресурса
+func (r *Resource) Validate() error {
+	if !r.Kind.Valid() {
+		return ValidationError{Field: "kind", Message: "Неизвестный вид ресурса: " + string(r.Kind)}
+	}
+
+	if strings.TrimSpace(r.Name) == "" {
+		return ValidationError{Field: "name", Message: "Название не может быть пустым"}
+	}
+	if utf8.RuneCountInString(r.Name) > MaxResourceNameLength {
+		return ValidationError{Field: "name", Message: fmt.Sprintf("Название не может быть длиннее %d символов", MaxResourceNameLength)}
+	}
+	if utf8.RuneCountInString(r.Description) > MaxDescriptionLength {
+		return ValidationError{Field: "description", Message: fmt.Sprintf("Описание не может быть длиннее %d символов", MaxDescriptionLength)}
+	}
+
+	if r.Email != "" {
+		if r.Kind != ResourceTeacher {
+			return ValidationError{Field: "email", Message: "Адрес электронной почты указывается только для преподавателя"}
+		}
+		if _, err := mail.ParseAddress(r.Email); err != nil {
+			return ValidationError{Field: "email", Message: "Неверный адрес электронной почты"}
+		}
+	}
+
+	if r.Size < 0 || (r.Size > 0 && r.Kind != ResourceGroup) {
+		return ValidationError{Field: "size", Message: "Число студентов указывается только для группы и не может быть отрицательным"}
+	}
+	if r.Capacity < 0 || (r.Capacity > 0 && r.Kind != ResourceRoom) {
+		return ValidationError{Field: "capacity", Message: "Число мест указывается только для аудитории и не может быть отрицательным"}
+	}
+
+	return nil
+}
+
+// Clone возвращает копию ресурса
+func (r *Resource) Clone() *Resource {
+	clone := *r
+	return &clone
+}
+
+// ResourceIDs возвращает ID ресурсов вида kind, на которые ссылается событие
+func (e *Event) ResourceIDs(kind ResourceKind) []string {
+	switch kind {
+	case ResourceGroup:
+		return e.Groups
+	case ResourceTeacher:
+		return e.Teachers
+	case ResourceRoom:
+		return e.Rooms
+	default:
+		return nil
+	}
+}
+
+// Uses сообщает, ссылается ли событие на ресурс вида kind с ID id
+func (e *Event) Uses(kind ResourceKind, id string) bool {
+	for _, resourceID := range e.ResourceIDs(kind) {
+		if resourceID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesAnywhere сообщает, ссылается ли на ресурс само событие или один
+// из измененных экземпляров серии
+func (e *Event) UsesAnywhere(kind ResourceKind, id string) bool {
+	if e.Uses(kind, id) {
+		return true
+	}
+	for _, override := range e.Overrides {
+		if override.Uses(kind, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResourceRefs проверяет, что ссылки на ресурсы не пусты и не повторяются
+func (e *Event) validateResourceRefs() error {
+	for _, kind := range ResourceKinds {
+		seen := make(map[string]bool)
+		for _, id := range e.ResourceIDs(kind) {
+			if strings.TrimSpace(id) == "" {
+				return ValidationError{Field: string(kind) + "s", Message: "Пустая ссылка на ресурс"}
+			}
+			if seen[id] {
+				return ValidationError{Field: string(kind) + "s", Message: "Ресурс указан дважды: " + id}
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// cloneStrings возвращает копию среза строк (nil для nil)
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string(nil), values...)
+}
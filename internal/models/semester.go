@@ -0,0 +1,330 @@
+// internal/models/semester.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WeekParity задает, по каким неделям семестра проходит занятие. В вузах
+// недели чередуются: нечетная - "числитель", четная - "знаменатель"
+type WeekParity string
+
+const (
+	// WeekAny - событие не привязано к семестру и проходит без ограничений
+	WeekAny WeekParity = ""
+	// WeekEvery - занятие проходит каждую неделю, но только в дни семестра
+	WeekEvery WeekParity = "every"
+	// WeekOdd - занятие по нечетным неделям (числитель)
+	WeekOdd WeekParity = "odd"
+	// WeekEven - занятие по четным неделям (знаменатель)
+	WeekEven WeekParity = "even"
+)
+
+// weekParityNames - принимаемые обозначения четности недели
+var weekParityNames = map[string]WeekParity{
+	"":            WeekAny,
+	"every":       WeekEvery,
+	"weekly":      WeekEvery,
+	"еженедельно": WeekEvery,
+	"каждая":      WeekEvery,
+	"odd":         WeekOdd,
+	"числитель":   WeekOdd,
+	"числ":        WeekOdd,
+	"нечетная":    WeekOdd,
+	"нечётная":    WeekOdd,
+	"even":        WeekEven,
+	"знаменатель": WeekEven,
+	"знам":        WeekEven,
+	"четная":      WeekEven,
+	"чётная":      WeekEven,
+}
+
+// ParseWeekParity разбирает четность недели: odd/even/every или русские
+// обозначения ("числитель", "знам.")
+func ParseWeekParity(value string) (WeekParity, error) {
+	key := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+	if parity, ok := weekParityNames[key]; ok {
+		return parity, nil
+	}
+	return WeekAny, fmt.Errorf("неизвестная четность недели: %s", value)
+}
+
+// Name возвращает русское название четности недели
+func (p WeekParity) Name() string {
+	switch p {
+	case WeekOdd:
+		return "числитель"
+	case WeekEven:
+		return "знаменатель"
+	case WeekEvery:
+		return "каждая неделя"
+	default:
+		return ""
+	}
+}
+
+// Matches сообщает, проходит ли занятие с четностью p на неделе с номером week
+func (p WeekParity) Matches(week int) bool {
+	switch p {
+	case WeekOdd:
+		return week%2 == 1
+	case WeekEven:
+		return week%2 == 0
+	default:
+		return true
+	}
+}
+
+// validate проверяет, что четность недели - одно из известных значений
+func (p WeekParity) validate() error {
+	switch p {
+	case WeekAny, WeekEvery, WeekOdd, WeekEven:
+		return nil
+	default:
+		return ValidationError{Field: "weekParity", Message: "Четность недели должна быть every, odd или even"}
+	}
+}
+
+// Semester - учебный семестр: первый и последний день и каникулы (праздники).
+// Недели нумеруются с 1, начиная с недели (с понедельника), на которую
+// приходится первый день семестра; каникулы нумерацию не прерывают.
+// Даты хранятся как календарные (полночь UTC) и сравниваются с датами
+// событий в их собственных часовых поясах
+type Semester struct {
+	Start    time.Time
+	End      time.Time
+	Holidays []DateRange
+}
+
+// DateRange - период из одного или нескольких дней, обе границы включаются
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// Contains сообщает, приходится ли календарная дата date на период
+func (r DateRange) Contains(date time.Time) bool {
+	day := civilDate(date)
+	return !day.Before(r.From) && !day.After(r.To)
+}
+
+// MarshalText выводит период как дату "2026-11-04" или как две даты
+// через косую черту "2026-12-29/2027-01-11"
+func (r DateRange) MarshalText() ([]byte, error) {
+	text := r.From.Format(DateLayout)
+	if !r.To.Equal(r.From) {
+		text += "/" + r.To.Format(DateLayout)
+	}
+	return []byte(text), nil
+}
+
+// UnmarshalText разбирает период в формате MarshalText
+func (r *DateRange) UnmarshalText(data []byte) error {
+	value := string(data)
+	from, to, isRange := strings.Cut(value, "/")
+
+	var period DateRange
+	var err error
+	if period.From, err = time.Parse(DateLayout, strings.TrimSpace(from)); err != nil {
+		return fmt.Errorf("неверная дата: %q", value)
+	}
+	period.To = period.From
+	if isRange {
+		if period.To, err = time.Parse(DateLayout, strings.TrimSpace(to)); err != nil || period.To.Before(period.From) {
+			return fmt.Errorf("неверный период: %q", value)
+		}
+	}
+
+	*r = period
+	return nil
+}
+
+// semesterJSON - представление семестра в JSON: даты в формате YYYY-MM-DD,
+// каникулы - отдельными датами или периодами "2026-12-29/2027-01-11"
+type semesterJSON struct {
+	Start    string      `json:"start"`
+	End      string      `json:"end"`
+	Holidays []DateRange `json:"holidays,omitempty"`
+}
+
+// MarshalJSON выводит даты семестра без времени
+func (s Semester) MarshalJSON() ([]byte, error) {
+	return json.Marshal(semesterJSON{
+		Start:    s.Start.Format(DateLayout),
+		End:      s.End.Format(DateLayout),
+		Holidays: s.Holidays,
+	})
+}
+
+// UnmarshalJSON разбирает семестр и проверяет его границы
+func (s *Semester) UnmarshalJSON(data []byte) error {
+	var raw semesterJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	semester := Semester{Holidays: raw.Holidays}
+	var err error
+	if semester.Start, err = time.Parse(DateLayout, raw.Start); err != nil {
+		return fmt.Errorf("неверное начало семестра: %q", raw.Start)
+	}
+	if semester.End, err = time.Parse(DateLayout, raw.End); err != nil {
+		return fmt.Errorf("неверный конец семестра: %q", raw.End)
+	}
+
+	if err := semester.Validate(); err != nil {
+		return err
+	}
+
+	*s = semester
+	return nil
+}
+
+// Validate проверяет, что семестр не заканчивается раньше, чем начинается
+func (s *Semester) Validate() error {
+	if s.Start.IsZero() || s.End.IsZero() {
+		return ValidationError{Field: "semester", Message: "Не указаны границы семестра"}
+	}
+	if s.End.Before(s.Start) {
+		return ValidationError{Field: "semester", Message: "Конец семестра не может быть раньше начала"}
+	}
+	return nil
+}
+
+// Contains сообщает, приходится ли календарная дата date на семестр
+func (s *Semester) Contains(date time.Time) bool {
+	return DateRange{From: civilDate(s.Start), To: civilDate(s.End)}.Contains(date)
+}
+
+// IsHoliday сообщает, приходится ли календарная дата date на каникулы
+func (s *Semester) IsHoliday(date time.Time) bool {
+	for _, holiday := range s.Holidays {
+		if holiday.Contains(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// Week возвращает номер учебной недели, на которую приходится календарная
+// дата date; false, если дата вне семестра
+func (s *Semester) Week(date time.Time) (int, bool) {
+	if !s.Contains(date) {
+		return 0, false
+	}
+
+	start := civilDate(s.Start)
+	firstMonday := start.AddDate(0, 0, -mondayOffset(start.Weekday()))
+	return DaysBetween(firstMonday, civilDate(date))/7 + 1, true
+}
+
+// Parity возвращает четность недели, на которую приходится дата date
+// (WeekOdd или WeekEven); false, если дата вне семестра
+func (s *Semester) Parity(date time.Time) (WeekParity, bool) {
+	week, ok := s.Week(date)
+	if !ok {
+		return WeekAny, false
+	}
+	if week%2 == 1 {
+		return WeekOdd, true
+	}
+	return WeekEven, true
+}
+
+// Includes сообщает, проходит ли событие (или экземпляр серии) в семестре:
+// события без четности недели не ограничиваются, занятия с четностью
+// проходят только в дни семестра вне каникул и по неделям своей четности.
+// День занятия определяется по времени начала в часовом поясе события
+func (s *Semester) Includes(e *Event) bool {
+	if e.WeekParity == WeekAny {
+		return true
+	}
+
+	date := e.StartTime.In(e.Location())
+	week, ok := s.Week(date)
+	return ok && !s.IsHoliday(date) && e.WeekParity.Matches(week)
+}
+
+// Restrict возвращает событие для экспорта в календарь, в котором учтены
+// семестр и четность недели: у серии начало переносится на первое
+// проходящее занятие, COUNT заменяется на UNTIL последнего занятия, а
+// пропущенные недели и каникулы становятся исключениями (EXDATE). Если
+// занятия идут через неделю, еженедельное правило заменяется на INTERVAL=2,
+// чтобы исключениями остались только каникулы. false - событие в семестре
+// не проходит. События без четности недели возвращаются без изменений
+func (s *Semester) Restrict(e *Event) (*Event, bool) {
+	if s == nil || e.WeekParity == WeekAny {
+		return e, true
+	}
+	if !e.IsRecurring() {
+		return e, s.Includes(e)
+	}
+
+	loc := e.Location()
+	year, month, day := civilDate(s.End).Date()
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+
+	var kept []time.Time
+	for _, start := range e.Recurrence.Between(e.seriesStart(), 0, e.StartTime, end) {
+		if e.isExcluded(start) {
+			continue
+		}
+		occurrence := e.occurrence(start)
+		if override := e.findOverride(start); override != nil {
+			occurrence = e.overrideCopy(override)
+		}
+		if s.Includes(occurrence) {
+			kept = append(kept, start)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, false
+	}
+
+	first, last := kept[0], kept[len(kept)-1]
+	restricted := e.Clone()
+	restricted.StartTime = first
+	restricted.EndTime = e.endFor(first)
+	restricted.Recurrence.Count = 0
+	restricted.Recurrence.Until = last
+
+	if e.Recurrence.Freq == FreqWeekly && e.Recurrence.interval() == 1 && e.WeekParity != WeekEvery {
+		restricted.Recurrence.Interval = 2
+		if !restricted.generates(kept) {
+			restricted.Recurrence.Interval = e.Recurrence.Interval
+		}
+	}
+
+	keep := make(map[int64]bool, len(kept))
+	for _, start := range kept {
+		keep[start.UnixNano()] = true
+	}
+	restricted.ExDates = nil
+	for _, start := range restricted.Recurrence.Between(restricted.seriesStart(), 0, first, last.Add(time.Nanosecond)) {
+		if !keep[start.UnixNano()] {
+			restricted.ExDates = append(restricted.ExDates, start)
+		}
+	}
+	overrides := restricted.Overrides[:0]
+	for _, override := range restricted.Overrides {
+		if keep[override.OriginalStartTime.UnixNano()] {
+			overrides = append(overrides, override)
+		}
+	}
+	restricted.Overrides = overrides
+
+	return restricted, true
+}
+
+// generates проверяет, что правило серии порождает все начала starts
+func (e *Event) generates(starts []time.Time) bool {
+	for _, start := range starts {
+		if !e.isOccurrence(start) {
+			return false
+		}
+	}
+	return true
+}
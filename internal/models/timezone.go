@@ -0,0 +1,81 @@
+// internal/models/timezone.go
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// locationCache хранит уже загруженные часовые пояса: time.LoadLocation
+// читает базу часовых поясов при каждом вызове
+var locationCache sync.Map
+
+// LoadLocation загружает часовой пояс IANA (например, Europe/Moscow) с кэшированием
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// Location возвращает часовой пояс события. Если пояс не задан или неизвестен,
+// используется смещение, с которым сохранено время начала
+func (e *Event) Location() *time.Location {
+	if e.TimeZone != "" {
+		if loc, err := LoadLocation(e.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return e.StartTime.Location()
+}
+
+// seriesStart возвращает начало серии в часовом поясе события: правило
+// повторения разворачивается по местному времени, поэтому при переходе
+// на летнее время экземпляры сохраняют время по часам
+func (e *Event) seriesStart() time.Time {
+	return e.StartTime.In(e.Location())
+}
+
+// LocalLayouts - форматы даты и времени без часового пояса, которые принимаются
+// во входных данных (пути запросов, импортируемые таблицы). Форматы только
+// с датой идут в начале списка до первого формата со временем
+var LocalLayouts = []string{
+	"2006-01-02",
+	"2.1.2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2.1.2006 15:04:05",
+	"2.1.2006 15:04",
+}
+
+// dateOnlyLayouts - число форматов только с датой в начале LocalLayouts
+const dateOnlyLayouts = 2
+
+// ParseLocalTime разбирает дату или дату со временем в одном из форматов
+// LocalLayouts (время относится к поясу loc) либо момент в формате RFC 3339.
+// Второе значение сообщает, что указана только дата
+func ParseLocalTime(value string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	for i, layout := range LocalLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, i < dateOnlyLayouts, nil
+		}
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("неверный формат даты: %s", value)
+}
@@ -0,0 +1,88 @@
+// internal/reminders/notifier.go
+package reminders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"schedule-app/internal/models"
+	"time"
+)
+
+// Notifier доставляет сработавшее напоминание. Ошибка означает, что
+// напоминание не доставлено: планировщик повторит его на следующем шаге
+type Notifier interface {
+	Notify(ctx context.Context, reminder *models.Reminder) error
+}
+
+// NotifierFunc позволяет использовать функцию как Notifier
+type NotifierFunc func(ctx context.Context, reminder *models.Reminder) error
+
+// Notify вызывает f
+func (f NotifierFunc) Notify(ctx context.Context, reminder *models.Reminder) error {
+	return f(ctx, reminder)
+}
+
+// LogNotifier записывает напоминания в журнал сервера
+type LogNotifier struct{}
+
+// Notify записывает напоминание в журнал
+func (LogNotifier) Notify(ctx context.Context, reminder *models.Reminder) error {
+	log.Printf("Напоминание: %q начнется %s (за %d мин)", reminder.Title, reminder.Start.Format("2006-01-02 15:04 MST"), reminder.Offset)
+	return nil
+}
+
+// WebhookNotifier отправляет напоминание POST-запросом с телом JSON
+// (models.Reminder) на адрес URL. Ответ с кодом не 2xx - ошибка доставки
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier создает Notifier, отправляющий напоминания на url
+// с ограничением времени запроса в 10 секунд
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify отправляет напоминание
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder *models.Reminder) error {
+	body, err := json.Marshal(reminder)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("не удалось отправить напоминание: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("адрес %s ответил кодом %d", n.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// Multi доставляет напоминание через все notifiers по порядку и возвращает
+// первую ошибку; при повторе напоминание получат и те, кому оно уже доставлено
+func Multi(notifiers ...Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, reminder *models.Reminder) error {
+		var first error
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, reminder); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
@@ -0,0 +1,159 @@
+// internal/reminders/scheduler.go
+
+// Package reminders рассылает напоминания о событиях (models.Event.Reminders).
+// Планировщик периодически находит в хранилище наступившие напоминания,
+// включая экземпляры повторяющихся серий, доставляет их через Notifier
+// и сохраняет в хранилище отметку о срабатывании и момент, до которого
+// рассылка выполнена. После перезапуска рассылка продолжается с этого
+// момента: отправленные напоминания не повторяются, а наступившие за время
+// простоя (не старше CatchUp) досылаются
+package reminders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"time"
+)
+
+// Значения по умолчанию для планировщика
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultCatchUp   = 24 * time.Hour
+	DefaultRetention = 30 * 24 * time.Hour
+)
+
+// Scheduler - планировщик напоминаний
+type Scheduler struct {
+	events   storage.EventStore
+	state    storage.ReminderStore
+	notifier Notifier
+
+	// Interval - период проверки наступивших напоминаний
+	Interval time.Duration
+	// CatchUp - насколько старые пропущенные напоминания досылаются после
+	// простоя сервера или неудачной доставки
+	CatchUp time.Duration
+	// Retention - сколько хранятся записи о сработавших напоминаниях
+	// (не меньше CatchUp, иначе напоминания могли бы повториться)
+	Retention time.Duration
+}
+
+// NewScheduler создает планировщик с параметрами по умолчанию: события
+// читаются из events, сработавшие напоминания и момент рассылки хранятся в state
+func NewScheduler(events storage.EventStore, state storage.ReminderStore, notifier Notifier) *Scheduler {
+	return &Scheduler{
+		events:    events,
+		state:     state,
+		notifier:  notifier,
+		Interval:  DefaultInterval,
+		CatchUp:   DefaultCatchUp,
+		Retention: DefaultRetention,
+	}
+}
+
+// Run проверяет напоминания сразу и затем каждые Interval, пока ctx не отменен
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Tick(ctx, time.Now()); err != nil {
+			log.Printf("Ошибка при рассылке напоминаний: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick доставляет напоминания со сроком от сохраненного момента рассылки
+// (не раньше now - CatchUp) до now и возвращает число доставленных.
+// При первом запуске прошлые напоминания не досылаются. Если доставка
+// не удалась, момент рассылки не сдвигается дальше срока недоставленного
+// напоминания, и оно повторяется на следующем шаге
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) (int, error) {
+	from, err := s.state.ReminderCheckpoint()
+	if err != nil {
+		return 0, err
+	}
+	if from.IsZero() {
+		from = now
+	}
+	if limit := now.Add(-s.CatchUp); from.Before(limit) {
+		from = limit
+	}
+
+	var due []*models.Reminder
+	if from.Before(now) {
+		// Событие с напоминанием за MaxReminderOffset минут начинается
+		// не позже now + MaxReminderOffset
+		events, err := s.events.Range(from, now.Add(models.MaxReminderOffset*time.Minute+time.Nanosecond))
+		if err != nil {
+			return 0, err
+		}
+		due = models.DueReminders(events, from, now)
+	}
+
+	checkpoint := now
+	delivered := 0
+	var failure error
+	for _, reminder := range due {
+		if fired, err := s.state.GetReminder(reminder.ID); err == nil && fired.FiredAt != nil {
+			continue
+		}
+
+		if err := s.notifier.Notify(ctx, reminder); err != nil {
+			if reminder.Due.Before(checkpoint) {
+				checkpoint = reminder.Due
+			}
+			if failure == nil {
+				failure = fmt.Errorf("напоминание %s не доставлено: %w", reminder.ID, err)
+			}
+			continue
+		}
+
+		firedAt := time.Now()
+		reminder.FiredAt = &firedAt
+		if err := s.state.SaveReminder(reminder); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	if err := s.state.SetReminderCheckpoint(checkpoint); err != nil {
+		return delivered, err
+	}
+
+	retention := s.Retention
+	if retention < s.CatchUp {
+		retention = s.CatchUp
+	}
+	if _, err := s.state.PruneReminders(now.Add(-retention)); err != nil {
+		return delivered, err
+	}
+
+	return delivered, failure
+}
+
+// Upcoming возвращает напоминания со сроком в окне [from, to), упорядоченные
+// по сроку; сработавшие дополняются отметками из state
+func Upcoming(store storage.EventStore, state storage.ReminderStore, from, to time.Time) ([]*models.Reminder, error) {
+	events, err := store.Range(from, to.Add(models.MaxReminderOffset*time.Minute+time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+
+	reminders := models.DueReminders(events, from, to)
+	for i, reminder := range reminders {
+		if fired, err := state.GetReminder(reminder.ID); err == nil {
+			reminders[i] = fired
+		}
+	}
+	return reminders, nil
+}
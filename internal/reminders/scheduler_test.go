@@ -0,0 +1,97 @@
+// internal/reminders/scheduler_test.go
+package reminders
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestTickRetriesAndSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	path := filepath.Join(t.TempDir(), "events.json")
+	store, err := storage.NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Напоминания за 20 минут: у a - в 09:10, у b - в 09:20
+	for id, eventStart := range map[string]time.Time{"a": at(30), "b": at(40)} {
+		event := models.NewEvent("Встреча "+id, eventStart, eventStart.Add(time.Hour), nil, models.EventDetails{})
+		event.ID = id
+		event.Reminders = []int{20}
+		if err := store.Create(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Первая доставка напоминания a не удается
+	var sent []string
+	failures := map[string]int{"a": 1}
+	notifier := NotifierFunc(func(ctx context.Context, reminder *models.Reminder) error {
+		if failures[reminder.EventID] > 0 {
+			failures[reminder.EventID]--
+			return errors.New("получатель недоступен")
+		}
+		sent = append(sent, reminder.EventID)
+		return nil
+	})
+
+	steps := []struct {
+		name      string
+		now       time.Time
+		delivered int
+		wantErr   bool
+		sent      []string
+	}{
+		// Первый запуск не досылает прошлое, а только запоминает момент рассылки
+		{name: "first run", now: at(0), delivered: 0},
+		{name: "failed delivery", now: at(25), delivered: 1, wantErr: true, sent: []string{"b"}},
+		{name: "retry", now: at(26), delivered: 1, sent: []string{"b", "a"}},
+		{name: "nothing due", now: at(27), delivered: 0, sent: []string{"b", "a"}},
+	}
+
+	scheduler := NewScheduler(store, store, notifier)
+	for _, step := range steps {
+		delivered, err := scheduler.Tick(ctx, step.now)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: ошибка %v, ожидалась ошибка: %v", step.name, err, step.wantErr)
+		}
+		if delivered != step.delivered || !reflect.DeepEqual(sent, step.sent) {
+			t.Fatalf("%s: доставлено %d, отправлены %v; ожидалось %d, %v", step.name, delivered, sent, step.delivered, step.sent)
+		}
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// После перезапуска отправленные напоминания не повторяются, даже если
+	// момент рассылки не успел сохраниться
+	store, err = storage.NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.SetReminderCheckpoint(at(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	delivered, err := NewScheduler(store, store, notifier).Tick(ctx, at(28))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 0 || !reflect.DeepEqual(sent, []string{"b", "a"}) {
+		t.Errorf("после перезапуска: доставлено %d, отправлены %v; ожидалось 0, [b a]", delivered, sent)
+	}
+}
@@ -0,0 +1,499 @@
+// internal/spreadsheet/csv.go
+
+// Package spreadsheet импортирует и экспортирует расписание в табличных
+// форматах, с которыми работают в Excel и подобных программах
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Поля события, которые можно сопоставить столбцам таблицы
+const (
+	FieldID          = "id"
+	FieldTitle       = "title"
+	FieldDate        = "date"
+	FieldStart       = "start"
+	FieldEnd         = "end"
+	FieldAllDay      = "allDay"
+	FieldTimeZone    = "timeZone"
+	FieldTags        = "tags"
+	FieldLocation    = "location"
+	FieldURL         = "url"
+	FieldDescription = "description"
+)
+
+// exportFields - столбцы CSV при экспорте, в порядке вывода
+var exportFields = []string{
+	FieldID, FieldTitle, FieldStart, FieldEnd, FieldAllDay, FieldTimeZone,
+	FieldTags, FieldLocation, FieldURL, FieldDescription,
+}
+
+// headerAliases - заголовки столбцов, которые без явного сопоставления
+// распознаются как поля события (сравнение без учета регистра)
+var headerAliases = map[string]string{
+	"id":           FieldID,
+	"title":        FieldTitle,
+	"название":     FieldTitle,
+	"событие":      FieldTitle,
+	"date":         FieldDate,
+	"дата":         FieldDate,
+	"start":        FieldStart,
+	"starttime":    FieldStart,
+	"начало":       FieldStart,
+	"end":          FieldEnd,
+	"endtime":      FieldEnd,
+	"окончание":    FieldEnd,
+	"конец":        FieldEnd,
+	"allday":       FieldAllDay,
+	"весь день":    FieldAllDay,
+	"timezone":     FieldTimeZone,
+	"часовой пояс": FieldTimeZone,
+	"tags":         FieldTags,
+	"теги":         FieldTags,
+	"location":     FieldLocation,
+	"место":        FieldLocation,
+	"url":          FieldURL,
+	"ссылка":       FieldURL,
+	"description":  FieldDescription,
+	"описание":     FieldDescription,
+}
+
+// exportLayout - формат времени в экспортируемом CSV (в поясе события)
+const exportLayout = "2006-01-02 15:04"
+
+// WriteCSV записывает события в CSV с разделителем comma. Файл начинается
+// с BOM, чтобы Excel распознал кодировку UTF-8. Время выводится в часовом
+// поясе события, границы событий на весь день - датами (окончание не
+// включается, как в API). Ячейки, которые табличный редактор принял бы
+// за формулу, предваряются апострофом
+func WriteCSV(w io.Writer, events []*models.Event, comma rune) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(bw)
+	cw.Comma = comma
+	cw.UseCRLF = true
+	if err := cw.Write(exportFields); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		loc := event.Location()
+		layout := exportLayout
+		if event.AllDay {
+			layout = models.DateLayout
+		}
+
+		record := []string{
+			event.ID,
+			escapeFormula(event.Title),
+			event.StartTime.In(loc).Format(layout),
+			event.EndTime.In(loc).Format(layout),
+			strconv.FormatBool(event.AllDay),
+			event.TimeZone,
+			escapeFormula(strings.Join(event.Tags, ", ")),
+			escapeFormula(event.Place),
+			event.URL,
+			escapeFormula(event.Description),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// escapeFormula защищает от выполнения ячейки как формулы (CSV injection)
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula снимает защиту escapeFormula при импорте
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// CSVOptions - параметры импорта CSV
+type CSVOptions struct {
+	// Mapping сопоставляет полям события (FieldTitle, FieldStart, ...) столбцы:
+	// заголовок столбца или его номер, начиная с 1. Поля без сопоставления
+	// ищутся по заголовкам (title, название, начало, ...)
+	Mapping map[string]string
+	// Comma - разделитель; 0 - определить по строке заголовков
+	Comma rune
+	// Location - часовой пояс для времени, если в строке не указан timeZone
+	Location *time.Location
+	// DryRun - только проверить файл и вернуть предпросмотр без сохранения
+	DryRun bool
+}
+
+// ImportCSV разбирает таблицу CSV и сохраняет события в store. Импорт
+// выполняется целиком или не выполняется вовсе: при ошибках хотя бы в одной
+// строке хранилище не изменяется, а ошибки возвращаются в ImportResult.Errors.
+// Строка с ID существующего события обновляет его название, время и поля
+// сопоставленных столбцов (остальные поля сохраняются), строка с ID экземпляра
+// серии (из экспорта) изменяет этот экземпляр, остальные строки создают
+// новые события. Ошибка возвращается, если файл не удалось прочитать
+func ImportCSV(store storage.EventStore, r io.Reader, opts CSVOptions) (*ImportResult, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+
+	records, err := readCSV(r, opts.Comma)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("файл пуст")
+	}
+
+	columns, err := mapColumns(records[0], opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: opts.DryRun, Events: []*models.Event{}, Errors: []RowError{}}
+	var rows []*row
+	for i, record := range records[1:] {
+		number := i + 2
+		if isBlank(record) {
+			continue
+		}
+
+		parsed, rowErr := parseRow(store, record, columns, opts.Location)
+		if rowErr != nil {
+			rowErr.Row = number
+			result.Errors = append(result.Errors, *rowErr)
+			continue
+		}
+		parsed.number = number
+		rows = append(rows, parsed)
+		result.Events = append(result.Events, parsed.event)
+	}
+
+	commit(store, rows, result)
+	return result, nil
+}
+
+// parseRow создает событие из строки таблицы
+func parseRow(store storage.EventStore, record []string, columns map[string]int, defaultLoc *time.Location) (*row, *RowError) {
+	cell := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	fail := func(field, message string) *RowError {
+		return &RowError{Column: field, Message: message}
+	}
+
+	loc := defaultLoc
+	zone := cell(FieldTimeZone)
+	if zone != "" {
+		zoneLoc, err := models.LoadLocation(zone)
+		if err != nil {
+			return nil, fail(FieldTimeZone, "Неизвестный часовой пояс: "+zone)
+		}
+		loc = zoneLoc
+	} else {
+		zone = defaultLoc.String()
+	}
+
+	title := unescapeFormula(cell(FieldTitle))
+	if title == "" {
+		return nil, fail(FieldTitle, "Название не может быть пустым")
+	}
+
+	var date time.Time
+	if value := cell(FieldDate); value != "" {
+		t, dateOnly, err := models.ParseLocalTime(value, loc)
+		if err != nil || !dateOnly {
+			return nil, fail(FieldDate, "Неверная дата: "+value)
+		}
+		date = t
+	}
+
+	start, startDateOnly, err := parseCellTime(cell(FieldStart), date, loc)
+	if err != nil {
+		return nil, fail(FieldStart, err.Error())
+	}
+	if start.IsZero() {
+		if date.IsZero() {
+			return nil, fail(FieldStart, "Не указано время начала")
+		}
+		start, startDateOnly = date, true
+	}
+
+	startDay := date
+	if startDay.IsZero() {
+		y, m, d := start.In(loc).Date()
+		startDay = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	end, endDateOnly, err := parseCellTime(cell(FieldEnd), startDay, loc)
+	if err != nil {
+		return nil, fail(FieldEnd, err.Error())
+	}
+
+	allDay := startDateOnly && (end.IsZero() || endDateOnly)
+	if value := cell(FieldAllDay); value != "" {
+		parsed, ok := parseBool(value)
+		if !ok {
+			return nil, fail(FieldAllDay, "Неверное значение: "+value)
+		}
+		allDay = parsed
+	}
+	if end.IsZero() {
+		if !allDay {
+			return nil, fail(FieldEnd, "Не указано время окончания")
+		}
+		end = start.AddDate(0, 0, 1)
+	}
+
+	event := models.NewEvent(title, start, end, parseTags(cell(FieldTags)), models.EventDetails{
+		Description: unescapeFormula(cell(FieldDescription)),
+		Place:       unescapeFormula(cell(FieldLocation)),
+		URL:         cell(FieldURL),
+	})
+	event.TimeZone = zone
+	event.AllDay = allDay
+	event.NormalizeAllDay()
+
+	parsed := &row{event: event, update: func(existing *models.Event) {
+		updateMapped(existing, event, columns)
+	}}
+	if id := cell(FieldID); id != "" {
+		if strings.ContainsAny(id, "/?# \t") {
+			return nil, fail(FieldID, "Недопустимый ID: "+id)
+		}
+		event.ID = id
+		if seriesID, originalStart, ok := models.ParseOccurrenceID(id); ok {
+			series, err := store.Get(seriesID)
+			if err != nil {
+				return nil, fail(FieldID, "Серия "+seriesID+" не найдена")
+			}
+			if _, ok := series.OccurrenceAt(originalStart); !ok {
+				return nil, fail(FieldID, "Экземпляр серии "+id+" не найден")
+			}
+			parsed.seriesID, parsed.originalStart = seriesID, originalStart
+		}
+	}
+
+	if err := event.Validate(); err != nil {
+		if validationErr, ok := err.(models.ValidationError); ok {
+			return nil, fail(validationErr.Field, validationErr.Message)
+		}
+		return nil, fail("", err.Error())
+	}
+
+	return parsed, nil
+}
+
+// updateMapped переносит в существующее событие значения строки таблицы:
+// название и время, а также поля сопоставленных столбцов. Повторение,
+// исключения, напоминания, ресурсы и другие поля, которых нет в таблице,
+// сохраняются. Как и при изменении через API, новое время отвязывает
+// событие от пары расписания звонков
+func updateMapped(existing, imported *models.Event, columns map[string]int) {
+	mapped := func(field string) bool {
+		_, ok := columns[field]
+		return ok
+	}
+
+	existing.Title = imported.Title
+	if !existing.StartTime.Equal(imported.StartTime) || !existing.EndTime.Equal(imported.EndTime) {
+		existing.Slot = 0
+	}
+	existing.StartTime, existing.EndTime, existing.AllDay = imported.StartTime, imported.EndTime, imported.AllDay
+	if mapped(FieldTimeZone) {
+		existing.TimeZone = imported.TimeZone
+	}
+	if mapped(FieldTags) {
+		existing.Tags = imported.Tags
+	}
+	if mapped(FieldLocation) {
+		existing.Place = imported.Place
+	}
+	if mapped(FieldURL) {
+		existing.URL = imported.URL
+	}
+	if mapped(FieldDescription) {
+		existing.Description = imported.Description
+	}
+	existing.NormalizeAllDay()
+}
+
+// parseCellTime разбирает время из ячейки: дату со временем, только дату
+// или только время ("9:30"), которое относится к дню day
+func parseCellTime(value string, day time.Time, loc *time.Location) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	if t, dateOnly, err := models.ParseLocalTime(value, loc); err == nil {
+		return t, dateOnly, nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		clock, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if day.IsZero() {
+			return time.Time{}, false, fmt.Errorf("время %s указано без даты", value)
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc), false, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("неверный формат даты: %s", value)
+}
+
+// readCSV читает все записи CSV. Разделитель, если не задан, выбирается
+// по строке заголовков среди запятой, точки с запятой и табуляции
+func readCSV(r io.Reader, comma rune) ([][]string, error) {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\ufeff" {
+		br.Discard(3)
+	}
+
+	if comma == 0 {
+		comma = ','
+		// Peek возвращает доступные данные и при ошибке (короткий файл)
+		header, _ := br.Peek(4096)
+		line, _, _ := strings.Cut(string(header), "\n")
+		best := strings.Count(line, ",")
+		for _, candidate := range []rune{';', '\t'} {
+			if n := strings.Count(line, string(candidate)); n > best {
+				comma, best = candidate, n
+			}
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении CSV: %w", err)
+	}
+	return records, nil
+}
+
+// mapColumns определяет номера столбцов полей события по заголовку
+// и явному сопоставлению
+func mapColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		if field, ok := headerAliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+
+	for field, column := range mapping {
+		if !isField(field) {
+			return nil, fmt.Errorf("неизвестное поле события в сопоставлении: %s", field)
+		}
+		index, err := columnIndex(header, column)
+		if err != nil {
+			return nil, err
+		}
+		columns[field] = index
+	}
+
+	if _, ok := columns[FieldTitle]; !ok {
+		return nil, fmt.Errorf("не найден столбец с названием события (title)")
+	}
+	if _, ok := columns[FieldStart]; !ok {
+		if _, ok := columns[FieldDate]; !ok {
+			return nil, fmt.Errorf("не найден столбец с началом события (start или date)")
+		}
+	}
+
+	return columns, nil
+}
+
+// columnIndex находит столбец по заголовку или номеру, начиная с 1
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+			return i, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(column); err == nil && n >= 1 && n <= len(header) {
+		return n - 1, nil
+	}
+
+	return 0, fmt.Errorf("столбец %q не найден", column)
+}
+
+// isField проверяет, что имя - поле события, доступное для сопоставления
+func isField(name string) bool {
+	if name == FieldDate {
+		return true
+	}
+	for _, field := range exportFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlank сообщает, что строка таблицы пустая
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTags разбирает теги, перечисленные через запятую или точку с запятой
+func parseTags(value string) []string {
+	value = unescapeFormula(value)
+	var tags []string
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseBool разбирает логическое значение ячейки (true, 1, да, x, ...)
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes", "y", "да", "д", "x", "+":
+		return true, true
+	case "false", "0", "no", "n", "нет", "н", "-":
+		return false, true
+	}
+	return false, false
+}
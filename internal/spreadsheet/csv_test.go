@@ -0,0 +1,81 @@
+// internal/spreadsheet/csv_test.go
+package spreadsheet
+
+import (
+	"reflect"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImportCSVKeepsUnmappedFields(t *testing.T) {
+	store := storage.NewMemoryStore()
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+
+	rule, err := models.ParseRecurrenceRule("FREQ=WEEKLY;COUNT=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	series := models.NewEvent("Лекция", start, start.Add(90*time.Minute), []string{"ИВТ-21"}, models.EventDetails{Place: "Ауд. 101"})
+	series.ID = "lecture"
+	series.Recurrence = rule
+	series.ExDates = []time.Time{start.AddDate(0, 0, 7)}
+	series.Reminders = []int{15}
+	series.WeekParity = models.WeekOdd
+	series.Slot = 1
+	if err := store.Create(series); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		csv       string
+		wantPlace string
+		wantTags  []string
+		wantSlot  int
+	}{
+		{
+			name:      "title only",
+			csv:       "id,title,start,end\nlecture,Лекция (поток),2026-03-02T09:00:00Z,2026-03-02T10:30:00Z\n",
+			wantPlace: "Ауд. 101",
+			wantTags:  []string{"ИВТ-21"},
+			wantSlot:  1,
+		},
+		{
+			name:      "mapped location, new time",
+			csv:       "id,title,start,end,location\nlecture,Лекция (поток),2026-03-02T10:40:00Z,2026-03-02T12:10:00Z,Ауд. 202\n",
+			wantPlace: "Ауд. 202",
+			wantTags:  []string{"ИВТ-21"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ImportCSV(store, strings.NewReader(tt.csv), CSVOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) > 0 || result.Updated != 1 {
+				t.Fatalf("обновлено %d, ошибки %v; ожидалось одно обновление", result.Updated, result.Errors)
+			}
+
+			got, err := store.Get("lecture")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "Лекция (поток)" {
+				t.Errorf("название %q, ожидалось %q", got.Title, "Лекция (поток)")
+			}
+			if got.Place != tt.wantPlace || !reflect.DeepEqual(got.Tags, tt.wantTags) || got.Slot != tt.wantSlot {
+				t.Errorf("место %q, теги %v, пара %d; ожидалось %q, %v, %d",
+					got.Place, got.Tags, got.Slot, tt.wantPlace, tt.wantTags, tt.wantSlot)
+			}
+			if got.Recurrence == nil || len(got.ExDates) != 1 || !reflect.DeepEqual(got.Reminders, []int{15}) || got.WeekParity != models.WeekOdd {
+				t.Errorf("поля вне таблицы потеряны: повторение %v, исключения %v, напоминания %v, четность %q",
+					got.Recurrence, got.ExDates, got.Reminders, got.WeekParity)
+			}
+		})
+	}
+}
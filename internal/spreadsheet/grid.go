@@ -0,0 +1,540 @@
+// internal/spreadsheet/grid.go
+package spreadsheet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GridTemplate описывает, где на листе Excel находится сетка расписания:
+// строки - пары по дням недели, столбцы - учебные группы. Пример для листа,
+// где в столбце A - дни недели, в B - пары, в строке 2 - группы с C по H:
+//
+//	{
+//	  "dayColumn": "A", "slotColumn": "B", "groupRow": 2,
+//	  "firstGroupColumn": "C", "lastGroupColumn": "H",
+//	  "semesterStart": "2026-09-01", "semesterEnd": "2026-12-31",
+//	  "slots": {"1": {"start": "9:00", "end": "10:30"}, "2": {"start": "10:40", "end": "12:10"}}
+//	}
+type GridTemplate struct {
+	// Sheet - имя листа; пустое - первый лист книги
+	Sheet string `json:"sheet,omitempty"`
+
+	// DayColumn - столбец с днями недели ("Понедельник", "Пн", "Monday")
+	DayColumn string `json:"dayColumn"`
+	// SlotColumn - столбец с парами: номер пары из Slots или время "9:00-10:30"
+	SlotColumn string `json:"slotColumn"`
+	// GroupRow - строка с названиями групп
+	GroupRow int `json:"groupRow"`
+	// FirstGroupColumn и LastGroupColumn - столбцы групп; без последнего
+	// столбца группы читаются до конца строки GroupRow
+	FirstGroupColumn string `json:"firstGroupColumn"`
+	LastGroupColumn  string `json:"lastGroupColumn,omitempty"`
+	// FirstRow и LastRow - строки сетки; по умолчанию от строки после
+	// GroupRow до конца листа
+	FirstRow int `json:"firstRow,omitempty"`
+	LastRow  int `json:"lastRow,omitempty"`
+
+	// Slots - время пар по их подписям в SlotColumn; пары, которых нет
+	// в шаблоне, ищутся по номеру в расписании звонков (XLSXOptions.Bells)
+	Slots map[string]Slot `json:"slots,omitempty"`
+	// Days - дополнительные подписи дней недели: подпись -> день (MO, TU, ...)
+	Days map[string]string `json:"days,omitempty"`
+
+	// SemesterStart и SemesterEnd - первый и последний день семестра (YYYY-MM-DD):
+	// каждое занятие повторяется еженедельно в этих границах
+	SemesterStart string `json:"semesterStart"`
+	SemesterEnd   string `json:"semesterEnd"`
+	// TimeZone - часовой пояс занятий; по умолчанию - пояс импорта
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// RoomPattern и TeacherPattern - регулярные выражения для аудитории
+	// и преподавателя в тексте ячейки; первая группа захвата, если есть, -
+	// значение для тега. По умолчанию распознаются "ауд. 305" и "Иванов И.И."
+	RoomPattern    string `json:"roomPattern,omitempty"`
+	TeacherPattern string `json:"teacherPattern,omitempty"`
+	// Tags добавляются ко всем импортированным занятиям
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Slot - время пары в формате "15:04"
+type Slot struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Префиксы тегов занятий, импортированных из сетки
+const (
+	GroupTagPrefix   = "группа:"
+	TeacherTagPrefix = "преподаватель:"
+	RoomTagPrefix    = "аудитория:"
+)
+
+var (
+	defaultRoomRe    = regexp.MustCompile(`(?i)(?:ауд(?:итория)?|каб(?:инет)?|room)\.?\s*№?\s*([0-9A-Za-zА-Яа-яЁё][0-9A-Za-zА-Яа-яЁё./-]*)`)
+	defaultTeacherRe = regexp.MustCompile(`\p{Lu}\p{Ll}+(?:-\p{Lu}\p{Ll}+)?\s+\p{Lu}\.\s?(?:\p{Lu}\.)?`)
+	academicTitleRe  = regexp.MustCompile(`(?i)(?:^|\s)(?:доц|проф|асс|преп|ст\.\s?преп)\.`)
+	parityRe         = regexp.MustCompile(`(?i)(?:^|[\s(\[])(числитель|знаменатель|числ\.|знам\.)[)\]]?`)
+	slotNumberRe     = regexp.MustCompile(`(?i)^\s*(\d{1,2})\s*(?:-?\s*я)?\s*(?:пара)?\s*$`)
+	slotTimeRe       = regexp.MustCompile(`(\d{1,2})[:.](\d{2})\s*[-–—]\s*(\d{1,2})[:.](\d{2})`)
+	spacesRe         = regexp.MustCompile(`\s+`)
+)
+
+// weekdayNames - подписи дней недели, распознаваемые без настройки шаблона
+var weekdayNames = map[string]time.Weekday{
+	"понедельник": time.Monday, "пн": time.Monday, "пнд": time.Monday, "monday": time.Monday, "mon": time.Monday,
+	"вторник": time.Tuesday, "вт": time.Tuesday, "tuesday": time.Tuesday, "tue": time.Tuesday,
+	"среда": time.Wednesday, "ср": time.Wednesday, "wednesday": time.Wednesday, "wed": time.Wednesday,
+	"четверг": time.Thursday, "чт": time.Thursday, "thursday": time.Thursday, "thu": time.Thursday,
+	"пятница": time.Friday, "пт": time.Friday, "friday": time.Friday, "fri": time.Friday,
+	"суббота": time.Saturday, "сб": time.Saturday, "saturday": time.Saturday, "sat": time.Saturday,
+	"воскресенье": time.Sunday, "вс": time.Sunday, "sunday": time.Sunday, "sun": time.Sunday,
+}
+
+// weekdayCodes - дни недели в нотации RFC 5545 для GridTemplate.Days
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// XLSXOptions - параметры импорта сетки расписания из .xlsx
+type XLSXOptions struct {
+	Template GridTemplate
+	// Location - часовой пояс занятий, если он не задан в шаблоне
+	Location *time.Location
+	// Bells - расписание звонков: занятия на парах, время которых взято
+	// из него, привязываются к номеру пары (models.Event.Slot)
+	Bells *models.BellSchedule
+	// DryRun - только разобрать сетку и вернуть предпросмотр без сохранения
+	DryRun bool
+}
+
+// ImportXLSX разбирает сетку расписания из книги .xlsx по шаблону и сохраняет
+// занятия как еженедельные серии на время семестра. Одинаковые занятия
+// в одной паре у нескольких групп (поток) объединяются в одно событие с тегами
+// всех групп. Отметки "числ."/"знам." в ячейке задают четность недели занятия
+// (см. models.WeekParity), остальные занятия проходят каждую неделю семестра.
+// ID занятия определяется днем, парой и группами, поэтому
+// повторный импорт обновляет расписание, а не дублирует его. Как и ImportCSV,
+// при ошибках хотя бы в одной ячейке хранилище не изменяется
+func ImportXLSX(store storage.EventStore, r io.ReaderAt, size int64, opts XLSXOptions) (*ImportResult, error) {
+	g, err := newGrid(&opts.Template, opts.Location, opts.Bells)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := ReadXLSX(r, size, opts.Template.Sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: opts.DryRun, Events: []*models.Event{}, Errors: []RowError{}}
+	rows := g.lessons(sheet, result)
+	for _, parsed := range rows {
+		result.Events = append(result.Events, parsed.event)
+	}
+
+	commit(store, rows, result)
+	return result, nil
+}
+
+// grid - шаблон с разобранными параметрами
+type grid struct {
+	tmpl                     *GridTemplate
+	dayCol, slotCol          int
+	firstGroupCol, lastGroup int
+	slots                    map[string][2]time.Duration
+	days                     map[string]time.Weekday
+	semesterStart            time.Time
+	until                    time.Time
+	loc                      *time.Location
+	bells                    *models.BellSchedule
+	roomRe, teacherRe        *regexp.Regexp
+}
+
+// newGrid проверяет шаблон и разбирает его параметры
+func newGrid(tmpl *GridTemplate, loc *time.Location, bells *models.BellSchedule) (*grid, error) {
+	g := &grid{tmpl: tmpl, loc: loc, bells: bells, roomRe: defaultRoomRe, teacherRe: defaultTeacherRe}
+	if g.loc == nil {
+		g.loc = time.UTC
+	}
+
+	var err error
+	if tmpl.TimeZone != "" {
+		if g.loc, err = models.LoadLocation(tmpl.TimeZone); err != nil {
+			return nil, fmt.Errorf("неизвестный часовой пояс: %s", tmpl.TimeZone)
+		}
+	}
+
+	if g.dayCol, err = ColumnNumber(tmpl.DayColumn); err != nil {
+		return nil, fmt.Errorf("dayColumn: %w", err)
+	}
+	if g.slotCol, err = ColumnNumber(tmpl.SlotColumn); err != nil {
+		return nil, fmt.Errorf("slotColumn: %w", err)
+	}
+	if g.firstGroupCol, err = ColumnNumber(tmpl.FirstGroupColumn); err != nil {
+		return nil, fmt.Errorf("firstGroupColumn: %w", err)
+	}
+	if tmpl.LastGroupColumn != "" {
+		if g.lastGroup, err = ColumnNumber(tmpl.LastGroupColumn); err != nil {
+			return nil, fmt.Errorf("lastGroupColumn: %w", err)
+		}
+	}
+	if tmpl.GroupRow < 1 {
+		return nil, fmt.Errorf("не указана строка с группами (groupRow)")
+	}
+
+	g.semesterStart, err = time.ParseInLocation(models.DateLayout, tmpl.SemesterStart, g.loc)
+	if err != nil {
+		return nil, fmt.Errorf("неверное начало семестра (semesterStart): %q", tmpl.SemesterStart)
+	}
+	semesterEnd, err := time.ParseInLocation(models.DateLayout, tmpl.SemesterEnd, g.loc)
+	if err != nil || semesterEnd.Before(g.semesterStart) {
+		return nil, fmt.Errorf("неверный конец семестра (semesterEnd): %q", tmpl.SemesterEnd)
+	}
+	// Последний день семестра включается целиком
+	g.until = semesterEnd.AddDate(0, 0, 1).Add(-time.Second)
+
+	g.slots = make(map[string][2]time.Duration, len(tmpl.Slots))
+	for label, slot := range tmpl.Slots {
+		start, errStart := parseClock(slot.Start)
+		end, errEnd := parseClock(slot.End)
+		if errStart != nil || errEnd != nil || end <= start {
+			return nil, fmt.Errorf("неверное время пары %q: %s-%s", label, slot.Start, slot.End)
+		}
+		g.slots[normalizeLabel(label)] = [2]time.Duration{start, end}
+	}
+
+	g.days = make(map[string]time.Weekday, len(tmpl.Days))
+	for label, code := range tmpl.Days {
+		weekday, ok := weekdayCodes[strings.ToUpper(code)]
+		if !ok {
+			return nil, fmt.Errorf("неверный день недели %q для %q: ожидается MO, TU, ...", code, label)
+		}
+		g.days[normalizeLabel(label)] = weekday
+	}
+
+	if tmpl.RoomPattern != "" {
+		if g.roomRe, err = regexp.Compile(tmpl.RoomPattern); err != nil {
+			return nil, fmt.Errorf("неверный roomPattern: %w", err)
+		}
+	}
+	if tmpl.TeacherPattern != "" {
+		if g.teacherRe, err = regexp.Compile(tmpl.TeacherPattern); err != nil {
+			return nil, fmt.Errorf("неверный teacherPattern: %w", err)
+		}
+	}
+
+	return g, nil
+}
+
+// lesson - занятие, собранное из ячеек одной пары
+type lesson struct {
+	text    string
+	parity  models.WeekParity
+	weekday time.Weekday
+	slot    string
+	clock   [2]time.Duration
+	// bell - номер пары в расписании звонков, если время взято из него
+	bell   int
+	groups []string
+	row    int
+}
+
+// lessons собирает занятия сетки; ошибки ячеек добавляются в result
+func (g *grid) lessons(sheet *Sheet, result *ImportResult) []*row {
+	lastGroup := g.lastGroup
+	if lastGroup == 0 {
+		lastGroup = sheet.Cols
+	}
+	firstRow, lastRow := g.tmpl.FirstRow, g.tmpl.LastRow
+	if firstRow == 0 {
+		firstRow = g.tmpl.GroupRow + 1
+	}
+	if lastRow == 0 {
+		lastRow = sheet.Rows
+	}
+
+	var order []string
+	lessons := make(map[string]*lesson)
+	for r := firstRow; r <= lastRow; r++ {
+		dayLabel, slotLabel := sheet.Cell(r, g.dayCol), sheet.Cell(r, g.slotCol)
+		if strings.TrimSpace(dayLabel) == "" || strings.TrimSpace(slotLabel) == "" {
+			continue
+		}
+
+		weekday, ok := g.weekday(dayLabel)
+		if !ok {
+			result.Errors = append(result.Errors, RowError{Row: r, Column: ColumnName(g.dayCol), Message: "Неизвестный день недели: " + dayLabel})
+			continue
+		}
+		clock, bell, ok := g.slot(slotLabel)
+		if !ok {
+			result.Errors = append(result.Errors, RowError{Row: r, Column: ColumnName(g.slotCol), Message: "Неизвестное время пары: " + slotLabel})
+			continue
+		}
+
+		for c := g.firstGroupCol; c <= lastGroup; c++ {
+			text := strings.TrimSpace(sheet.Cell(r, c))
+			if text == "" {
+				continue
+			}
+			group := strings.TrimSpace(sheet.Cell(g.tmpl.GroupRow, c))
+			if group == "" {
+				group = ColumnName(c)
+			}
+
+			for _, part := range splitParity(text) {
+				key := fmt.Sprintf("%d|%s|%s|%s", weekday, normalizeLabel(slotLabel), part.parity, spacesRe.ReplaceAllString(part.text, " "))
+				l, ok := lessons[key]
+				if !ok {
+					l = &lesson{text: part.text, parity: part.parity, weekday: weekday, slot: normalizeLabel(slotLabel), clock: clock, bell: bell, row: r}
+					lessons[key] = l
+					order = append(order, key)
+				}
+				if !containsString(l.groups, group) {
+					l.groups = append(l.groups, group)
+				}
+			}
+		}
+	}
+
+	rows := make([]*row, 0, len(order))
+	for _, key := range order {
+		l := lessons[key]
+		event := g.event(l)
+		if event == nil {
+			continue
+		}
+		if err := event.Validate(); err != nil {
+			result.Errors = append(result.Errors, RowError{Row: l.row, Message: err.Error()})
+			continue
+		}
+		rows = append(rows, &row{number: l.row, event: event})
+	}
+
+	return rows
+}
+
+// event создает еженедельную серию занятия; nil, если в семестре нет такого дня недели
+func (g *grid) event(l *lesson) *models.Event {
+	first := g.semesterStart.AddDate(0, 0, (int(l.weekday)-int(g.semesterStart.Weekday())+7)%7)
+	if first.After(g.until) {
+		return nil
+	}
+
+	title, rooms, teachers := g.parseLesson(l.text)
+
+	tags := append([]string(nil), g.tmpl.Tags...)
+	for _, group := range l.groups {
+		tags = append(tags, GroupTagPrefix+group)
+	}
+	for _, teacher := range teachers {
+		tags = append(tags, TeacherTagPrefix+teacher)
+	}
+	for _, room := range rooms {
+		tags = append(tags, RoomTagPrefix+room.value)
+	}
+
+	places := make([]string, len(rooms))
+	for i, room := range rooms {
+		places[i] = room.text
+	}
+
+	event := models.NewEvent(title, atClock(first, l.clock[0]), atClock(first, l.clock[1]), tags, models.EventDetails{
+		Place: strings.Join(places, ", "),
+	})
+	event.ID = gridEventID(g.tmpl.SemesterStart, l)
+	event.TimeZone = g.loc.String()
+	event.WeekParity = l.parity
+	event.Slot = l.bell
+	event.Recurrence = &models.RecurrenceRule{Freq: models.FreqWeekly, Until: g.until}
+
+	return event
+}
+
+// gridEventID - стабильный ID занятия: день недели, пара и группы в семестре,
+// а для занятий по числителю или знаменателю - еще и четность недели
+func gridEventID(semester string, l *lesson) string {
+	key := fmt.Sprintf("%s|%d|%s|%s", semester, l.weekday, l.slot, strings.Join(l.groups, "\x00"))
+	if l.parity != models.WeekEvery {
+		key += "|" + string(l.parity)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "xlsx-" + hex.EncodeToString(sum[:16])
+}
+
+// parityPart - занятие из ячейки с его четностью недели
+type parityPart struct {
+	text   string
+	parity models.WeekParity
+}
+
+// splitParity делит текст ячейки на занятия по отметкам "числитель"/"знаменатель"
+// ("числ.", "(знам.)"): строка с отметкой начинает новое занятие, предшествующие
+// строки относятся к первому. Ячейка без отметок - занятие каждую неделю
+func splitParity(text string) []parityPart {
+	var parts []parityPart
+	current := parityPart{parity: models.WeekEvery}
+	for _, line := range strings.Split(text, "\n") {
+		m := parityRe.FindStringSubmatch(line)
+		if m == nil {
+			current.text += line + "\n"
+			continue
+		}
+
+		parity, _ := models.ParseWeekParity(m[1])
+		if current.parity != models.WeekEvery || strings.TrimSpace(current.text) != "" {
+			parts = append(parts, current)
+		}
+		current = parityPart{text: parityRe.ReplaceAllString(line, " ") + "\n", parity: parity}
+	}
+	parts = append(parts, current)
+
+	// Занятие без отметки перед занятием по числителю или знаменателю
+	// проходит по неделям противоположной четности
+	if len(parts) > 1 && parts[0].parity == models.WeekEvery {
+		switch parts[1].parity {
+		case models.WeekOdd:
+			parts[0].parity = models.WeekEven
+		case models.WeekEven:
+			parts[0].parity = models.WeekOdd
+		}
+	}
+
+	result := parts[:0]
+	for _, part := range parts {
+		part.text = strings.TrimSpace(part.text)
+		if part.text != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// match - найденное в тексте ячейки значение: text - фрагмент целиком,
+// value - значение для тега
+type match struct {
+	text, value string
+}
+
+// parseLesson выделяет из текста ячейки название занятия, аудитории
+// и преподавателей
+func (g *grid) parseLesson(text string) (string, []match, []string) {
+	rest := text
+	find := func(re *regexp.Regexp) []match {
+		var found []match
+		for _, m := range re.FindAllStringSubmatch(rest, -1) {
+			value := m[0]
+			if len(m) > 1 && m[1] != "" {
+				value = m[1]
+			}
+			found = append(found, match{text: strings.TrimSpace(m[0]), value: strings.TrimSpace(value)})
+		}
+		rest = re.ReplaceAllString(rest, " ")
+		return found
+	}
+
+	rooms := find(g.roomRe)
+	var teachers []string
+	for _, m := range find(g.teacherRe) {
+		teachers = append(teachers, spacesRe.ReplaceAllString(m.value, " "))
+	}
+	rest = academicTitleRe.ReplaceAllString(rest, " ")
+
+	title := strings.Trim(spacesRe.ReplaceAllString(rest, " "), " ,;-–")
+	if title == "" {
+		title = strings.TrimSpace(spacesRe.ReplaceAllString(text, " "))
+	}
+
+	return title, rooms, teachers
+}
+
+// weekday распознает подпись дня недели: по словарю шаблона, затем по
+// встроенным названиям (учитывается первое слово: "Понедельник 07.09")
+func (g *grid) weekday(label string) (time.Weekday, bool) {
+	label = normalizeLabel(label)
+	if weekday, ok := g.days[label]; ok {
+		return weekday, true
+	}
+
+	word := strings.TrimRight(strings.Fields(label)[0], ".,")
+	weekday, ok := weekdayNames[word]
+	return weekday, ok
+}
+
+// slot возвращает время пары по подписи: из шаблона, из самой подписи
+// ("9:00-10:30", "1 пара 9.00–10.30") или по номеру пары ("1", "1 пара")
+// из расписания звонков. Второе значение - номер пары, если время взято
+// из расписания звонков
+func (g *grid) slot(label string) ([2]time.Duration, int, bool) {
+	if clock, ok := g.slots[normalizeLabel(label)]; ok {
+		return clock, 0, true
+	}
+
+	if m := slotTimeRe.FindStringSubmatch(label); m != nil {
+		start, errStart := parseClock(m[1] + ":" + m[2])
+		end, errEnd := parseClock(m[3] + ":" + m[4])
+		if errStart != nil || errEnd != nil || end <= start {
+			return [2]time.Duration{}, 0, false
+		}
+		return [2]time.Duration{start, end}, 0, true
+	}
+
+	if g.bells == nil {
+		return [2]time.Duration{}, 0, false
+	}
+	m := slotNumberRe.FindStringSubmatch(label)
+	if m == nil {
+		return [2]time.Duration{}, 0, false
+	}
+	number, _ := strconv.Atoi(m[1])
+	bell, ok := g.bells.Slot(number)
+	if !ok {
+		return [2]time.Duration{}, 0, false
+	}
+	start, errStart := parseClock(bell.Start)
+	end, errEnd := parseClock(bell.End)
+	if errStart != nil || errEnd != nil {
+		return [2]time.Duration{}, 0, false
+	}
+	return [2]time.Duration{start, end}, number, true
+}
+
+// parseClock разбирает время суток "9:00" в смещение от полуночи
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// atClock возвращает время суток clock в день day по местным часам
+func atClock(day time.Time, clock time.Duration) time.Time {
+	minutes := int(clock / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// normalizeLabel приводит подпись к нижнему регистру без лишних пробелов
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(spacesRe.ReplaceAllString(label, " ")))
+}
+
+// containsString проверяет наличие строки в списке
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
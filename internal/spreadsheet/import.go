@@ -0,0 +1,123 @@
+// internal/spreadsheet/import.go
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"schedule-app/internal/models"
+	"schedule-app/internal/storage"
+	"strings"
+	"time"
+)
+
+// RowError - ошибка в строке импортируемой таблицы
+type RowError struct {
+	// Row - номер строки файла (заголовок - строка 1)
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult - итог импорта или предпросмотра таблицы
+type ImportResult struct {
+	DryRun    bool `json:"dryRun"`
+	Created   int  `json:"created"`
+	Updated   int  `json:"updated"`
+	Unchanged int  `json:"unchanged"`
+	// Events - события, разобранные из таблицы (для предпросмотра)
+	Events []*models.Event `json:"events"`
+	Errors []RowError      `json:"errors"`
+}
+
+// row - разобранная строка таблицы
+type row struct {
+	number int
+	event  *models.Event
+	// seriesID и originalStart заполнены, если ID строки - ID экземпляра серии
+	seriesID      string
+	originalStart time.Time
+	// update переносит значения строки в существующее событие с тем же ID;
+	// nil - событие заменяется целиком
+	update func(existing *models.Event)
+}
+
+// commit сохраняет разобранные строки таблицы. Импорт выполняется целиком
+// или не выполняется вовсе: при ошибках разбора хотя бы в одной строке,
+// как и при предпросмотре, хранилище не изменяется
+func commit(store storage.EventStore, rows []*row, result *ImportResult) {
+	if result.DryRun || len(result.Errors) > 0 {
+		return
+	}
+
+	for _, parsed := range rows {
+		if err := saveRow(store, parsed, result); err != nil {
+			result.Errors = append(result.Errors, RowError{Row: parsed.number, Message: err.Error()})
+		}
+	}
+}
+
+// errUnchanged прерывает Modify, если строка не изменяет экземпляр серии
+var errUnchanged = errors.New("экземпляр не изменен")
+
+// saveRow сохраняет событие строки и обновляет счетчики результата
+func saveRow(store storage.EventStore, parsed *row, result *ImportResult) error {
+	if parsed.seriesID == "" {
+		var created bool
+		var err error
+		if parsed.update == nil {
+			created, err = storage.Upsert(store, parsed.event)
+		} else {
+			created, err = store.Upsert(parsed.event, func(existing *models.Event) error {
+				parsed.update(existing)
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		return nil
+	}
+
+	_, err := store.Modify(parsed.seriesID, func(series *models.Event) error {
+		current, ok := series.OccurrenceAt(parsed.originalStart)
+		if !ok {
+			return fmt.Errorf("экземпляр серии %s не найден", parsed.event.ID)
+		}
+		if sameOccurrence(current, parsed.event) {
+			return errUnchanged
+		}
+
+		override := parsed.event.Clone()
+		override.TimeZone = series.TimeZone
+		override.WeekParity = series.WeekParity
+		override.CreatedAt = series.CreatedAt
+		override.OriginalStartTime = &parsed.originalStart
+		series.SetOverride(override)
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errUnchanged):
+		result.Unchanged++
+	case err != nil:
+		return err
+	default:
+		result.Updated++
+	}
+	return nil
+}
+
+// sameOccurrence сообщает, что строка таблицы не изменяет экземпляр серии
+func sameOccurrence(current, imported *models.Event) bool {
+	return current.Title == imported.Title &&
+		current.StartTime.Equal(imported.StartTime) &&
+		current.EndTime.Equal(imported.EndTime) &&
+		current.AllDay == imported.AllDay &&
+		strings.Join(current.Tags, "\x00") == strings.Join(imported.Tags, "\x00") &&
+		current.Details() == imported.Details()
+}
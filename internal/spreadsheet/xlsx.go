@@ -0,0 +1,391 @@
+// internal/spreadsheet/xlsx.go
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ограничения листа .xlsx: наибольшие номера строки и столбца (XFD1048576)
+// по спецификации формата
+const (
+	maxSheetRows = 1048576
+	maxSheetCols = 16384
+)
+
+// Ограничения разбора, защищающие сервер от специально собранных книг:
+// размер распакованной части архива, площадь листа с данными (Rows * Cols)
+// и число строк, которые в сумме занимают объединения
+const (
+	maxPartSize   = 64 << 20
+	maxSheetCells = 4 << 20
+	maxMergedRows = 1 << 20
+)
+
+// Sheet - лист книги Excel: значения ячеек в виде текста. Значение
+// объединенной ячейки возвращается для всех ячеек объединения, поэтому
+// подпись дня, объединенная на несколько пар, видна в каждой строке
+type Sheet struct {
+	Name  string
+	cells map[cellRef]string
+	// merges - объединения с непустым значением по строкам листа,
+	// упорядоченные по первому столбцу
+	merges map[int][]cellRange
+	// Rows и Cols - число строк и столбцов с данными
+	Rows, Cols int
+}
+
+// cellRef - адрес ячейки: номера строки и столбца, начиная с 1
+type cellRef struct {
+	row, col int
+}
+
+// cellRange - объединение ячеек: левая верхняя и правая нижняя ячейки
+type cellRange struct {
+	start, end cellRef
+}
+
+// Cell возвращает текст ячейки по номерам строки и столбца, начиная с 1;
+// для ячейки внутри объединения - значение его левой верхней ячейки
+func (s *Sheet) Cell(row, col int) string {
+	if value, ok := s.cells[cellRef{row, col}]; ok {
+		return value
+	}
+
+	// Объединения на одной строке не пересекаются: подходит только
+	// последнее, начинающееся не правее col
+	merges := s.merges[row]
+	i := sort.Search(len(merges), func(i int) bool { return merges[i].start.col > col }) - 1
+	if i >= 0 && merges[i].end.col >= col {
+		return s.cells[merges[i].start]
+	}
+	return ""
+}
+
+// ReadXLSX читает лист книги .xlsx: лист с именем sheetName или первый лист,
+// если имя пустое. Разбираются только значения ячеек (общие строки, строки
+// в ячейке, числа и логические значения) и объединения ячеек; форматирование
+// и формулы игнорируются (используется сохраненное значение формулы)
+func ReadXLSX(r io.ReaderAt, size int64, sheetName string) (*Sheet, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("файл не является книгой .xlsx: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	name, sheetPath, err := findSheet(files, sheetName)
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("в книге нет листа %s", sheetPath)
+	}
+	var ws xlsxWorksheet
+	if err := decodeXML(f, &ws); err != nil {
+		return nil, err
+	}
+
+	sheet := &Sheet{Name: name, cells: make(map[cellRef]string), merges: make(map[int][]cellRange)}
+	for i, row := range ws.Rows {
+		rowNum := row.Num
+		if rowNum == 0 {
+			rowNum = i + 1
+		}
+		for j, c := range row.Cells {
+			ref := cellRef{row: rowNum, col: j + 1}
+			if c.Ref != "" {
+				if ref, err = parseCellRef(c.Ref); err != nil {
+					return nil, err
+				}
+			} else if ref.row > maxSheetRows || ref.col > maxSheetCols {
+				return nil, fmt.Errorf("неверный номер строки %d", row.Num)
+			}
+			value, err := c.value(shared)
+			if err != nil {
+				return nil, fmt.Errorf("ячейка %s: %w", c.Ref, err)
+			}
+			sheet.set(ref, value)
+		}
+	}
+
+	if sheet.Rows*sheet.Cols > maxSheetCells {
+		return nil, fmt.Errorf("лист слишком большой: %d строк и %d столбцов с данными", sheet.Rows, sheet.Cols)
+	}
+
+	// Значение объединенной ячейки хранится только в левой верхней; строки
+	// объединения за пределами данных листа не нужны
+	mergedRows := 0
+	for _, merge := range ws.MergeCells {
+		from, to, ok := strings.Cut(merge.Ref, ":")
+		if !ok {
+			continue
+		}
+		start, err := parseCellRef(from)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseCellRef(to)
+		if err != nil {
+			return nil, err
+		}
+		if sheet.cells[start] == "" || end.row < start.row || end.col < start.col {
+			continue
+		}
+
+		last := min(end.row, sheet.Rows)
+		if mergedRows += last - start.row + 1; mergedRows > maxMergedRows {
+			return nil, fmt.Errorf("слишком много объединенных ячеек")
+		}
+		for row := start.row; row <= last; row++ {
+			sheet.merges[row] = append(sheet.merges[row], cellRange{start: start, end: end})
+		}
+	}
+	for _, merges := range sheet.merges {
+		sort.Slice(merges, func(i, j int) bool {
+			return merges[i].start.col < merges[j].start.col
+		})
+	}
+
+	return sheet, nil
+}
+
+// set сохраняет непустое значение ячейки и расширяет размеры листа
+func (s *Sheet) set(ref cellRef, value string) {
+	if value == "" {
+		return
+	}
+	s.cells[ref] = value
+	s.Rows = max(s.Rows, ref.row)
+	s.Cols = max(s.Cols, ref.col)
+}
+
+// findSheet находит путь к XML листа по его имени через workbook.xml
+// и связи книги
+func findSheet(files map[string]*zip.File, name string) (string, string, error) {
+	var wb xlsxWorkbook
+	f, ok := files["xl/workbook.xml"]
+	if !ok {
+		return "", "", fmt.Errorf("в книге нет xl/workbook.xml")
+	}
+	if err := decodeXML(f, &wb); err != nil {
+		return "", "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", "", fmt.Errorf("в книге нет листов")
+	}
+
+	sheet := wb.Sheets[0]
+	if name != "" {
+		found := false
+		for _, s := range wb.Sheets {
+			if strings.EqualFold(s.Name, name) {
+				sheet, found = s, true
+				break
+			}
+		}
+		if !found {
+			return "", "", fmt.Errorf("лист %q не найден", name)
+		}
+	}
+
+	var rels xlsxRelationships
+	if f, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		if err := decodeXML(f, &rels); err != nil {
+			return "", "", err
+		}
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != sheet.RelID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return sheet.Name, strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return sheet.Name, path.Join("xl", rel.Target), nil
+	}
+
+	// Без связей книги используется стандартное расположение листов
+	return sheet.Name, "xl/worksheets/sheet" + sheet.SheetID + ".xml", nil
+}
+
+// readSharedStrings читает таблицу общих строк книги
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+	if err := decodeXML(f, &sst); err != nil {
+		return nil, err
+	}
+
+	shared := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		shared[i] = item.String()
+	}
+	return shared, nil
+}
+
+// decodeXML разбирает XML-файл из архива книги. Распакованный файл
+// читается не дальше maxPartSize байт, чтобы небольшой архив не мог
+// развернуться в гигабайты
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	limited := &io.LimitedReader{R: rc, N: maxPartSize + 1}
+	if err := xml.NewDecoder(limited).Decode(v); err != nil {
+		if limited.N <= 0 {
+			return fmt.Errorf("файл %s в книге больше %d МБ", f.Name, maxPartSize>>20)
+		}
+		return fmt.Errorf("ошибка при разборе %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// parseCellRef разбирает адрес ячейки вида "B12"
+func parseCellRef(ref string) (cellRef, error) {
+	i := strings.IndexFunc(ref, func(r rune) bool { return r >= '0' && r <= '9' })
+	if i <= 0 {
+		return cellRef{}, fmt.Errorf("неверный адрес ячейки: %s", ref)
+	}
+
+	col, err := ColumnNumber(ref[:i])
+	if err != nil {
+		return cellRef{}, err
+	}
+	row, err := strconv.Atoi(ref[i:])
+	if err != nil || row < 1 || row > maxSheetRows || col > maxSheetCols {
+		return cellRef{}, fmt.Errorf("неверный адрес ячейки: %s", ref)
+	}
+
+	return cellRef{row: row, col: col}, nil
+}
+
+// ColumnNumber переводит буквенное обозначение столбца ("A", "AB") в номер, начиная с 1
+func ColumnNumber(letters string) (int, error) {
+	letters = strings.ToUpper(strings.TrimSpace(letters))
+	if letters == "" || len(letters) > 3 {
+		return 0, fmt.Errorf("неверный столбец: %q", letters)
+	}
+
+	n := 0
+	for _, r := range letters {
+		if r < 'A' || r > 'Z' {
+			return 0, fmt.Errorf("неверный столбец: %q", letters)
+		}
+		n = n*26 + int(r-'A') + 1
+	}
+	return n, nil
+}
+
+// ColumnName переводит номер столбца, начиная с 1, в буквенное обозначение
+func ColumnName(n int) string {
+	var letters []byte
+	for ; n > 0; n = (n - 1) / 26 {
+		letters = append([]byte{byte('A' + (n-1)%26)}, letters...)
+	}
+	return string(letters)
+}
+
+// XML-структуры книги .xlsx (SpreadsheetML, ECMA-376), нужные для чтения значений
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name    string `xml:"name,attr"`
+		SheetID string `xml:"sheetId,attr"`
+		RelID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Num   int        `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+	MergeCells []struct {
+		Ref string `xml:"ref,attr"`
+	} `xml:"mergeCells>mergeCell"`
+}
+
+type xlsxCell struct {
+	Ref    string        `xml:"r,attr"`
+	Type   string        `xml:"t,attr"`
+	Value  string        `xml:"v"`
+	Inline *xlsxRichText `xml:"is"`
+}
+
+// value возвращает текст ячейки с учетом ее типа
+func (c *xlsxCell) value(shared []string) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(c.Value))
+		if err != nil || i < 0 || i >= len(shared) {
+			return "", fmt.Errorf("неверная ссылка на общую строку: %s", c.Value)
+		}
+		return shared[i], nil
+	case "inlineStr":
+		if c.Inline == nil {
+			return "", nil
+		}
+		return c.Inline.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	default:
+		// Числа, строки формул (str), ошибки (e)
+		return c.Value, nil
+	}
+}
+
+// xlsxRichText - текст общей строки или строки в ячейке: целиком в <t>
+// или фрагментами форматирования <r><t>
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String собирает текст из всех фрагментов
+func (t *xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+
+	var b strings.Builder
+	b.WriteString(t.Text)
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
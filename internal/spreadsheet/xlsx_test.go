@@ -0,0 +1,98 @@
+// internal/spreadsheet/xlsx_test.go
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// buildXLSX собирает минимальную книгу с одним листом из XML строк
+// и объединений
+func buildXLSX(t *testing.T, rows, merges string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook><sheets><sheet name="Расписание" sheetId="1"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + rows + `</sheetData>` +
+			`<mergeCells>` + merges + `</mergeCells></worksheet>`,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadXLSXMerges(t *testing.T) {
+	rows := `<row r="1"><c r="A1" t="inlineStr"><is><t>Понедельник</t></is></c><c r="B1" t="inlineStr"><is><t>1</t></is></c></row>` +
+		`<row r="2"><c r="B2" t="inlineStr"><is><t>2</t></is></c><c r="C2" t="inlineStr"><is><t>Физика</t></is></c></row>` +
+		`<row r="3"><c r="B3" t="inlineStr"><is><t>3</t></is></c></row>`
+	// Объединение до последней строки листа не разворачивается в ячейки
+	r := buildXLSX(t, rows, `<mergeCell ref="A1:A1048576"/><mergeCell ref="C2:D3"/>`)
+
+	sheet, err := ReadXLSX(r, r.Size(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sheet.Rows != 3 || sheet.Cols != 3 {
+		t.Errorf("размер листа %dx%d, ожидалось 3x3", sheet.Rows, sheet.Cols)
+	}
+
+	tests := []struct {
+		row, col int
+		want     string
+	}{
+		{1, 1, "Понедельник"},
+		{3, 1, "Понедельник"},
+		{2, 2, "2"},
+		{2, 3, "Физика"},
+		{3, 4, "Физика"},
+		{1, 3, ""},
+		{2, 5, ""},
+	}
+	for _, tt := range tests {
+		if got := sheet.Cell(tt.row, tt.col); got != tt.want {
+			t.Errorf("Cell(%d, %d) = %q, ожидалось %q", tt.row, tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestReadXLSXLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   string
+		merges string
+	}{
+		{"row out of range", `<row r="1"><c r="A1048577" t="inlineStr"><is><t>x</t></is></c></row>`, ""},
+		{"column out of range", `<row r="1"><c r="XFE1" t="inlineStr"><is><t>x</t></is></c></row>`, ""},
+		{"row number out of range", `<row r="2000000"><c t="inlineStr"><is><t>x</t></is></c></row>`, ""},
+		{"merge out of range", `<row r="1"><c r="A1" t="inlineStr"><is><t>x</t></is></c></row>`, `<mergeCell ref="A1:XFE1"/>`},
+		{"sheet too large", `<row r="1"><c r="A1" t="inlineStr"><is><t>x</t></is></c></row>` +
+			`<row r="1000"><c r="XFD1000" t="inlineStr"><is><t>x</t></is></c></row>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := buildXLSX(t, tt.rows, tt.merges)
+			if _, err := ReadXLSX(r, r.Size(), ""); err == nil {
+				t.Error("ReadXLSX: ожидалась ошибка")
+			}
+		})
+	}
+
+	// Распакованный лист больше ограничения отклоняется, даже если архив мал
+	r := buildXLSX(t, `<row r="1"><c r="A1" t="inlineStr"><is><t>`+strings.Repeat("x", maxPartSize)+`</t></is></c></row>`, "")
+	if _, err := ReadXLSX(r, r.Size(), ""); err == nil || !strings.Contains(err.Error(), "МБ") {
+		t.Errorf("ReadXLSX: ошибка %v, ожидалась ошибка о размере файла", err)
+	}
+}
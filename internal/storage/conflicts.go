@@ -0,0 +1,91 @@
+// internal/storage/conflicts.go
+package storage
+
+import (
+	"schedule-app/internal/models"
+	"sort"
+	"time"
+)
+
+// ConflictWindow возвращает окно [from, to), в котором хранимые события могут
+// пересекаться с экземплярами события event (см. Conflicts). Пустое окно
+// означает, что проверять нечего. Окно передается в CreateChecked,
+// ModifyChecked и Split, чтобы проверка и запись выполнялись атомарно
+func ConflictWindow(event *models.Event, exclusiveTags []string, semester *models.Semester) (time.Time, time.Time) {
+	candidates := conflictCandidates(event, exclusiveTags, semester)
+	if len(candidates) == 0 {
+		return time.Time{}, time.Time{}
+	}
+
+	from, to := candidates[0].StartTime, candidates[0].EndTime
+	for _, candidate := range candidates[1:] {
+		if candidate.StartTime.Before(from) {
+			from = candidate.StartTime
+		}
+		if candidate.EndTime.After(to) {
+			to = candidate.EndTime
+		}
+	}
+	return from, to
+}
+
+// Conflicts ищет двойное бронирование: экземпляры события event, которые
+// пересекаются с событиями existing (окна ConflictWindow) по времени и общим
+// ресурсам или исключительным тегам exclusiveTags (см. models.Event.Clashes).
+// Серия проверяется в окне RecurrenceLookbehind/RecurrenceLookahead, с учетом
+// семестра semester. События с ID из ignore и экземпляры таких серий
+// не проверяются: так изменяемое событие не конфликтует само с собой
+func Conflicts(event *models.Event, existing []*models.Event, exclusiveTags []string, semester *models.Semester, ignore ...string) []models.Conflict {
+	candidates := conflictCandidates(event, exclusiveTags, semester)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	skip := make(map[string]bool, len(ignore))
+	for _, id := range ignore {
+		skip[id] = true
+	}
+
+	var conflicts []models.Conflict
+	for _, other := range existing {
+		if skip[other.ID] || skip[other.RecurringEventID] || !other.Exclusive(exclusiveTags) {
+			continue
+		}
+		for _, candidate := range candidates {
+			resources, tags := candidate.Clashes(other, exclusiveTags)
+			if len(resources) > 0 || len(tags) > 0 {
+				conflicts = append(conflicts, models.Conflict{
+					Event:     candidate,
+					With:      other,
+					Resources: resources,
+					Tags:      tags,
+				})
+			}
+		}
+	}
+
+	sortConflicts(conflicts)
+	return conflicts
+}
+
+// conflictCandidates возвращает экземпляры события event, которые
+// проверяются на пересечения; nil, если событие может пересекаться с любыми
+func conflictCandidates(event *models.Event, exclusiveTags []string, semester *models.Semester) []*models.Event {
+	if !event.Exclusive(exclusiveTags) {
+		return nil
+	}
+
+	candidates := []*models.Event{event}
+	if event.IsRecurring() {
+		from, to := expansionWindow()
+		candidates = event.Occurrences(from, to)
+	}
+	return filterSemester(candidates, semester)
+}
+
+// sortConflicts упорядочивает конфликты по времени начала экземпляров
+func sortConflicts(conflicts []models.Conflict) {
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].Event.StartTime.Before(conflicts[j].Event.StartTime)
+	})
+}
@@ -0,0 +1,215 @@
+// internal/storage/index.go
+package storage
+
+import (
+	"math/rand/v2"
+	"schedule-app/internal/models"
+	"time"
+)
+
+// farFuture - условный конец бесконечной серии
+var farFuture = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// intervalKey - интервал, занимаемый событием в индексе
+type intervalKey struct {
+	start time.Time
+	end   time.Time
+	id    string
+}
+
+func (k intervalKey) less(other intervalKey) bool {
+	if !k.start.Equal(other.start) {
+		return k.start.Before(other.start)
+	}
+	return k.id < other.id
+}
+
+// intervalNode - узел декартова дерева (treap), упорядоченного по началу
+// интервала; maxEnd хранит наибольший конец интервала в поддереве
+type intervalNode struct {
+	key         intervalKey
+	event       *models.Event
+	priority    uint32
+	maxEnd      time.Time
+	left, right *intervalNode
+}
+
+// eventIndex - интервальное дерево событий для запросов по диапазону времени.
+// Серия занимает в индексе весь свой период (см. eventSpan) и разворачивается
+// в экземпляры только при попадании в запрошенное окно
+type eventIndex struct {
+	root *intervalNode
+	keys map[string]intervalKey
+}
+
+func newEventIndex() *eventIndex {
+	return &eventIndex{keys: make(map[string]intervalKey)}
+}
+
+// put добавляет событие в индекс или обновляет его интервал
+func (x *eventIndex) put(event *models.Event) {
+	x.remove(event.ID)
+
+	start, end := eventSpan(event)
+	key := intervalKey{start: start, end: end, id: event.ID}
+	x.keys[event.ID] = key
+	x.root = insertNode(x.root, &intervalNode{
+		key:      key,
+		event:    event,
+		priority: rand.Uint32(),
+		maxEnd:   end,
+	})
+}
+
+// remove удаляет событие из индекса
+func (x *eventIndex) remove(id string) {
+	key, exists := x.keys[id]
+	if !exists {
+		return
+	}
+
+	delete(x.keys, id)
+	x.root = deleteNode(x.root, key)
+}
+
+// overlapping возвращает события, чей интервал может пересекаться с окном [from, to).
+// Точную проверку выполняет Event.Occurrences
+func (x *eventIndex) overlapping(from, to time.Time) []*models.Event {
+	var events []*models.Event
+	collectOverlapping(x.root, from, to, &events)
+	return events
+}
+
+func collectOverlapping(n *intervalNode, from, to time.Time, events *[]*models.Event) {
+	// В поддереве нет интервалов, заканчивающихся в окне или позже
+	if n == nil || n.maxEnd.Before(from) {
+		return
+	}
+
+	collectOverlapping(n.left, from, to, events)
+
+	// Правее только интервалы, начинающиеся не раньше текущего
+	if !n.key.start.Before(to) {
+		return
+	}
+
+	if !n.key.end.Before(from) {
+		*events = append(*events, n.event)
+	}
+
+	collectOverlapping(n.right, from, to, events)
+}
+
+func insertNode(n, node *intervalNode) *intervalNode {
+	if n == nil {
+		return node
+	}
+
+	if node.key.less(n.key) {
+		n.left = insertNode(n.left, node)
+		if n.left.priority > n.priority {
+			n = rotateRight(n)
+		}
+	} else {
+		n.right = insertNode(n.right, node)
+		if n.right.priority > n.priority {
+			n = rotateLeft(n)
+		}
+	}
+
+	n.update()
+	return n
+}
+
+func deleteNode(n *intervalNode, key intervalKey) *intervalNode {
+	if n == nil {
+		return nil
+	}
+
+	switch {
+	case key.id == n.key.id:
+		return mergeNodes(n.left, n.right)
+	case key.less(n.key):
+		n.left = deleteNode(n.left, key)
+	default:
+		n.right = deleteNode(n.right, key)
+	}
+
+	n.update()
+	return n
+}
+
+// mergeNodes объединяет два поддерева, все ключи левого меньше ключей правого
+func mergeNodes(left, right *intervalNode) *intervalNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+
+	if left.priority > right.priority {
+		left.right = mergeNodes(left.right, right)
+		left.update()
+		return left
+	}
+
+	right.left = mergeNodes(left, right.left)
+	right.update()
+	return right
+}
+
+func rotateRight(n *intervalNode) *intervalNode {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+func rotateLeft(n *intervalNode) *intervalNode {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+// update пересчитывает maxEnd узла по его детям
+func (n *intervalNode) update() {
+	n.maxEnd = n.key.end
+	if n.left != nil && n.left.maxEnd.After(n.maxEnd) {
+		n.maxEnd = n.left.maxEnd
+	}
+	if n.right != nil && n.right.maxEnd.After(n.maxEnd) {
+		n.maxEnd = n.right.maxEnd
+	}
+}
+
+// eventSpan возвращает интервал, в пределах которого лежат все экземпляры
+// события. Для серии без UNTIL конец интервала - farFuture
+func eventSpan(event *models.Event) (time.Time, time.Time) {
+	start, end := event.StartTime, event.EndTime
+	if !event.IsRecurring() {
+		return start, end
+	}
+
+	end = farFuture
+	if !event.Recurrence.Until.IsZero() {
+		end = event.Recurrence.Until.Add(event.EndTime.Sub(event.StartTime))
+	}
+
+	// Измененный экземпляр мог быть перенесен за пределы правила
+	for _, override := range event.Overrides {
+		if override.StartTime.Before(start) {
+			start = override.StartTime
+		}
+		if override.EndTime.After(end) {
+			end = override.EndTime
+		}
+	}
+
+	return start, end
+}
@@ -0,0 +1,138 @@
+// internal/storage/index_test.go
+package storage
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"schedule-app/internal/models"
+	"slices"
+	"testing"
+	"time"
+)
+
+// indexEpoch - начало периода, в котором тесты индекса создают события
+var indexEpoch = time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+
+// randomEvent создает событие со случайным началом в пределах 60 дней от
+// indexEpoch; примерно каждое четвертое событие - серия
+func randomEvent(rng *rand.Rand, id string) *models.Event {
+	start := indexEpoch.Add(time.Duration(rng.IntN(60*24*4)) * 15 * time.Minute)
+	end := start.Add(time.Duration(rng.IntN(16)) * 15 * time.Minute)
+	event := models.NewEvent("Событие "+id, start, end, nil, models.EventDetails{})
+	event.ID = id
+
+	switch rng.IntN(8) {
+	case 0:
+		event.Recurrence = &models.RecurrenceRule{Freq: models.FreqDaily, Count: 1 + rng.IntN(10)}
+	case 1:
+		event.Recurrence = &models.RecurrenceRule{Freq: models.FreqWeekly, Until: start.AddDate(0, 0, 7*rng.IntN(6))}
+	}
+	return event
+}
+
+// randomWindow возвращает случайное окно длиной до двух недель вокруг периода событий
+func randomWindow(rng *rand.Rand) (time.Time, time.Time) {
+	from := indexEpoch.Add(time.Duration(rng.IntN(100*24)-20*24) * time.Hour)
+	return from, from.Add(time.Duration(rng.IntN(14*24*4)) * 15 * time.Minute)
+}
+
+func TestEventIndexOverlapping(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	index := newEventIndex()
+	live := make(map[string]*models.Event)
+
+	for step := 0; step < 3000; step++ {
+		id := fmt.Sprintf("e%d", rng.IntN(300))
+		if rng.IntN(4) == 0 {
+			index.remove(id)
+			delete(live, id)
+		} else {
+			event := randomEvent(rng, id)
+			index.put(event)
+			live[id] = event
+		}
+
+		if step%10 != 0 {
+			continue
+		}
+		from, to := randomWindow(rng)
+
+		var got []string
+		for _, event := range index.overlapping(from, to) {
+			got = append(got, event.ID)
+		}
+		var want []string
+		for id, event := range live {
+			start, end := eventSpan(event)
+			if start.Before(to) && !end.Before(from) {
+				want = append(want, id)
+			}
+		}
+		slices.Sort(got)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Fatalf("шаг %d, окно [%s, %s): индекс вернул %v, ожидалось %v", step, from, to, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreRange(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	store := NewMemoryStore()
+
+	for step := 0; step < 1000; step++ {
+		id := fmt.Sprintf("e%d", rng.IntN(100))
+		current, err := store.Get(id)
+		switch {
+		case err != nil:
+			if err := store.Create(randomEvent(rng, id)); err != nil {
+				t.Fatal(err)
+			}
+		case rng.IntN(3) == 0:
+			if err := store.Delete(id); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			event := randomEvent(rng, id)
+			event.Version = current.Version
+			if err := store.Update(event); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if step%10 != 0 {
+			continue
+		}
+		from, to := randomWindow(rng)
+
+		events, err := store.Range(from, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for i, event := range events {
+			got = append(got, event.ID)
+			if i > 0 && event.StartTime.Before(events[i-1].StartTime) {
+				t.Fatalf("шаг %d: события не упорядочены по началу", step)
+			}
+		}
+
+		// Без индекса: каждое хранимое событие разворачивается в окне
+		all, err := store.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for _, event := range all {
+			for _, occurrence := range event.Occurrences(from, to) {
+				want = append(want, occurrence.ID)
+			}
+		}
+
+		slices.Sort(got)
+		slices.Sort(want)
+		if !slices.Equal(got, want) {
+			t.Fatalf("шаг %d, окно [%s, %s): Range вернул %v, ожидалось %v", step, from, to, got, want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// internal/storage/journal.go
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"schedule-app/internal/models"
+	"time"
+)
+
+// Операции журнала изменений
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+	// opBatch объединяет изменения одной операции в одну строку журнала:
+	// при сбое запись отбрасывается целиком
+	opBatch = "batch"
+)
+
+// journalEntry - одна строка журнала изменений
+type journalEntry struct {
+	Time  time.Time     `json:"time"`
+	Op    string        `json:"op"`
+	ID    string        `json:"id"`
+	Event *models.Event `json:"event,omitempty"`
+	// Batch - изменения записи opBatch
+	Batch []journalEntry `json:"batch,omitempty"`
+}
+
+// journal - файл упреждающей записи: каждое изменение дописывается
+// отдельной JSON-строкой и сбрасывается на диск до ответа клиенту
+type journal struct {
+	path    string
+	file    *os.File
+	entries int
+	// size - длина файла журнала в байтах
+	size int64
+	// broken - ошибка, после которой не удалось убрать из файла неудачную
+	// запись: дозапись запрещена, пока журнал не будет переписан
+	// (reset, dropPrefix), иначе после нее в файл попадут целые записи
+	broken error
+}
+
+// openJournal открывает журнал для дозаписи, создавая его при необходимости
+func openJournal(path string) (*journal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть журнал: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("не удалось открыть журнал: %w", err)
+	}
+	return &journal{path: path, file: file, size: info.Size()}, nil
+}
+
+// append дописывает запись в журнал и вызывает fsync. Если запись или
+// fsync не удались, файл обрезается до прежнего размера: изменение
+// отменяется в памяти и не должно примениться при восстановлении
+func (j *journal) append(entry journalEntry) error {
+	if j.broken != nil {
+		return fmt.Errorf("журнал недоступен для записи: %w", j.broken)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации записи журнала: %w", err)
+	}
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		j.rollback()
+		return fmt.Errorf("ошибка при записи в журнал: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		j.rollback()
+		return fmt.Errorf("ошибка при сбросе журнала на диск: %w", err)
+	}
+
+	j.size += int64(len(data)) + 1
+	j.entries++
+	return nil
+}
+
+// rollback убирает из файла неудачную запись, обрезая его до j.size.
+// Если это не удалось, журнал помечается неисправным
+func (j *journal) rollback() {
+	if err := j.file.Truncate(j.size); err != nil {
+		j.broken = err
+		return
+	}
+	if err := j.file.Sync(); err != nil {
+		j.broken = err
+	}
+}
+
+// reset очищает журнал после того, как его содержимое попало в снимок
+func (j *journal) reset() error {
+	if err := j.file.Truncate(0); err != nil {
+		return fmt.Errorf("не удалось очистить журнал: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("ошибка при сбросе журнала на диск: %w", err)
+	}
+
+	j.entries = 0
+	j.broken = nil
+	return nil
+}
+
+// dropPrefix убирает из журнала первые entries записей, занимающие offset
+// байт: записи после них переписываются в новый файл, который атомарно
+// заменяет журнал. При сбое до замены остается полный журнал, повторное
+// применение которого к новому снимку безопасно
+func (j *journal) dropPrefix(offset int64, entries int) error {
+	if offset == j.size {
+		if err := j.reset(); err != nil {
+			return err
+		}
+		j.size = 0
+		return nil
+	}
+
+	source, err := os.Open(j.path)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть журнал: %w", err)
+	}
+	tail := make([]byte, j.size-offset)
+	_, err = source.ReadAt(tail, offset)
+	source.Close()
+	if err != nil {
+		return fmt.Errorf("ошибка при чтении журнала: %w", err)
+	}
+
+	tmpFile := j.path + ".tmp"
+	if err := writeFileSync(tmpFile, tail); err != nil {
+		return fmt.Errorf("ошибка при записи во временный файл: %w", err)
+	}
+	if err := os.Rename(tmpFile, j.path); err != nil {
+		return fmt.Errorf("ошибка при замене журнала: %w", err)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("не удалось открыть журнал: %w", err)
+	}
+	j.file.Close()
+	j.file = file
+	j.size = int64(len(tail))
+	j.entries -= entries
+	j.broken = nil
+	return nil
+}
+
+func (j *journal) close() error {
+	return j.file.Close()
+}
+
+// replayJournal применяет записи журнала к событиям. Повторное применение
+// безопасно: create и update заменяют событие, delete отсутствующего
+// события игнорируется. Оборванная при сбое последняя строка (без перевода
+// строки) отбрасывается, и файл обрезается до последней целой записи.
+// Возвращает число примененных записей
+func replayJournal(path string, events map[string]*models.Event) (int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("не удалось открыть журнал: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	applied := 0
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+
+		if err != nil && err != io.EOF {
+			return applied, fmt.Errorf("ошибка при чтении журнала: %w", err)
+		}
+
+		if err == io.EOF {
+			// Последняя запись оборвана сбоем во время дозаписи
+			log.Printf("Журнал %s: отброшена неполная запись на позиции %d", path, offset)
+			if err := file.Truncate(offset); err != nil {
+				return applied, fmt.Errorf("не удалось обрезать журнал: %w", err)
+			}
+			break
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(bytes.TrimSpace(line), &entry); err != nil {
+			return applied, fmt.Errorf("журнал поврежден на позиции %d: %w", offset, err)
+		}
+
+		if err := entry.apply(events); err != nil {
+			return applied, err
+		}
+
+		offset += int64(len(line))
+		applied++
+	}
+
+	return applied, nil
+}
+
+// apply применяет запись журнала к событиям
+func (entry journalEntry) apply(events map[string]*models.Event) error {
+	switch entry.Op {
+	case opCreate, opUpdate:
+		events[entry.ID] = entry.Event
+	case opDelete:
+		delete(events, entry.ID)
+	case opBatch:
+		for _, item := range entry.Batch {
+			if err := item.apply(events); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("неизвестная операция журнала: %s", entry.Op)
+	}
+	return nil
+}
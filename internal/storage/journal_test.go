@@ -0,0 +1,207 @@
+// internal/storage/journal_test.go
+package storage
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"schedule-app/internal/models"
+	"testing"
+	"time"
+)
+
+func TestReplayJournal(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	event := func(id, title string) *models.Event {
+		e := models.NewEvent(title, start, start.Add(time.Hour), nil, models.EventDetails{})
+		e.ID = id
+		return e
+	}
+	entry := func(op, id string, e *models.Event) journalEntry {
+		return journalEntry{Time: start, Op: op, ID: id, Event: e}
+	}
+
+	base := []journalEntry{
+		entry(opCreate, "a", event("a", "Лекция")),
+		entry(opCreate, "b", event("b", "Семинар")),
+		entry(opUpdate, "a", event("a", "Лекция (перенесена)")),
+	}
+
+	tests := []struct {
+		name    string
+		entries []journalEntry
+		// tail дописывается после записей как есть, без перевода строки
+		tail        string
+		wantApplied int
+		wantTitles  map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "complete",
+			entries:     append(base, entry(opDelete, "b", nil)),
+			wantApplied: 4,
+			wantTitles:  map[string]string{"a": "Лекция (перенесена)"},
+		},
+		{
+			name:        "truncated last line",
+			entries:     base,
+			tail:        `{"time":"2026-03-02T09:00:00Z","op":"delete","id":"a"`,
+			wantApplied: 3,
+			wantTitles:  map[string]string{"a": "Лекция (перенесена)", "b": "Семинар"},
+		},
+		{
+			name:        "truncated after brace",
+			entries:     base,
+			tail:        `{"time":"2026-03-02T09:00:00Z","op":"delete","id":"b"}`,
+			wantApplied: 3,
+			wantTitles:  map[string]string{"a": "Лекция (перенесена)", "b": "Семинар"},
+		},
+		{
+			name: "batch",
+			entries: append(base, journalEntry{Time: start, Op: opBatch, Batch: []journalEntry{
+				entry(opUpdate, "a", event("a", "Лекция (до 10 марта)")),
+				entry(opCreate, "c", event("c", "Лекция (с 10 марта)")),
+			}}),
+			wantApplied: 4,
+			wantTitles:  map[string]string{"a": "Лекция (до 10 марта)", "b": "Семинар", "c": "Лекция (с 10 марта)"},
+		},
+		{
+			// Оборванная запись пакета отбрасывается целиком
+			name:        "truncated batch",
+			entries:     base,
+			tail:        `{"time":"2026-03-02T09:00:00Z","op":"batch","batch":[{"op":"delete","id":"a"},{"op":"delete","id":"b"}`,
+			wantApplied: 3,
+			wantTitles:  map[string]string{"a": "Лекция (перенесена)", "b": "Семинар"},
+		},
+		{
+			name:        "empty",
+			wantApplied: 0,
+			wantTitles:  map[string]string{},
+		},
+		{
+			name:        "corrupted line",
+			entries:     base,
+			tail:        "{не json}\n",
+			wantApplied: 3,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.journal")
+			j, err := openJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.entries {
+				if err := j.append(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			complete := j.size
+			if _, err := j.file.WriteString(tt.tail); err != nil {
+				t.Fatal(err)
+			}
+			j.close()
+
+			events := make(map[string]*models.Event)
+			applied, err := replayJournal(path, events)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("replayJournal: ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if applied != tt.wantApplied {
+				t.Errorf("применено %d записей, ожидалось %d", applied, tt.wantApplied)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			titles := make(map[string]string, len(events))
+			for id, e := range events {
+				titles[id] = e.Title
+			}
+			if !maps.Equal(titles, tt.wantTitles) {
+				t.Errorf("события после применения журнала: %v, ожидалось %v", titles, tt.wantTitles)
+			}
+
+			// Неполная запись обрезается, и журнал можно дописывать дальше
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size() != complete {
+				t.Errorf("размер журнала %d, ожидалось %d", info.Size(), complete)
+			}
+
+			j, err = openJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := j.append(entry(opCreate, "d", event("d", "Консультация"))); err != nil {
+				t.Fatal(err)
+			}
+			j.close()
+
+			events = make(map[string]*models.Event)
+			if applied, err := replayJournal(path, events); err != nil || applied != tt.wantApplied+1 || events["d"] == nil {
+				t.Errorf("после дозаписи: применено %d, ошибка %v, событие d: %v", applied, err, events["d"] != nil)
+			}
+		})
+	}
+}
+
+func TestJournalAppendFailure(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	entry := func(id string) journalEntry {
+		e := models.NewEvent("Лекция", start, start.Add(time.Hour), nil, models.EventDetails{})
+		e.ID = id
+		return journalEntry{Time: start, Op: opCreate, ID: id, Event: e}
+	}
+
+	path := filepath.Join(t.TempDir(), "events.journal")
+	j, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	if err := j.append(entry("a")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись в закрытый файл не удается, и обрезать его тоже нельзя:
+	// журнал должен отказывать в дозаписи, пока не будет переписан
+	file := j.file
+	closed, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	j.file = closed
+	if err := j.append(entry("b")); err == nil {
+		t.Fatal("append: ожидалась ошибка записи")
+	}
+	j.file = file
+	if err := j.append(entry("c")); err == nil {
+		t.Fatal("append после неудачного отката: ожидалась ошибка")
+	}
+
+	if err := j.dropPrefix(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.append(entry("d")); err != nil {
+		t.Fatalf("append после перезаписи журнала: %v", err)
+	}
+
+	events := make(map[string]*models.Event)
+	if _, err := replayJournal(path, events); err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]bool, len(events))
+	for id := range events {
+		ids[id] = true
+	}
+	if want := map[string]bool{"a": true, "d": true}; !maps.Equal(ids, want) {
+		t.Errorf("события после применения журнала: %v, ожидалось %v", ids, want)
+	}
+}
@@ -0,0 +1,796 @@
+// internal/storage/memory.go
+package storage
+
+import (
+	"fmt"
+	"schedule-app/internal/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит события в памяти процесса. Используется как самостоятельный
+// backend (например, для тестов) и как основа файлового хранилища Storage.
+// Хранимые события никогда не покидают хранилище: чтение возвращает копии,
+// а запись сохраняет копию переданного события
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events map[string]*models.Event
+	index  *eventIndex
+
+	// persist вызывается под блокировкой после каждого изменения; изменения
+	// одной операции (например, разделения серии) передаются вместе и должны
+	// сохраняться атомарно. При ошибке изменения откатываются
+	persist func(changes []change) error
+
+	// resources - группы, преподаватели и аудитории по ID; persistResources,
+	// как и persist, вызывается под блокировкой после каждого их изменения
+	resources        map[string]*models.Resource
+	persistResources func() error
+
+	// profiles - профили доступности по ID; persistProfiles вызывается
+	// так же, как persistResources
+	profiles        map[string]*models.AvailabilityProfile
+	persistProfiles func() error
+
+	// bookingLinks - ссылки для записи по ID; persistBookingLinks вызывается
+	// так же, как persistResources
+	bookingLinks        map[string]*models.BookingLink
+	persistBookingLinks func() error
+
+	// reminders - сработавшие напоминания по ID, reminderCheckpoint - момент,
+	// до которого напоминания разосланы; persistReminders вызывается так же,
+	// как persistResources
+	reminders          map[string]*models.Reminder
+	reminderCheckpoint time.Time
+	persistReminders   func() error
+
+	// semester - учебный семестр для запросов по датам (см. SetSemester)
+	semester *models.Semester
+}
+
+// change - изменение события id: op - create, update или delete, event ==
+// nil при удалении; previous - прежнее состояние для отката (nil - события не было)
+type change struct {
+	op       string
+	id       string
+	event    *models.Event
+	previous *models.Event
+}
+
+// NewMemoryStore создает пустое хранилище в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		events:    make(map[string]*models.Event),
+		index:     newEventIndex(),
+		resources: make(map[string]*models.Resource),
+		profiles:  make(map[string]*models.AvailabilityProfile),
+
+		bookingLinks: make(map[string]*models.BookingLink),
+		reminders:    make(map[string]*models.Reminder),
+	}
+}
+
+// List возвращает все события
+func (s *MemoryStore) List() ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return cloneEvents(s.getAllEvents()), nil
+}
+
+// Get возвращает событие по ID
+func (s *MemoryStore) Get(id string) (*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	event, exists := s.events[id]
+	if !exists {
+		return nil, fmt.Errorf("событие с ID %s не найдено", id)
+	}
+
+	return event.Clone(), nil
+}
+
+// Range возвращает события и экземпляры серий, пересекающиеся с окном [from, to).
+// Кандидаты отбираются по интервальному индексу
+func (s *MemoryStore) Range(from, to time.Time) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.rangeEvents(from, to), nil
+}
+
+// rangeEvents возвращает копии событий окна [from, to), отсортированные
+// по времени начала; вызывается под блокировкой
+func (s *MemoryStore) rangeEvents(from, to time.Time) []*models.Event {
+	var events []*models.Event
+	for _, event := range s.index.overlapping(from, to) {
+		events = append(events, event.Occurrences(from, to)...)
+	}
+	events = cloneSingles(filterSemester(events, s.semester))
+
+	// Сортируем события по времени начала
+	sortByStart(events)
+
+	return events
+}
+
+// SetSemester задает учебный семестр для запросов по датам
+func (s *MemoryStore) SetSemester(semester *models.Semester) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.semester = semester
+}
+
+// Create создает новое событие
+func (s *MemoryStore) Create(event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.create(event)
+}
+
+// CreateChecked создает событие, если check не нашла препятствий среди
+// событий окна [from, to); проверка и запись выполняются под одной блокировкой
+func (s *MemoryStore) CreateChecked(event *models.Event, from, to time.Time, check func(existing []*models.Event) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkRefs(event, nil); err != nil {
+		return err
+	}
+	if err := check(s.rangeEvents(from, to)); err != nil {
+		return err
+	}
+	return s.create(event)
+}
+
+// Upsert изменяет событие с ID event.ID функцией fn или, если такого
+// события нет, создает event; поиск, проверка ресурсов и запись выполняются
+// под одной блокировкой
+func (s *MemoryStore) Upsert(event *models.Event, fn func(existing *models.Event) error) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.events[event.ID]
+	if !exists {
+		if err := s.checkRefs(event, nil); err != nil {
+			return false, err
+		}
+		return true, s.create(event)
+	}
+
+	_, err := s.modify(event.ID, func(existing *models.Event) error {
+		if err := fn(existing); err != nil {
+			return err
+		}
+		return s.checkRefs(existing, previous)
+	})
+	return false, err
+}
+
+// checkRefs проверяет новые ссылки события на ресурсы (см. checkNewRefs);
+// вызывается под блокировкой
+func (s *MemoryStore) checkRefs(event, previous *models.Event) error {
+	return checkNewRefs(event, previous, func(kind models.ResourceKind, id string) (bool, error) {
+		resource, exists := s.resources[id]
+		return exists && resource.Kind == kind, nil
+	})
+}
+
+// create сохраняет новое событие; вызывается под блокировкой записи
+func (s *MemoryStore) create(event *models.Event) error {
+	// Проверяем, существует ли уже событие с таким ID
+	if _, exists := s.events[event.ID]; exists {
+		return fmt.Errorf("событие с ID %s уже существует", event.ID)
+	}
+
+	event.Version = 1
+	stored := event.Clone()
+	s.events[event.ID] = stored
+	s.index.put(stored)
+	return s.commit(change{op: opCreate, id: event.ID, event: stored})
+}
+
+// Update обновляет существующее событие, сверяя его версию с хранимой
+func (s *MemoryStore) Update(event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.events[event.ID]
+	if !exists {
+		return fmt.Errorf("событие с ID %s не найдено", event.ID)
+	}
+
+	version := event.Version
+	if previous.Version != version {
+		return fmt.Errorf("событие с ID %s: %w", event.ID, ErrVersionConflict)
+	}
+
+	event.Version = version + 1
+	stored := event.Clone()
+	s.events[event.ID] = stored
+	s.index.put(stored)
+	if err := s.commit(change{op: opUpdate, id: event.ID, event: stored, previous: previous}); err != nil {
+		event.Version = version
+		return err
+	}
+
+	return nil
+}
+
+// Split атомарно сохраняет сокращенную серию head, сверяя ее версию
+// с хранимой, и создает новую серию tail, если check не нашла препятствий
+// среди событий окна [from, to)
+func (s *MemoryStore) Split(head, tail *models.Event, from, to time.Time, check func(existing []*models.Event) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.events[head.ID]
+	if !exists {
+		return fmt.Errorf("событие с ID %s не найдено", head.ID)
+	}
+	if previous.Version != head.Version {
+		return fmt.Errorf("событие с ID %s: %w", head.ID, ErrVersionConflict)
+	}
+	if _, exists := s.events[tail.ID]; exists {
+		return fmt.Errorf("событие с ID %s уже существует", tail.ID)
+	}
+	if err := s.checkRefs(tail, previous); err != nil {
+		return err
+	}
+	if err := check(s.rangeEvents(from, to)); err != nil {
+		return err
+	}
+
+	version := head.Version
+	head.Version = version + 1
+	tail.Version = 1
+	storedHead, storedTail := head.Clone(), tail.Clone()
+	s.events[head.ID] = storedHead
+	s.index.put(storedHead)
+	s.events[tail.ID] = storedTail
+	s.index.put(storedTail)
+
+	err := s.commit(
+		change{op: opUpdate, id: head.ID, event: storedHead, previous: previous},
+		change{op: opCreate, id: tail.ID, event: storedTail},
+	)
+	if err != nil {
+		head.Version = version
+		return err
+	}
+
+	return nil
+}
+
+// Modify атомарно изменяет событие: fn получает копию хранимого события
+// и выполняется под блокировкой хранилища. Если fn вернула ошибку или
+// измененное событие не прошло проверку, хранимое событие не меняется
+func (s *MemoryStore) Modify(id string, fn func(event *models.Event) error) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.modify(id, fn)
+}
+
+// ModifyChecked изменяет событие так же, как Modify; fn дополнительно
+// получает события окна [from, to), прочитанные под той же блокировкой
+func (s *MemoryStore) ModifyChecked(id string, from, to time.Time, fn func(event *models.Event, existing []*models.Event) error) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.rangeEvents(from, to)
+	previous := s.events[id]
+	return s.modify(id, func(event *models.Event) error {
+		if err := fn(event, existing); err != nil {
+			return err
+		}
+		return s.checkRefs(event, previous)
+	})
+}
+
+// ModifyAll изменяет события функцией fn; изменения применяются до вызова
+// check, чтобы она видела новое время событий, и откатываются при ее ошибке
+func (s *MemoryStore) ModifyAll(fn func(event *models.Event) bool, check BatchCheck) ([]*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changes []change
+	var changed []*models.Event
+	for _, previous := range s.getAllEvents() {
+		event, err := modifyCopy(previous, fn)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+		if err := s.checkRefs(event, previous); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change{op: opUpdate, id: event.ID, event: event, previous: previous})
+		changed = append(changed, event)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	sortByStart(changed)
+
+	for _, c := range changes {
+		s.events[c.id] = c.event
+		s.index.put(c.event)
+	}
+	if check != nil {
+		err := check(changed, func(from, to time.Time) ([]*models.Event, error) {
+			return s.rangeEvents(from, to), nil
+		})
+		if err != nil {
+			s.restore(changes)
+			return nil, err
+		}
+	}
+	if err := s.commit(changes...); err != nil {
+		return nil, err
+	}
+
+	return cloneEvents(changed), nil
+}
+
+// modify изменяет событие; вызывается под блокировкой записи
+func (s *MemoryStore) modify(id string, fn func(event *models.Event) error) (*models.Event, error) {
+	previous, exists := s.events[id]
+	if !exists {
+		return nil, fmt.Errorf("событие с ID %s не найдено", id)
+	}
+
+	event := previous.Clone()
+	if err := modifyEvent(event, fn); err != nil {
+		return nil, err
+	}
+
+	event.Version = previous.Version + 1
+	s.events[id] = event
+	s.index.put(event)
+	if err := s.commit(change{op: opUpdate, id: id, event: event, previous: previous}); err != nil {
+		return nil, err
+	}
+
+	return event.Clone(), nil
+}
+
+// Delete удаляет событие по ID
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(id, nil)
+}
+
+// DeleteIfVersion удаляет событие, если его версия совпадает с version
+func (s *MemoryStore) DeleteIfVersion(id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(id, &version)
+}
+
+// delete удаляет событие, сверяя версию, если она указана; вызывается
+// под блокировкой записи
+func (s *MemoryStore) delete(id string, version *int64) error {
+	previous, exists := s.events[id]
+	if !exists {
+		return fmt.Errorf("событие с ID %s не найдено", id)
+	}
+	if version != nil && previous.Version != *version {
+		return fmt.Errorf("событие с ID %s: %w", id, ErrVersionConflict)
+	}
+
+	delete(s.events, id)
+	s.index.remove(id)
+	return s.commit(change{op: opDelete, id: id, previous: previous})
+}
+
+// Search ищет события по ключевым словам в заголовке, тегах, описании и месте проведения.
+// Найденные повторяющиеся серии разворачиваются так же, как в GetAllOccurrences
+func (s *MemoryStore) Search(query string) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	from, to := expansionWindow()
+	if query == "" {
+		return cloneSingles(expandEvents(s.getAllEvents(), from, to)), nil
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	var results []*models.Event
+
+	for _, event := range s.events {
+		if matchesQuery(event, query) {
+			results = append(results, event)
+		}
+	}
+
+	return cloneSingles(expandEvents(results, from, to)), nil
+}
+
+// commit сохраняет изменения событий; если сохранение не удалось,
+// восстанавливает их предыдущее состояние
+func (s *MemoryStore) commit(changes ...change) error {
+	if s.persist == nil {
+		return nil
+	}
+
+	if err := s.persist(changes); err != nil {
+		s.restore(changes)
+		return err
+	}
+
+	return nil
+}
+
+// restore возвращает события, затронутые changes, в предыдущее состояние
+func (s *MemoryStore) restore(changes []change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if c.previous != nil {
+			s.events[c.id] = c.previous
+			s.index.put(c.previous)
+		} else {
+			delete(s.events, c.id)
+			s.index.remove(c.id)
+		}
+	}
+}
+
+// rebuildIndex заново строит индекс после массовой загрузки событий
+func (s *MemoryStore) rebuildIndex() {
+	s.index = newEventIndex()
+	for _, event := range s.events {
+		s.index.put(event)
+	}
+}
+
+// Получить все события (вспомогательная функция)
+func (s *MemoryStore) getAllEvents() []*models.Event {
+	events := make([]*models.Event, 0, len(s.events))
+	for _, event := range s.events {
+		events = append(events, event)
+	}
+	return events
+}
+
+// ListResources возвращает ресурсы вида kind, отсортированные по названию
+func (s *MemoryStore) ListResources(kind models.ResourceKind) ([]*models.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resources := make([]*models.Resource, 0)
+	for _, resource := range s.resources {
+		if resource.Kind == kind {
+			resources = append(resources, resource.Clone())
+		}
+	}
+	sortResources(resources)
+
+	return resources, nil
+}
+
+// GetResource возвращает ресурс вида kind по ID
+func (s *MemoryStore) GetResource(kind models.ResourceKind, id string) (*models.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resource, exists := s.resources[id]
+	if !exists || resource.Kind != kind {
+		return nil, resourceNotFound(kind, id)
+	}
+
+	return resource.Clone(), nil
+}
+
+// CreateResource создает новый ресурс
+func (s *MemoryStore) CreateResource(resource *models.Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.resources[resource.ID]; exists {
+		return fmt.Errorf("ресурс с ID %s уже существует", resource.ID)
+	}
+
+	resource.Version = 1
+	s.resources[resource.ID] = resource.Clone()
+	return commitEntry(s.resources, resource.ID, nil, s.persistResources)
+}
+
+// ModifyResource атомарно изменяет ресурс (см. Modify)
+func (s *MemoryStore) ModifyResource(kind models.ResourceKind, id string, fn func(resource *models.Resource) error) (*models.Resource, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.resources[id]
+	if !exists || previous.Kind != kind {
+		return nil, resourceNotFound(kind, id)
+	}
+
+	resource := previous.Clone()
+	if err := modifyResource(resource, fn); err != nil {
+		return nil, err
+	}
+
+	resource.Version = previous.Version + 1
+	s.resources[id] = resource
+	if err := commitEntry(s.resources, id, previous, s.persistResources); err != nil {
+		return nil, err
+	}
+
+	return resource.Clone(), nil
+}
+
+// DeleteResource удаляет ресурс, на который не ссылаются события
+func (s *MemoryStore) DeleteResource(kind models.ResourceKind, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.resources[id]
+	if !exists || previous.Kind != kind {
+		return resourceNotFound(kind, id)
+	}
+	if used := usingEvents(s.getAllEvents(), kind, id); len(used) > 0 {
+		return &ResourceInUseError{Events: used}
+	}
+
+	delete(s.resources, id)
+	return commitEntry(s.resources, id, previous, s.persistResources)
+}
+
+// ListProfiles возвращает профили доступности, упорядоченные по ID
+func (s *MemoryStore) ListProfiles() ([]*models.AvailabilityProfile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := make([]*models.AvailabilityProfile, 0, len(s.profiles))
+	for _, profile := range s.profiles {
+		profiles = append(profiles, profile.Clone())
+	}
+	sortProfiles(profiles)
+
+	return profiles, nil
+}
+
+// GetProfile возвращает профиль доступности по ID
+func (s *MemoryStore) GetProfile(id string) (*models.AvailabilityProfile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profile, exists := s.profiles[id]
+	if !exists {
+		return nil, profileNotFound(id)
+	}
+
+	return profile.Clone(), nil
+}
+
+// SaveProfile создает или заменяет профиль доступности
+func (s *MemoryStore) SaveProfile(profile *models.AvailabilityProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.profiles[profile.ID]
+	var stored int64
+	if exists {
+		stored = previous.Version
+	}
+	if err := prepareSave(&profile.Version, &profile.UpdatedAt, stored, profile.Validate); err != nil {
+		return err
+	}
+
+	s.profiles[profile.ID] = profile.Clone()
+	return commitEntry(s.profiles, profile.ID, previous, s.persistProfiles)
+}
+
+// DeleteProfile удаляет профиль доступности
+func (s *MemoryStore) DeleteProfile(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.profiles[id]
+	if !exists {
+		return profileNotFound(id)
+	}
+
+	delete(s.profiles, id)
+	return commitEntry(s.profiles, id, previous, s.persistProfiles)
+}
+
+// ListBookingLinks возвращает ссылки для записи, упорядоченные по ID
+func (s *MemoryStore) ListBookingLinks() ([]*models.BookingLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	links := make([]*models.BookingLink, 0, len(s.bookingLinks))
+	for _, link := range s.bookingLinks {
+		links = append(links, link.Clone())
+	}
+	sortBookingLinks(links)
+
+	return links, nil
+}
+
+// GetBookingLink возвращает ссылку для записи по ID
+func (s *MemoryStore) GetBookingLink(id string) (*models.BookingLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, exists := s.bookingLinks[id]
+	if !exists {
+		return nil, bookingLinkNotFound(id)
+	}
+
+	return link.Clone(), nil
+}
+
+// SaveBookingLink создает или заменяет ссылку для записи
+func (s *MemoryStore) SaveBookingLink(link *models.BookingLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.bookingLinks[link.ID]
+	var stored int64
+	if exists {
+		stored = previous.Version
+	}
+	if err := prepareSave(&link.Version, &link.UpdatedAt, stored, link.Validate); err != nil {
+		return err
+	}
+
+	s.bookingLinks[link.ID] = link.Clone()
+	return commitEntry(s.bookingLinks, link.ID, previous, s.persistBookingLinks)
+}
+
+// DeleteBookingLink удаляет ссылку для записи
+func (s *MemoryStore) DeleteBookingLink(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.bookingLinks[id]
+	if !exists {
+		return bookingLinkNotFound(id)
+	}
+
+	delete(s.bookingLinks, id)
+	return commitEntry(s.bookingLinks, id, previous, s.persistBookingLinks)
+}
+
+// ListReminders возвращает сработавшие напоминания, упорядоченные по сроку
+func (s *MemoryStore) ListReminders() ([]*models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminders := make([]*models.Reminder, 0, len(s.reminders))
+	for _, reminder := range s.reminders {
+		reminders = append(reminders, reminder.Clone())
+	}
+	sortReminders(reminders)
+
+	return reminders, nil
+}
+
+// GetReminder возвращает сработавшее напоминание по ID
+func (s *MemoryStore) GetReminder(id string) (*models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminder, exists := s.reminders[id]
+	if !exists {
+		return nil, reminderNotFound(id)
+	}
+
+	return reminder.Clone(), nil
+}
+
+// SaveReminder создает или заменяет запись о напоминании
+func (s *MemoryStore) SaveReminder(reminder *models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.reminders[reminder.ID]
+	s.reminders[reminder.ID] = reminder.Clone()
+	return commitEntry(s.reminders, reminder.ID, previous, s.persistReminders)
+}
+
+// AcknowledgeReminder отмечает напоминание подтвержденным
+func (s *MemoryStore) AcknowledgeReminder(id string, at time.Time) (*models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.reminders[id]
+	if !exists {
+		return nil, reminderNotFound(id)
+	}
+
+	reminder := previous.Clone()
+	acknowledge(reminder, at)
+	s.reminders[id] = reminder
+	if err := commitEntry(s.reminders, id, previous, s.persistReminders); err != nil {
+		return nil, err
+	}
+
+	return reminder.Clone(), nil
+}
+
+// PruneReminders удаляет записи о напоминаниях со сроком раньше before
+func (s *MemoryStore) PruneReminders(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make(map[string]*models.Reminder)
+	for id, reminder := range s.reminders {
+		if reminder.Due.Before(before) {
+			removed[id] = reminder
+			delete(s.reminders, id)
+		}
+	}
+	if len(removed) == 0 || s.persistReminders == nil {
+		return len(removed), nil
+	}
+
+	if err := s.persistReminders(); err != nil {
+		for id, reminder := range removed {
+			s.reminders[id] = reminder
+		}
+		return 0, err
+	}
+
+	return len(removed), nil
+}
+
+// ReminderCheckpoint возвращает момент, до которого напоминания разосланы
+func (s *MemoryStore) ReminderCheckpoint() (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.reminderCheckpoint, nil
+}
+
+// SetReminderCheckpoint сохраняет момент, до которого напоминания разосланы
+func (s *MemoryStore) SetReminderCheckpoint(checkpoint time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.reminderCheckpoint
+	s.reminderCheckpoint = checkpoint
+	if s.persistReminders == nil {
+		return nil
+	}
+
+	if err := s.persistReminders(); err != nil {
+		s.reminderCheckpoint = previous
+		return err
+	}
+
+	return nil
+}
+
+// commitEntry сохраняет изменение записи id в карте entries (ресурса,
+// профиля, ссылки или напоминания) вызовом persist; если сохранение
+// не удалось, восстанавливает предыдущее состояние (previous == nil -
+// записи не было)
+func commitEntry[T any](entries map[string]*T, id string, previous *T, persist func() error) error {
+	if persist == nil {
+		return nil
+	}
+
+	if err := persist(); err != nil {
+		if previous != nil {
+			entries[id] = previous
+		} else {
+			delete(entries, id)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+// internal/storage/migrations.go
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migration описывает одну версию схемы базы данных
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// sqliteMigrations - история схемы SQLite-хранилища. Уже примененные миграции
+// не изменяются: любое изменение схемы добавляется новой версией в конец списка
+var sqliteMigrations = []migration{
+	{
+		version: 1,
+		name:    "события и теги",
+		statements: []string{
+			`CREATE TABLE events (
+				id           TEXT PRIMARY KEY,
+				title_folded TEXT NOT NULL,
+				start_time   INTEGER NOT NULL,
+				end_time     INTEGER NOT NULL,
+				recurring    INTEGER NOT NULL DEFAULT 0,
+				series_end   INTEGER,
+				data         TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_events_start_time ON events(start_time)`,
+			`CREATE INDEX idx_events_end_time ON events(end_time)`,
+			`CREATE TABLE event_tags (
+				event_id   TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+				tag        TEXT NOT NULL,
+				tag_folded TEXT NOT NULL,
+				PRIMARY KEY (event_id, tag)
+			)`,
+			`CREATE INDEX idx_event_tags_tag_folded ON event_tags(tag_folded)`,
+		},
+	},
+	{
+		version: 2,
+		name:    "версия события",
+		statements: []string{
+			`ALTER TABLE events ADD COLUMN version INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version: 3,
+		name:    "описание и место проведения для поиска",
+		statements: []string{
+			`ALTER TABLE events ADD COLUMN details_folded TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version: 4,
+		name:    "учебные группы, преподаватели и аудитории",
+		statements: []string{
+			`CREATE TABLE resources (
+				id          TEXT PRIMARY KEY,
+				kind        TEXT NOT NULL,
+				name_folded TEXT NOT NULL,
+				version     INTEGER NOT NULL,
+				data        TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_resources_kind_name ON resources(kind, name_folded)`,
+		},
+	},
+	{
+		version: 5,
+		name:    "профили доступности",
+		statements: []string{
+			`CREATE TABLE profiles (
+				id      TEXT PRIMARY KEY,
+				version INTEGER NOT NULL,
+				data    TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 6,
+		name:    "ссылки для самостоятельной записи",
+		statements: []string{
+			`CREATE TABLE booking_links (
+				id      TEXT PRIMARY KEY,
+				version INTEGER NOT NULL,
+				data    TEXT NOT NULL
+			)`,
+		},
+	},
+	{
+		version: 7,
+		name:    "напоминания",
+		statements: []string{
+			`CREATE TABLE reminders (
+				id   TEXT PRIMARY KEY,
+				due  INTEGER NOT NULL,
+				data TEXT NOT NULL
+			)`,
+			`CREATE INDEX idx_reminders_due ON reminders(due)`,
+			`CREATE TABLE reminder_checkpoint (
+				id         INTEGER PRIMARY KEY CHECK (id = 1),
+				checkpoint INTEGER NOT NULL
+			)`,
+		},
+	},
+}
+
+// migrate применяет недостающие миграции по порядку, каждую в отдельной транзакции
+func migrate(db *sql.DB, migrations []migration) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("не удалось создать таблицу миграций: %w", err)
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("не удалось определить версию схемы: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("миграция %d (%s): %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
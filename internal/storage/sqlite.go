@@ -0,0 +1,986 @@
+// internal/storage/sqlite.go
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"schedule-app/internal/models"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore хранит события в базе SQLite (драйвер на чистом Go, без cgo).
+// Время начала и окончания вынесено в индексированные столбцы, теги - в
+// отдельную таблицу, полное событие хранится в столбце data в формате JSON
+type SQLiteStore struct {
+	db *sql.DB
+
+	// semester - учебный семестр для запросов по датам (см. SetSemester)
+	semester *models.Semester
+}
+
+// NewSQLiteStore открывает базу по пути path и применяет недостающие миграции.
+// Транзакции начинаются с BEGIN IMMEDIATE: чтение и запись в Update и Modify
+// выполняются под блокировкой записи и не конфликтуют с другими писателями
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := path + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных: %w", err)
+	}
+
+	if err := migrate(db, sqliteMigrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close закрывает соединение с базой данных
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// List возвращает все события
+func (s *SQLiteStore) List() ([]*models.Event, error) {
+	return s.query(`SELECT data FROM events ORDER BY start_time`)
+}
+
+// Get возвращает событие по ID
+func (s *SQLiteStore) Get(id string) (*models.Event, error) {
+	return getEvent(s.db, id)
+}
+
+// Range возвращает события и экземпляры серий, пересекающиеся с окном [from, to).
+// Кандидаты отбираются по индексам времени, серии разворачиваются в памяти
+func (s *SQLiteStore) Range(from, to time.Time) ([]*models.Event, error) {
+	return rangeEvents(s.db, from, to, s.semester)
+}
+
+// SetSemester задает учебный семестр для запросов по датам
+func (s *SQLiteStore) SetSemester(semester *models.Semester) {
+	s.semester = semester
+}
+
+// rangeEvents читает события окна [from, to) в базе или внутри транзакции;
+// занятия с четностью недели отбираются по семестру semester
+func rangeEvents(q querier, from, to time.Time, semester *models.Semester) ([]*models.Event, error) {
+	candidates, err := queryEvents(q, `SELECT data FROM events
+		WHERE (recurring = 0 AND start_time < ? AND (end_time > ? OR (end_time = start_time AND start_time >= ?)))
+		   OR (recurring = 1 AND start_time < ? AND (series_end IS NULL OR series_end >= ?))`,
+		to.UnixNano(), from.UnixNano(), from.UnixNano(), to.UnixNano(), from.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*models.Event
+	for _, event := range candidates {
+		events = append(events, event.Occurrences(from, to)...)
+	}
+	events = filterSemester(events, semester)
+
+	// Сортируем события по времени начала
+	sortByStart(events)
+
+	return events, nil
+}
+
+// Create создает новое событие
+func (s *SQLiteStore) Create(event *models.Event) error {
+	event.Version = 1
+
+	return s.write(func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM events WHERE id = ?`, event.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			return fmt.Errorf("событие с ID %s уже существует", event.ID)
+		}
+
+		return insertEvent(tx, event)
+	})
+}
+
+// CreateChecked создает событие, если check не нашла препятствий среди
+// событий окна [from, to). События читаются в той же транзакции, что и запись:
+// BEGIN IMMEDIATE не дает другому писателю изменить их до фиксации
+func (s *SQLiteStore) CreateChecked(event *models.Event, from, to time.Time, check func(existing []*models.Event) error) error {
+	event.Version = 1
+
+	return s.write(func(tx *sql.Tx) error {
+		if err := checkRefsTx(tx, event, nil); err != nil {
+			return err
+		}
+		existing, err := rangeEvents(tx, from, to, s.semester)
+		if err != nil {
+			return err
+		}
+		if err := check(existing); err != nil {
+			return err
+		}
+
+		return insertEvent(tx, event)
+	})
+}
+
+// Update обновляет существующее событие, сверяя его версию с хранимой
+// в той же транзакции
+func (s *SQLiteStore) Update(event *models.Event) error {
+	version := event.Version
+	err := s.write(func(tx *sql.Tx) error {
+		current, err := getEvent(tx, event.ID)
+		if err != nil {
+			return err
+		}
+		if current.Version != version {
+			return fmt.Errorf("событие с ID %s: %w", event.ID, ErrVersionConflict)
+		}
+
+		event.Version = version + 1
+		if err := deleteEvent(tx, event.ID); err != nil {
+			return err
+		}
+		return insertEvent(tx, event)
+	})
+	if err != nil {
+		event.Version = version
+	}
+	return err
+}
+
+// Split сохраняет сокращенную серию head и создает серию tail в одной
+// транзакции, если check не нашла препятствий среди событий окна [from, to)
+func (s *SQLiteStore) Split(head, tail *models.Event, from, to time.Time, check func(existing []*models.Event) error) error {
+	version := head.Version
+	err := s.write(func(tx *sql.Tx) error {
+		current, err := getEvent(tx, head.ID)
+		if err != nil {
+			return err
+		}
+		if current.Version != version {
+			return fmt.Errorf("событие с ID %s: %w", head.ID, ErrVersionConflict)
+		}
+		if err := checkRefsTx(tx, tail, current); err != nil {
+			return err
+		}
+
+		existing, err := rangeEvents(tx, from, to, s.semester)
+		if err != nil {
+			return err
+		}
+		if err := check(existing); err != nil {
+			return err
+		}
+
+		head.Version = version + 1
+		tail.Version = 1
+		if err := deleteEvent(tx, head.ID); err != nil {
+			return err
+		}
+		if err := insertEvent(tx, head); err != nil {
+			return err
+		}
+		return insertEvent(tx, tail)
+	})
+	if err != nil {
+		head.Version = version
+	}
+	return err
+}
+
+// Modify атомарно изменяет событие: fn получает событие, прочитанное в той же
+// транзакции, в которой сохраняется результат
+func (s *SQLiteStore) Modify(id string, fn func(event *models.Event) error) (*models.Event, error) {
+	var event *models.Event
+	err := s.write(func(tx *sql.Tx) error {
+		var err error
+		event, err = modifyTx(tx, id, fn)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// ModifyChecked изменяет событие так же, как Modify; fn дополнительно
+// получает события окна [from, to), прочитанные в той же транзакции
+func (s *SQLiteStore) ModifyChecked(id string, from, to time.Time, fn func(event *models.Event, existing []*models.Event) error) (*models.Event, error) {
+	var event *models.Event
+	err := s.write(func(tx *sql.Tx) error {
+		existing, err := rangeEvents(tx, from, to, s.semester)
+		if err != nil {
+			return err
+		}
+		previous, err := getEvent(tx, id)
+		if err != nil {
+			return err
+		}
+
+		event, err = modifyTx(tx, id, func(event *models.Event) error {
+			if err := fn(event, existing); err != nil {
+				return err
+			}
+			return checkRefsTx(tx, event, previous)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// Upsert изменяет событие с ID event.ID функцией fn или, если такого
+// события нет, создает event; поиск, проверка ресурсов и запись выполняются
+// в одной транзакции
+func (s *SQLiteStore) Upsert(event *models.Event, fn func(existing *models.Event) error) (bool, error) {
+	created := false
+	err := s.write(func(tx *sql.Tx) error {
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM events WHERE id = ?`, event.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			if err := checkRefsTx(tx, event, nil); err != nil {
+				return err
+			}
+			event.Version = 1
+			created = true
+			return insertEvent(tx, event)
+		}
+
+		previous, err := getEvent(tx, event.ID)
+		if err != nil {
+			return err
+		}
+		_, err = modifyTx(tx, event.ID, func(existing *models.Event) error {
+			if err := fn(existing); err != nil {
+				return err
+			}
+			return checkRefsTx(tx, existing, previous)
+		})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+// ModifyAll изменяет события функцией fn в одной транзакции. Измененные
+// события записываются до вызова check, чтобы она видела новое время,
+// и еще раз после нее, если check их изменила
+func (s *SQLiteStore) ModifyAll(fn func(event *models.Event) bool, check BatchCheck) ([]*models.Event, error) {
+	var changed []*models.Event
+	err := s.write(func(tx *sql.Tx) error {
+		events, err := queryEvents(tx, `SELECT data FROM events ORDER BY start_time`)
+		if err != nil {
+			return err
+		}
+
+		for _, previous := range events {
+			event, err := modifyCopy(previous, fn)
+			if err != nil {
+				return err
+			}
+			if event == nil {
+				continue
+			}
+			if err := checkRefsTx(tx, event, previous); err != nil {
+				return err
+			}
+			if err := replaceEvent(tx, event); err != nil {
+				return err
+			}
+			changed = append(changed, event)
+		}
+		if len(changed) == 0 || check == nil {
+			return nil
+		}
+		sortByStart(changed)
+
+		err = check(changed, func(from, to time.Time) ([]*models.Event, error) {
+			return rangeEvents(tx, from, to, s.semester)
+		})
+		if err != nil {
+			return err
+		}
+		for _, event := range changed {
+			if err := replaceEvent(tx, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// replaceEvent перезаписывает хранимое событие в транзакции tx
+func replaceEvent(tx *sql.Tx, event *models.Event) error {
+	if err := deleteEvent(tx, event.ID); err != nil {
+		return err
+	}
+	return insertEvent(tx, event)
+}
+
+// checkRefsTx проверяет новые ссылки события на ресурсы (см. checkNewRefs)
+// в транзакции tx
+func checkRefsTx(tx *sql.Tx, event, previous *models.Event) error {
+	return checkNewRefs(event, previous, func(kind models.ResourceKind, id string) (bool, error) {
+		var exists int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM resources WHERE id = ? AND kind = ?`, id, string(kind)).Scan(&exists)
+		if err != nil {
+			return false, fmt.Errorf("ошибка при чтении ресурса: %w", err)
+		}
+		return exists > 0, nil
+	})
+}
+
+// modifyTx изменяет событие в транзакции tx
+func modifyTx(tx *sql.Tx, id string, fn func(event *models.Event) error) (*models.Event, error) {
+	event, err := getEvent(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := modifyEvent(event, fn); err != nil {
+		return nil, err
+	}
+
+	event.Version++
+	if err := replaceEvent(tx, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Delete удаляет событие по ID
+func (s *SQLiteStore) Delete(id string) error {
+	return s.write(func(tx *sql.Tx) error {
+		return deleteEvent(tx, id)
+	})
+}
+
+// DeleteIfVersion удаляет событие, если его версия, прочитанная в той же
+// транзакции, совпадает с version
+func (s *SQLiteStore) DeleteIfVersion(id string, version int64) error {
+	return s.write(func(tx *sql.Tx) error {
+		current, err := getEvent(tx, id)
+		if err != nil {
+			return err
+		}
+		if current.Version != version {
+			return fmt.Errorf("событие с ID %s: %w", id, ErrVersionConflict)
+		}
+		return deleteEvent(tx, id)
+	})
+}
+
+// Search ищет события по ключевым словам в заголовке, тегах, описании и месте проведения.
+// Найденные повторяющиеся серии разворачиваются так же, как в GetAllOccurrences
+func (s *SQLiteStore) Search(query string) ([]*models.Event, error) {
+	query = foldCase(strings.TrimSpace(query))
+
+	results, err := s.query(`SELECT data FROM events e
+		WHERE instr(e.title_folded, ?) > 0
+		   OR instr(e.details_folded, ?) > 0
+		   OR EXISTS (SELECT 1 FROM event_tags t WHERE t.event_id = e.id AND instr(t.tag_folded, ?) > 0)
+		ORDER BY e.start_time`, query, query, query)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := expansionWindow()
+	return expandEvents(results, from, to), nil
+}
+
+// write выполняет изменение в транзакции
+func (s *SQLiteStore) write(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось сохранить изменения: %w", err)
+	}
+
+	return nil
+}
+
+// query выполняет запрос, возвращающий столбец data, и декодирует события
+func (s *SQLiteStore) query(query string, args ...interface{}) ([]*models.Event, error) {
+	return queryEvents(s.db, query, args...)
+}
+
+// querier - общий интерфейс *sql.DB и *sql.Tx для чтения нескольких строк
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryEvents выполняет запрос в базе или внутри транзакции и декодирует события
+func queryEvents(q querier, query string, args ...interface{}) ([]*models.Event, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении событий: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении событий: %w", err)
+		}
+
+		event, err := decodeEvent(data)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// queryRower - общий интерфейс *sql.DB и *sql.Tx для чтения одной строки
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getEvent читает событие по ID в базе или внутри транзакции
+func getEvent(q queryRower, id string) (*models.Event, error) {
+	var data string
+	err := q.QueryRow(`SELECT data FROM events WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("событие с ID %s не найдено", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении события: %w", err)
+	}
+
+	return decodeEvent(data)
+}
+
+func insertEvent(tx *sql.Tx, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+	}
+
+	// Для серии start_time - начало ее периода (с учетом перенесенных
+	// экземпляров), series_end - конец периода или NULL для бесконечной серии
+	start, end := eventSpan(event)
+	recurring := 0
+	var seriesEnd interface{}
+	if event.IsRecurring() {
+		recurring = 1
+		if !end.Equal(farFuture) {
+			seriesEnd = end.UnixNano()
+		}
+	}
+
+	// Описание и место проведения ищутся одним столбцом; перевод строки
+	// не дает запросу совпасть на стыке полей
+	details := foldCase(event.Description + "\n" + event.Place)
+
+	_, err = tx.Exec(`INSERT INTO events (id, title_folded, details_folded, start_time, end_time, recurring, series_end, version, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID, foldCase(event.Title), details, start.UnixNano(), event.EndTime.UnixNano(),
+		recurring, seriesEnd, event.Version, string(data))
+	if err != nil {
+		return fmt.Errorf("ошибка при записи события: %w", err)
+	}
+
+	for _, tag := range event.Tags {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO event_tags (event_id, tag, tag_folded) VALUES (?, ?, ?)`,
+			event.ID, tag, foldCase(tag))
+		if err != nil {
+			return fmt.Errorf("ошибка при записи тегов: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func deleteEvent(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM event_tags WHERE event_id = ?`, id); err != nil {
+		return fmt.Errorf("ошибка при удалении тегов: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM events WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении события: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("событие с ID %s не найдено", id)
+	}
+
+	return nil
+}
+
+func decodeEvent(data string) (*models.Event, error) {
+	var event models.Event
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	return &event, nil
+}
+
+// foldCase приводит строку к нижнему регистру для поиска: встроенная функция
+// lower() в SQLite не работает с кириллицей
+func foldCase(s string) string {
+	return strings.ToLower(s)
+}
+
+// ListResources возвращает ресурсы вида kind, отсортированные по названию
+func (s *SQLiteStore) ListResources(kind models.ResourceKind) ([]*models.Resource, error) {
+	rows, err := s.db.Query(`SELECT data FROM resources WHERE kind = ? ORDER BY name_folded`, string(kind))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ресурсов: %w", err)
+	}
+	defer rows.Close()
+
+	resources := make([]*models.Resource, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении ресурсов: %w", err)
+		}
+
+		resource, err := decodeResource(data)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, rows.Err()
+}
+
+// GetResource возвращает ресурс вида kind по ID
+func (s *SQLiteStore) GetResource(kind models.ResourceKind, id string) (*models.Resource, error) {
+	return getResource(s.db, kind, id)
+}
+
+// CreateResource создает новый ресурс
+func (s *SQLiteStore) CreateResource(resource *models.Resource) error {
+	resource.Version = 1
+	return s.write(func(tx *sql.Tx) error {
+		return insertResource(tx, resource)
+	})
+}
+
+// ModifyResource атомарно изменяет ресурс (см. Modify)
+func (s *SQLiteStore) ModifyResource(kind models.ResourceKind, id string, fn func(resource *models.Resource) error) (*models.Resource, error) {
+	var resource *models.Resource
+	err := s.write(func(tx *sql.Tx) error {
+		var err error
+		resource, err = getResource(tx, kind, id)
+		if err != nil {
+			return err
+		}
+
+		if err := modifyResource(resource, fn); err != nil {
+			return err
+		}
+		resource.Version++
+
+		if _, err := tx.Exec(`DELETE FROM resources WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("ошибка при обновлении ресурса: %w", err)
+		}
+		return insertResource(tx, resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// DeleteResource удаляет ресурс, если в той же транзакции не нашлось ссылающихся на него событий
+func (s *SQLiteStore) DeleteResource(kind models.ResourceKind, id string) error {
+	return s.write(func(tx *sql.Tx) error {
+		if _, err := getResource(tx, kind, id); err != nil {
+			return err
+		}
+		events, err := queryEvents(tx, `SELECT data FROM events`)
+		if err != nil {
+			return err
+		}
+		if used := usingEvents(events, kind, id); len(used) > 0 {
+			return &ResourceInUseError{Events: used}
+		}
+
+		result, err := tx.Exec(`DELETE FROM resources WHERE id = ? AND kind = ?`, id, string(kind))
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении ресурса: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return resourceNotFound(kind, id)
+		}
+		return nil
+	})
+}
+
+// getResource читает ресурс по ID в базе или внутри транзакции
+func getResource(q queryRower, kind models.ResourceKind, id string) (*models.Resource, error) {
+	var data string
+	err := q.QueryRow(`SELECT data FROM resources WHERE id = ? AND kind = ?`, id, string(kind)).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, resourceNotFound(kind, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ресурса: %w", err)
+	}
+
+	return decodeResource(data)
+}
+
+func insertResource(tx *sql.Tx, resource *models.Resource) error {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO resources (id, kind, name_folded, version, data) VALUES (?, ?, ?, ?, ?)`,
+		resource.ID, string(resource.Kind), foldCase(resource.Name), resource.Version, string(data))
+	if err != nil {
+		return fmt.Errorf("ошибка при записи ресурса: %w", err)
+	}
+	return nil
+}
+
+func decodeResource(data string) (*models.Resource, error) {
+	var resource models.Resource
+	if err := json.Unmarshal([]byte(data), &resource); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	return &resource, nil
+}
+
+// ListProfiles возвращает профили доступности, упорядоченные по ID
+func (s *SQLiteStore) ListProfiles() ([]*models.AvailabilityProfile, error) {
+	rows, err := s.db.Query(`SELECT data FROM profiles ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении профилей: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := make([]*models.AvailabilityProfile, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении профиля: %w", err)
+		}
+		profile, err := decodeProfile(data)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+// GetProfile возвращает профиль доступности по ID
+func (s *SQLiteStore) GetProfile(id string) (*models.AvailabilityProfile, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM profiles WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, profileNotFound(id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении профиля: %w", err)
+	}
+
+	return decodeProfile(data)
+}
+
+// SaveProfile создает или заменяет профиль доступности
+func (s *SQLiteStore) SaveProfile(profile *models.AvailabilityProfile) error {
+	return s.write(func(tx *sql.Tx) error {
+		var stored int64
+		err := tx.QueryRow(`SELECT version FROM profiles WHERE id = ?`, profile.ID).Scan(&stored)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("ошибка при чтении профиля: %w", err)
+		}
+		if err := prepareSave(&profile.Version, &profile.UpdatedAt, stored, profile.Validate); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(profile)
+		if err != nil {
+			return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+		}
+
+		_, err = tx.Exec(`INSERT INTO profiles (id, version, data) VALUES (?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET version = excluded.version, data = excluded.data`,
+			profile.ID, profile.Version, string(data))
+		if err != nil {
+			return fmt.Errorf("ошибка при записи профиля: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteProfile удаляет профиль доступности
+func (s *SQLiteStore) DeleteProfile(id string) error {
+	return s.write(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM profiles WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении профиля: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return profileNotFound(id)
+		}
+		return nil
+	})
+}
+
+func decodeProfile(data string) (*models.AvailabilityProfile, error) {
+	var profile models.AvailabilityProfile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	return &profile, nil
+}
+
+// ListBookingLinks возвращает ссылки для записи, упорядоченные по ID
+func (s *SQLiteStore) ListBookingLinks() ([]*models.BookingLink, error) {
+	rows, err := s.db.Query(`SELECT data FROM booking_links ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ссылок для записи: %w", err)
+	}
+	defer rows.Close()
+
+	links := make([]*models.BookingLink, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении ссылки для записи: %w", err)
+		}
+		link, err := decodeBookingLink(data)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// GetBookingLink возвращает ссылку для записи по ID
+func (s *SQLiteStore) GetBookingLink(id string) (*models.BookingLink, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM booking_links WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, bookingLinkNotFound(id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ссылки для записи: %w", err)
+	}
+
+	return decodeBookingLink(data)
+}
+
+// SaveBookingLink создает или заменяет ссылку для записи
+func (s *SQLiteStore) SaveBookingLink(link *models.BookingLink) error {
+	return s.write(func(tx *sql.Tx) error {
+		var stored int64
+		err := tx.QueryRow(`SELECT version FROM booking_links WHERE id = ?`, link.ID).Scan(&stored)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("ошибка при чтении ссылки для записи: %w", err)
+		}
+		if err := prepareSave(&link.Version, &link.UpdatedAt, stored, link.Validate); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(link)
+		if err != nil {
+			return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+		}
+
+		_, err = tx.Exec(`INSERT INTO booking_links (id, version, data) VALUES (?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET version = excluded.version, data = excluded.data`,
+			link.ID, link.Version, string(data))
+		if err != nil {
+			return fmt.Errorf("ошибка при записи ссылки для записи: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteBookingLink удаляет ссылку для записи
+func (s *SQLiteStore) DeleteBookingLink(id string) error {
+	return s.write(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM booking_links WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении ссылки для записи: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return bookingLinkNotFound(id)
+		}
+		return nil
+	})
+}
+
+func decodeBookingLink(data string) (*models.BookingLink, error) {
+	var link models.BookingLink
+	if err := json.Unmarshal([]byte(data), &link); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	return &link, nil
+}
+
+// ListReminders возвращает сработавшие напоминания, упорядоченные по сроку
+func (s *SQLiteStore) ListReminders() ([]*models.Reminder, error) {
+	rows, err := s.db.Query(`SELECT data FROM reminders ORDER BY due, id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении напоминаний: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := make([]*models.Reminder, 0)
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка при чтении напоминания: %w", err)
+		}
+		reminder, err := decodeReminder(data)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// GetReminder возвращает сработавшее напоминание по ID
+func (s *SQLiteStore) GetReminder(id string) (*models.Reminder, error) {
+	return getReminder(s.db, id)
+}
+
+// SaveReminder создает или заменяет запись о напоминании
+func (s *SQLiteStore) SaveReminder(reminder *models.Reminder) error {
+	return s.write(func(tx *sql.Tx) error {
+		return putReminder(tx, reminder)
+	})
+}
+
+// AcknowledgeReminder отмечает напоминание подтвержденным
+func (s *SQLiteStore) AcknowledgeReminder(id string, at time.Time) (*models.Reminder, error) {
+	var reminder *models.Reminder
+	err := s.write(func(tx *sql.Tx) error {
+		var err error
+		if reminder, err = getReminder(tx, id); err != nil {
+			return err
+		}
+		acknowledge(reminder, at)
+		return putReminder(tx, reminder)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// PruneReminders удаляет записи о напоминаниях со сроком раньше before
+func (s *SQLiteStore) PruneReminders(before time.Time) (int, error) {
+	var removed int64
+	err := s.write(func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM reminders WHERE due < ?`, before.UnixNano())
+		if err != nil {
+			return fmt.Errorf("ошибка при удалении напоминаний: %w", err)
+		}
+		removed, _ = result.RowsAffected()
+		return nil
+	})
+
+	return int(removed), err
+}
+
+// ReminderCheckpoint возвращает момент, до которого напоминания разосланы
+func (s *SQLiteStore) ReminderCheckpoint() (time.Time, error) {
+	var checkpoint int64
+	err := s.db.QueryRow(`SELECT checkpoint FROM reminder_checkpoint WHERE id = 1`).Scan(&checkpoint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ошибка при чтении состояния напоминаний: %w", err)
+	}
+
+	return time.Unix(0, checkpoint), nil
+}
+
+// SetReminderCheckpoint сохраняет момент, до которого напоминания разосланы
+func (s *SQLiteStore) SetReminderCheckpoint(checkpoint time.Time) error {
+	return s.write(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO reminder_checkpoint (id, checkpoint) VALUES (1, ?)
+			ON CONFLICT(id) DO UPDATE SET checkpoint = excluded.checkpoint`, checkpoint.UnixNano())
+		if err != nil {
+			return fmt.Errorf("ошибка при записи состояния напоминаний: %w", err)
+		}
+		return nil
+	})
+}
+
+// getReminder читает напоминание по ID в базе или внутри транзакции
+func getReminder(q queryRower, id string) (*models.Reminder, error) {
+	var data string
+	err := q.QueryRow(`SELECT data FROM reminders WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, reminderNotFound(id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении напоминания: %w", err)
+	}
+
+	return decodeReminder(data)
+}
+
+func putReminder(tx *sql.Tx, reminder *models.Reminder) error {
+	data, err := json.Marshal(reminder)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO reminders (id, due, data) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET due = excluded.due, data = excluded.data`,
+		reminder.ID, reminder.Due.UnixNano(), string(data))
+	if err != nil {
+		return fmt.Errorf("ошибка при записи напоминания: %w", err)
+	}
+	return nil
+}
+
+func decodeReminder(data string) (*models.Reminder, error) {
+	var reminder models.Reminder
+	if err := json.Unmarshal([]byte(data), &reminder); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+	return &reminder, nil
+}
@@ -4,28 +4,55 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
 	"schedule-app/internal/models"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
-// Storage представляет файловое хранилище для событий
+// CompactInterval и CompactThreshold задают, как часто журнал изменений
+// сворачивается в снимок: по таймеру и при накоплении заданного числа записей
+var (
+	CompactInterval  = time.Minute
+	CompactThreshold = 1000
+)
+
+// Storage представляет файловое хранилище для событий: события хранятся
+// в памяти, каждое изменение дописывается в журнал (filePath + ".journal"),
+// а журнал периодически сворачивается в снимок - JSON-файл filePath.
+// Группы, преподаватели и аудитории, как и профили доступности, ссылки для
+// записи и состояние напоминаний, меняются редко и целиком записываются
+// в отдельные файлы (events.json -> events.resources.json,
+// events.profiles.json, events.booking.json, events.reminders.json)
+// при каждом изменении
 type Storage struct {
-	mu       sync.RWMutex
+	*MemoryStore
 	filePath string
-	events   map[string]*models.Event
+	journal  *journal
+
+	// compactMu не дает двум сворачиваниям журнала выполняться одновременно
+	compactMu sync.Mutex
+	compactCh chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
 }
 
 // NewStorage создает новое хранилище
 func NewStorage(filePath string) (*Storage, error) {
 	storage := &Storage{
-		filePath: filePath,
-		events:   make(map[string]*models.Event),
+		MemoryStore: NewMemoryStore(),
+		filePath:    filePath,
+		compactCh:   make(chan struct{}, 1),
+		done:        make(chan struct{}),
 	}
+	storage.persist = storage.appendJournal
+	storage.persistResources = storage.saveResources
+	storage.persistProfiles = storage.saveProfiles
+	storage.persistBookingLinks = storage.saveBookingLinks
+	storage.persistReminders = storage.saveReminders
 
 	// Создаем директорию, если она не существует
 	dir := filepath.Dir(filePath)
@@ -34,198 +61,354 @@ func NewStorage(filePath string) (*Storage, error) {
 	}
 
 	// Загружаем данные из файла
-	if err := storage.load(); err != nil {
+	renamed, err := storage.load()
+	if err != nil {
 		// Если файл не существует, создаем пустой
 		if os.IsNotExist(err) {
 			if err := storage.save(); err != nil {
 				return nil, fmt.Errorf("не удалось создать файл данных: %w", err)
 			}
-			return storage, nil
+		} else {
+			return nil, fmt.Errorf("не удалось загрузить данные: %w", err)
 		}
-		return nil, fmt.Errorf("не удалось загрузить данные: %w", err)
 	}
 
+	// Исправленные дубликаты ID сразу записываются в снимок
+	if renamed > 0 {
+		if err := storage.save(); err != nil {
+			return nil, fmt.Errorf("не удалось сохранить исправленные ID: %w", err)
+		}
+	}
+
+	// Применяем изменения, не попавшие в снимок до остановки
+	replayed, err := replayJournal(storage.journalPath(), storage.events)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось восстановить данные из журнала: %w", err)
+	}
+
+	storage.rebuildIndex()
+
+	if err := storage.loadResources(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить ресурсы: %w", err)
+	}
+	if err := storage.loadProfiles(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить профили доступности: %w", err)
+	}
+	if err := storage.loadBookingLinks(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить ссылки для записи: %w", err)
+	}
+	if err := storage.loadReminders(); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить напоминания: %w", err)
+	}
+
+	storage.journal, err = openJournal(storage.journalPath())
+	if err != nil {
+		return nil, err
+	}
+	storage.journal.entries = replayed
+
+	storage.wg.Add(1)
+	go storage.compactLoop()
+
 	return storage, nil
 }
 
-// GetAll возвращает все события
-func (s *Storage) GetAll() ([]*models.Event, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// Close останавливает фоновое сворачивание, сворачивает журнал в снимок
+// и закрывает файл журнала
+func (s *Storage) Close() error {
+	close(s.done)
+	s.wg.Wait()
 
-	events := make([]*models.Event, 0, len(s.events))
-	for _, event := range s.events {
-		events = append(events, event)
+	err := s.Compact()
+	if closeErr := s.journal.close(); err == nil {
+		err = closeErr
 	}
-
-	return events, nil
+	return err
 }
 
-// GetByID возвращает событие по ID
-func (s *Storage) GetByID(id string) (*models.Event, error) {
+// Compact сохраняет снимок всех событий и убирает из журнала вошедшие в него
+// записи. Снимок записывается на диск без блокировки хранилища: изменения,
+// сделанные в это время, остаются в журнале, а блокировка удерживается
+// только на время замены журнала
+func (s *Storage) Compact() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	// Хранимые события не изменяются на месте (изменение заменяет событие
+	// в карте), поэтому срез указателей - согласованный снимок
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	entries, offset := s.journal.entries, s.journal.size
+	events := s.getAllEvents()
+	s.mu.RUnlock()
 
-	event, exists := s.events[id]
-	if !exists {
-		return nil, fmt.Errorf("событие с ID %s не найдено", id)
+	if entries == 0 {
+		return nil
 	}
 
-	return event, nil
+	// Снимок записывается атомарно до сокращения журнала: при сбое между
+	// этими шагами журнал будет повторно применен к новому снимку
+	if err := s.writeSnapshot(events); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.dropPrefix(offset, entries)
 }
 
-// GetByDate возвращает события на определенную дату
-// internal/storage/storage.go
-// (добавляем после существующего кода)
+// compactLoop сворачивает журнал в фоне по таймеру или по сигналу
+// о превышении CompactThreshold
+func (s *Storage) compactLoop() {
+	defer s.wg.Done()
 
-// GetByDate возвращает события на определенную дату (включая целый день)
-func (s *Storage) GetByDate(date time.Time) ([]*models.Event, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	ticker := time.NewTicker(CompactInterval)
+	defer ticker.Stop()
 
-	var events []*models.Event
-	year, month, day := date.Date()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.compactCh:
+		}
 
-	for _, event := range s.events {
-		eventYear, eventMonth, eventDay := event.StartTime.Date()
-		if year == eventYear && month == eventMonth && day == eventDay {
-			events = append(events, event)
+		if err := s.Compact(); err != nil {
+			log.Printf("Ошибка при сворачивании журнала: %v", err)
 		}
 	}
+}
 
-	// Сортируем события по времени начала
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].StartTime.Before(events[j].StartTime)
-	})
+// appendJournal дописывает изменения в журнал одной записью; вызывается
+// под блокировкой хранилища
+func (s *Storage) appendJournal(changes []change) error {
+	now := time.Now()
+	entries := make([]journalEntry, len(changes))
+	for i, c := range changes {
+		entries[i] = journalEntry{Time: now, Op: c.op, ID: c.id, Event: c.event}
+	}
+
+	entry := entries[0]
+	if len(entries) > 1 {
+		entry = journalEntry{Time: now, Op: opBatch, Batch: entries}
+	}
+	if err := s.journal.append(entry); err != nil {
+		return err
+	}
 
-	return events, nil
+	if s.journal.entries >= CompactThreshold {
+		select {
+		case s.compactCh <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
 }
 
-// Create создает новое событие
-func (s *Storage) Create(event *models.Event) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *Storage) journalPath() string {
+	return s.filePath + ".journal"
+}
 
-	// Проверяем, существует ли уже событие с таким ID
-	if _, exists := s.events[event.ID]; exists {
-		return fmt.Errorf("событие с ID %s уже существует", event.ID)
-	}
+func (s *Storage) resourcesPath() string {
+	return strings.TrimSuffix(s.filePath, filepath.Ext(s.filePath)) + ".resources.json"
+}
 
-	s.events[event.ID] = event
-	return s.save()
+// loadResources загружает ресурсы из файла; отсутствие файла - не ошибка
+func (s *Storage) loadResources() error {
+	return loadEntries(s.resourcesPath(), s.resources, func(resource *models.Resource) string {
+		return resource.ID
+	})
 }
 
-// Update обновляет существующее событие
-func (s *Storage) Update(event *models.Event) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// saveResources атомарно записывает все ресурсы в файл; вызывается под
+// блокировкой хранилища
+func (s *Storage) saveResources() error {
+	return writeJSONAtomic(s.resourcesPath(), sortedValues(s.resources, sortResources))
+}
 
-	if _, exists := s.events[event.ID]; !exists {
-		return fmt.Errorf("событие с ID %s не найдено", event.ID)
-	}
+func (s *Storage) profilesPath() string {
+	return strings.TrimSuffix(s.filePath, filepath.Ext(s.filePath)) + ".profiles.json"
+}
 
-	s.events[event.ID] = event
-	return s.save()
+// loadProfiles загружает профили доступности из файла; отсутствие файла - не ошибка
+func (s *Storage) loadProfiles() error {
+	return loadEntries(s.profilesPath(), s.profiles, func(profile *models.AvailabilityProfile) string {
+		return profile.ID
+	})
 }
 
-// Delete удаляет событие по ID
-func (s *Storage) Delete(id string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// saveProfiles атомарно записывает все профили доступности в файл;
+// вызывается под блокировкой хранилища
+func (s *Storage) saveProfiles() error {
+	return writeJSONAtomic(s.profilesPath(), sortedValues(s.profiles, sortProfiles))
+}
 
-	if _, exists := s.events[id]; !exists {
-		return fmt.Errorf("событие с ID %s не найдено", id)
-	}
+func (s *Storage) bookingLinksPath() string {
+	return strings.TrimSuffix(s.filePath, filepath.Ext(s.filePath)) + ".booking.json"
+}
 
-	delete(s.events, id)
-	return s.save()
+// loadBookingLinks загружает ссылки для записи из файла; отсутствие файла - не ошибка
+func (s *Storage) loadBookingLinks() error {
+	return loadEntries(s.bookingLinksPath(), s.bookingLinks, func(link *models.BookingLink) string {
+		return link.ID
+	})
 }
 
-// Search ищет события по ключевым словам в заголовке и тегах
-func (s *Storage) Search(query string) ([]*models.Event, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// saveBookingLinks атомарно записывает все ссылки для записи в файл;
+// вызывается под блокировкой хранилища
+func (s *Storage) saveBookingLinks() error {
+	return writeJSONAtomic(s.bookingLinksPath(), sortedValues(s.bookingLinks, sortBookingLinks))
+}
 
-	if query == "" {
-		return s.getAllEvents(), nil
-	}
+func (s *Storage) remindersPath() string {
+	return strings.TrimSuffix(s.filePath, filepath.Ext(s.filePath)) + ".reminders.json"
+}
 
-	query = strings.ToLower(strings.TrimSpace(query))
-	var results []*models.Event
+// remindersFile - содержимое файла состояния напоминаний
+type remindersFile struct {
+	Checkpoint time.Time          `json:"checkpoint"`
+	Reminders  []*models.Reminder `json:"reminders"`
+}
 
-	for _, event := range s.events {
-		// Поиск в заголовке
-		if containsIgnoreCase(event.Title, query) {
-			results = append(results, event)
-			continue
-		}
+// loadReminders загружает состояние напоминаний из файла; отсутствие файла - не ошибка
+func (s *Storage) loadReminders() error {
+	data, err := os.ReadFile(s.remindersPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-		// Поиск в тегах
-		for _, tag := range event.Tags {
-			if containsIgnoreCase(tag, query) {
-				results = append(results, event)
-				break
-			}
-		}
+	var file remindersFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("ошибка при разборе JSON: %w", err)
 	}
 
-	// Удаляем дубликаты
-	return removeDuplicates(results), nil
+	s.reminderCheckpoint = file.Checkpoint
+	for _, reminder := range file.Reminders {
+		s.reminders[reminder.ID] = reminder
+	}
+	return nil
 }
 
-// Вспомогательная функция для удаления дубликатов
-func removeDuplicates(events []*models.Event) []*models.Event {
-	seen := make(map[string]bool)
-	result := make([]*models.Event, 0, len(events))
+// saveReminders атомарно записывает состояние напоминаний в файл;
+// вызывается под блокировкой хранилища
+func (s *Storage) saveReminders() error {
+	return writeJSONAtomic(s.remindersPath(), remindersFile{
+		Checkpoint: s.reminderCheckpoint,
+		Reminders:  sortedValues(s.reminders, sortReminders),
+	})
+}
 
-	for _, event := range events {
-		if !seen[event.ID] {
-			seen[event.ID] = true
-			result = append(result, event)
-		}
+// loadEntries загружает список записей (ресурсов, профилей или ссылок)
+// из JSON-файла path в карту entries по ключу id; отсутствие файла - не ошибка
+func loadEntries[T any](path string, entries map[string]*T, id func(entry *T) string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
-	return result
+	var list []*T
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("ошибка при разборе JSON: %w", err)
+	}
+
+	for _, entry := range list {
+		entries[id(entry)] = entry
+	}
+	return nil
 }
 
-// Получить все события (вспомогательная функция)
-func (s *Storage) getAllEvents() []*models.Event {
-	events := make([]*models.Event, 0, len(s.events))
-	for _, event := range s.events {
-		events = append(events, event)
+// sortedValues возвращает записи карты entries, упорядоченные sortEntries
+func sortedValues[T any](entries map[string]*T, sortEntries func(entries []*T)) []*T {
+	list := make([]*T, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
 	}
-	return events
+	sortEntries(list)
+	return list
 }
 
-// load загружает данные из файла
-func (s *Storage) load() error {
+// writeJSONAtomic записывает value в JSON-файл path через временный файл,
+// чтобы при сбое не остался недописанный файл
+func writeJSONAtomic(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
+	if err := writeFileSync(tmpFile, data); err != nil {
+		return fmt.Errorf("ошибка при записи во временный файл: %w", err)
+	}
+	if err := os.Rename(tmpFile, path); err != nil {
+		return fmt.Errorf("ошибка при замене файла: %w", err)
+	}
+	return nil
+}
+
+// load загружает данные из файла и возвращает число событий, получивших
+// новый ID из-за дубликатов (см. ResolveDuplicateIDs)
+func (s *Storage) load() (int, error) {
 	data, err := os.ReadFile(s.filePath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	var events []*models.Event
 	if err := json.Unmarshal(data, &events); err != nil {
-		return fmt.Errorf("ошибка при разборе JSON: %w", err)
+		return 0, fmt.Errorf("ошибка при разборе JSON: %w", err)
 	}
 
+	renamed := ResolveDuplicateIDs(events)
+
 	// Преобразуем срез в карту для быстрого доступа по ID
 	s.events = make(map[string]*models.Event)
 	for _, event := range events {
 		s.events[event.ID] = event
 	}
 
-	return nil
+	return renamed, nil
+}
+
+// ResolveDuplicateIDs находит события с одинаковыми ID (их порождал прежний
+// генератор ID, зависевший только от времени) и присваивает им новые ID.
+// Свой ID сохраняет последнее из совпадающих событий: раньше при загрузке оно
+// заменяло остальные, и именно к нему относились изменения из журнала.
+// Возвращает число событий, получивших новый ID
+func ResolveDuplicateIDs(events []*models.Event) int {
+	last := make(map[string]int, len(events))
+	for i, event := range events {
+		last[event.ID] = i
+	}
+
+	renamed := 0
+	for i, event := range events {
+		if last[event.ID] == i {
+			continue
+		}
+
+		oldID := event.ID
+		event.AssignNewID()
+		log.Printf("Дубликат ID %s (событие %q): присвоен новый ID %s", oldID, event.Title, event.ID)
+		renamed++
+	}
+
+	return renamed
 }
 
-// save сохраняет данные в файл
+// save сохраняет снимок всех событий в файл
 func (s *Storage) save() error {
 	// Преобразуем карту в срез для сериализации
-	events := make([]*models.Event, 0, len(s.events))
-	for _, event := range s.events {
-		events = append(events, event)
-	}
+	return s.writeSnapshot(s.getAllEvents())
+}
 
+// writeSnapshot атомарно записывает события в файл снимка
+func (s *Storage) writeSnapshot(events []*models.Event) error {
 	data, err := json.MarshalIndent(events, "", "  ")
 	if err != nil {
 		return fmt.Errorf("ошибка при сериализации JSON: %w", err)
@@ -233,7 +416,7 @@ func (s *Storage) save() error {
 
 	// Создаем временный файл для безопасной записи
 	tmpFile := s.filePath + ".tmp"
-	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+	if err := writeFileSync(tmpFile, data); err != nil {
 		return fmt.Errorf("ошибка при записи во временный файл: %w", err)
 	}
 
@@ -245,12 +428,22 @@ func (s *Storage) save() error {
 	return nil
 }
 
-// containsIgnoreCase проверяет, содержит ли строка подстроку (без учета регистра)
-func containsIgnoreCase(s, substr string) bool {
-	if len(substr) == 0 {
-		return true
+// writeFileSync записывает файл и сбрасывает его содержимое на диск
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
 	}
 
-	// Используем стандартную библиотеку для корректной работы с Unicode
-	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	return file.Close()
 }
@@ -0,0 +1,566 @@
+// internal/storage/store.go
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"schedule-app/internal/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventStore описывает хранилище событий. Реализации: Storage (JSON-файл),
+// SQLiteStore (база SQLite) и MemoryStore (в памяти процесса)
+type EventStore interface {
+	// Get возвращает хранимое событие (одиночное или серию) по ID
+	Get(id string) (*models.Event, error)
+	// List возвращает все хранимые события без разворачивания серий
+	List() ([]*models.Event, error)
+	// Create сохраняет новое событие с версией 1
+	Create(event *models.Event) error
+	// Update заменяет существующее событие, если его версия совпадает с хранимой
+	// (иначе возвращает ErrVersionConflict), и увеличивает версию
+	Update(event *models.Event) error
+	// Modify атомарно применяет fn к копии хранимого события и сохраняет
+	// результат с новой версией. Если fn вернула ошибку или событие не прошло
+	// Validate, хранилище не изменяется. Возвращает сохраненное событие
+	Modify(id string, fn func(event *models.Event) error) (*models.Event, error)
+	// ModifyChecked работает как Modify, но fn дополнительно получает события
+	// окна [from, to) (как Range), прочитанные под той же блокировкой, что
+	// и запись (см. CreateChecked). Новые ссылки события на ресурсы
+	// проверяются так же, как в CreateChecked
+	ModifyChecked(id string, from, to time.Time, fn func(event *models.Event, existing []*models.Event) error) (*models.Event, error)
+	// Split атомарно сохраняет сокращенную серию head (версия сверяется, как
+	// в Update) и создает новую серию tail с версией 1 (см. models.Event.SplitAt),
+	// если check не вернула ошибку и ресурсы новой серии существуют (см.
+	// CreateChecked): либо сохраняются обе серии, либо ни одна
+	Split(head, tail *models.Event, from, to time.Time, check func(existing []*models.Event) error) error
+	// Delete удаляет событие по ID
+	Delete(id string) error
+	// DeleteIfVersion удаляет событие, если его версия совпадает с version,
+	// иначе возвращает ErrVersionConflict; проверка и удаление атомарны
+	DeleteIfVersion(id string, version int64) error
+	// Search ищет события по ключевым словам в заголовке, тегах, описании
+	// и месте проведения
+	Search(query string) ([]*models.Event, error)
+	// Range возвращает события и экземпляры серий, пересекающиеся с окном [from, to),
+	// отсортированные по времени начала. Занятия с четностью недели отбираются
+	// по семестру SetSemester
+	Range(from, to time.Time) ([]*models.Event, error)
+	// SetSemester задает учебный семестр (nil - не задан): в запросах
+	// по датам занятия с четностью недели (models.Event.WeekParity)
+	// проходят только в дни семестра вне каникул и по неделям нужной
+	// четности. Вызывается при запуске до начала обработки запросов
+	SetSemester(semester *models.Semester)
+	// CreateChecked атомарно создает событие, если check не вернула ошибку.
+	// check получает события окна [from, to) (как Range) и вызывается под той
+	// же блокировкой, что и запись: между проверкой и созданием события
+	// хранилище не меняется. Ошибка check возвращается без изменений.
+	// Ресурсы, на которые ссылается событие, проверяются под той же
+	// блокировкой: ссылка на несуществующий ресурс - models.ValidationError
+	CreateChecked(event *models.Event, from, to time.Time, check func(existing []*models.Event) error) error
+	// Upsert атомарно изменяет событие с ID event.ID функцией fn (как Modify)
+	// или, если такого события нет, создает event с версией 1. Ссылки
+	// на ресурсы проверяются так же, как в CreateChecked. Возвращает true,
+	// если событие создано
+	Upsert(event *models.Event, fn func(existing *models.Event) error) (bool, error)
+	// ModifyAll атомарно применяет fn к копиям всех хранимых событий и одной
+	// операцией (одна запись журнала, одна транзакция) сохраняет с новыми
+	// версиями те, для которых fn вернула true. check получает измененные
+	// события и чтение окна [from, to) (как Range) с уже примененными
+	// изменениями; она может дополнительно изменить события (например,
+	// отметить ConflictOverride), а ее ошибка отменяет всю операцию. Ссылки
+	// на ресурсы проверяются, как в CreateChecked. Возвращает сохраненные события
+	ModifyAll(fn func(event *models.Event) bool, check BatchCheck) ([]*models.Event, error)
+}
+
+// BatchCheck - проверка изменений ModifyAll: changed - измененные события,
+// rangeEvents читает события окна [from, to) под той же блокировкой
+type BatchCheck func(changed []*models.Event, rangeEvents func(from, to time.Time) ([]*models.Event, error)) error
+
+// Backend - хранилище выбранного типа (см. Open): события и данные, которые
+// хранятся вместе с ними в том же файле или базе, - ресурсы расписания,
+// профили доступности, ссылки для записи и состояние рассылки напоминаний.
+// Потребители получают только нужные им интерфейсы
+type Backend interface {
+	EventStore
+	ResourceStore
+	ProfileStore
+	BookingStore
+	ReminderStore
+}
+
+// ResourceStore описывает хранилище ресурсов расписания: учебных групп,
+// преподавателей и аудиторий. Как и события, ресурсы возвращаются копиями
+type ResourceStore interface {
+	// ListResources возвращает ресурсы вида kind, отсортированные по названию
+	ListResources(kind models.ResourceKind) ([]*models.Resource, error)
+	// GetResource возвращает ресурс вида kind по ID
+	GetResource(kind models.ResourceKind, id string) (*models.Resource, error)
+	// CreateResource сохраняет новый ресурс с версией 1
+	CreateResource(resource *models.Resource) error
+	// ModifyResource атомарно применяет fn к копии ресурса и сохраняет
+	// результат с новой версией; при ошибке fn или Validate ресурс не меняется
+	ModifyResource(kind models.ResourceKind, id string, fn func(resource *models.Resource) error) (*models.Resource, error)
+	// DeleteResource удаляет ресурс, если на него не ссылается ни одно
+	// событие (в том числе измененный экземпляр серии), иначе возвращает
+	// *ResourceInUseError. Проверка и удаление атомарны
+	DeleteResource(kind models.ResourceKind, id string) error
+}
+
+// ProfileStore описывает хранилище профилей доступности. Профили
+// возвращаются копиями
+type ProfileStore interface {
+	// ListProfiles возвращает профили, упорядоченные по ID
+	ListProfiles() ([]*models.AvailabilityProfile, error)
+	// GetProfile возвращает профиль по ID
+	GetProfile(id string) (*models.AvailabilityProfile, error)
+	// SaveProfile создает или заменяет профиль, если profile.Version совпадает
+	// с хранимой версией (0 - профиля еще нет), иначе возвращает
+	// ErrVersionConflict. Версия сохраненного профиля увеличивается
+	SaveProfile(profile *models.AvailabilityProfile) error
+	// DeleteProfile удаляет профиль по ID
+	DeleteProfile(id string) error
+}
+
+// BookingStore описывает хранилище ссылок для самостоятельной записи.
+// Ссылки возвращаются копиями
+type BookingStore interface {
+	// ListBookingLinks возвращает ссылки, упорядоченные по ID
+	ListBookingLinks() ([]*models.BookingLink, error)
+	// GetBookingLink возвращает ссылку по ID
+	GetBookingLink(id string) (*models.BookingLink, error)
+	// SaveBookingLink создает или заменяет ссылку по тем же правилам версий,
+	// что и SaveProfile
+	SaveBookingLink(link *models.BookingLink) error
+	// DeleteBookingLink удаляет ссылку по ID. События, созданные по ней, остаются
+	DeleteBookingLink(id string) error
+}
+
+// ReminderStore хранит сработавшие напоминания и момент, до которого
+// напоминания уже разосланы: после перезапуска рассылка продолжается с него,
+// не повторяя отправленные и не пропуская наступившие за время простоя.
+// Напоминания возвращаются копиями
+type ReminderStore interface {
+	// ListReminders возвращает сработавшие напоминания, упорядоченные по сроку
+	ListReminders() ([]*models.Reminder, error)
+	// GetReminder возвращает сработавшее напоминание по ID
+	GetReminder(id string) (*models.Reminder, error)
+	// SaveReminder создает или заменяет запись о напоминании
+	SaveReminder(reminder *models.Reminder) error
+	// AcknowledgeReminder отмечает напоминание подтвержденным в момент at;
+	// повторное подтверждение не меняет отметку. Возвращает напоминание
+	AcknowledgeReminder(id string, at time.Time) (*models.Reminder, error)
+	// PruneReminders удаляет записи о напоминаниях со сроком раньше before
+	// и возвращает их число
+	PruneReminders(before time.Time) (int, error)
+	// ReminderCheckpoint возвращает момент, до которого напоминания разосланы;
+	// нулевое время - рассылки еще не было
+	ReminderCheckpoint() (time.Time, error)
+	// SetReminderCheckpoint сохраняет момент, до которого напоминания разосланы
+	SetReminderCheckpoint(checkpoint time.Time) error
+}
+
+// Проверка соответствия реализаций интерфейсам
+var (
+	_ Backend = (*Storage)(nil)
+	_ Backend = (*SQLiteStore)(nil)
+	_ Backend = (*MemoryStore)(nil)
+)
+
+// ErrVersionConflict возвращается из Update, если событие успели изменить
+// после того, как была прочитана обновляемая версия
+var ErrVersionConflict = errors.New("событие было изменено другим запросом")
+
+// ErrNotFound соответствует (errors.Is) ошибкам отсутствия ресурса, профиля
+// доступности, ссылки для записи или напоминания, чтобы их можно было
+// отличить от сбоя хранилища
+var ErrNotFound = errors.New("запись не найдена")
+
+// notFoundError - ошибка отсутствия записи с текстом для пользователя
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return string(e)
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ResourceInUseError возвращается из DeleteResource, если на ресурс
+// ссылаются события Events
+type ResourceInUseError struct {
+	Events []string
+}
+
+func (e *ResourceInUseError) Error() string {
+	return "ресурс используется в событиях: " + strings.Join(e.Events, ", ")
+}
+
+// Open создает хранилище выбранного типа: json, sqlite или memory.
+// Пустой path означает путь по умолчанию (data/events.json или data/events.db)
+func Open(backend, path string) (Backend, error) {
+	switch backend {
+	case "json":
+		if path == "" {
+			path = "data/events.json"
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("ошибка при создании директории данных: %w", err)
+		}
+		return NewStorage(path)
+	case "sqlite":
+		if path == "" {
+			path = "data/events.db"
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("ошибка при создании директории данных: %w", err)
+		}
+		return NewSQLiteStore(path)
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("неизвестный тип хранилища: %s", backend)
+	}
+}
+
+// Upsert сохраняет событие, заменяя хранимое событие с тем же ID независимо
+// от его версии (время создания сохраняется), или создает новое. Используется
+// при импорте, чтобы повторная загрузка обновляла события, а не дублировала их.
+// Возвращает true, если событие создано
+func Upsert(store EventStore, event *models.Event) (bool, error) {
+	return store.Upsert(event, func(existing *models.Event) error {
+		createdAt := existing.CreatedAt
+		*existing = *event.Clone()
+		existing.CreatedAt = createdAt
+		return nil
+	})
+}
+
+// ApplyBells пересчитывает по расписанию звонков время всех занятий,
+// привязанных к номерам пар (см. models.Event.ApplyBells), одной операцией
+// ModifyAll: либо переносятся все занятия, либо ни одно. check проверяет
+// перенесенные занятия (nil - без проверки). Возвращает число измененных событий
+func ApplyBells(store EventStore, bells *models.BellSchedule, check BatchCheck) (int, error) {
+	changed, err := store.ModifyAll(func(event *models.Event) bool {
+		return event.ApplyBells(bells)
+	}, check)
+	if err != nil {
+		return 0, err
+	}
+	return len(changed), nil
+}
+
+// RecurrenceLookbehind и RecurrenceLookahead задают окно относительно текущего
+// момента, в котором разворачиваются повторяющиеся события для запросов без
+// явного диапазона дат (список всех событий, поиск)
+var (
+	RecurrenceLookbehind = 30 * 24 * time.Hour
+	RecurrenceLookahead  = 365 * 24 * time.Hour
+)
+
+// GetAllOccurrences возвращает все события хранилища, разворачивая повторяющиеся
+// серии в экземпляры внутри окна RecurrenceLookbehind/RecurrenceLookahead.
+// Занятия с четностью недели отбираются по семестру semester (nil - не задан)
+func GetAllOccurrences(store EventStore, semester *models.Semester) ([]*models.Event, error) {
+	events, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := expansionWindow()
+	events = filterSemester(expandEvents(events, from, to), semester)
+	sortByStart(events)
+
+	return events, nil
+}
+
+// maxZoneOffset - наибольшее смещение часового пояса от UTC. Сутки события
+// на весь день в его поясе могут отстоять от запрошенных суток на столько же
+const maxZoneOffset = 14 * time.Hour
+
+// GetByDate возвращает события, приходящиеся на дату (с полуночи до полуночи
+// в часовом поясе date), включая многодневные и переходящие через полночь;
+// повторяющиеся серии разворачиваются в экземпляры этого дня. События на весь
+// день отбираются по календарным датам, а не по пересечению во времени, поэтому
+// не попадают на соседний день при просмотре из другого часового пояса
+func GetByDate(store EventStore, date time.Time) ([]*models.Event, error) {
+	year, month, day := date.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+
+	candidates, err := store.Range(dayStart.Add(-maxZoneOffset), dayStart.AddDate(0, 0, 1).Add(maxZoneOffset))
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.Event, 0, len(candidates))
+	for _, event := range candidates {
+		if event.CoversDate(dayStart) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// expandEvents заменяет повторяющиеся серии их экземплярами внутри окна [from, to),
+// одиночные события возвращаются без изменений
+func expandEvents(events []*models.Event, from, to time.Time) []*models.Event {
+	result := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		if event.IsRecurring() {
+			result = append(result, event.Occurrences(from, to)...)
+			continue
+		}
+		result = append(result, event)
+	}
+	return result
+}
+
+// filterSemester убирает занятия, которые не проходят в дни и недели
+// семестра semester (nil - семестр не задан, занятия не ограничиваются);
+// фильтрует срез на месте
+func filterSemester(events []*models.Event, semester *models.Semester) []*models.Event {
+	if semester == nil {
+		return events
+	}
+
+	filtered := events[:0]
+	for _, event := range events {
+		if semester.Includes(event) {
+			filtered = append(filtered, event)
+		}
+	}
+	return filtered
+}
+
+// expansionWindow возвращает окно разворачивания серий относительно текущего момента
+func expansionWindow() (time.Time, time.Time) {
+	now := time.Now()
+	return now.Add(-RecurrenceLookbehind), now.Add(RecurrenceLookahead)
+}
+
+// modifyResource применяет fn к копии ресурса в ModifyResource и проверяет результат
+func modifyResource(resource *models.Resource, fn func(resource *models.Resource) error) error {
+	id, kind := resource.ID, resource.Kind
+	if err := fn(resource); err != nil {
+		return err
+	}
+
+	if resource.ID != id || resource.Kind != kind {
+		return fmt.Errorf("нельзя изменить ID и вид ресурса %s", id)
+	}
+
+	resource.UpdatedAt = time.Now()
+	return resource.Validate()
+}
+
+// sortResources сортирует ресурсы по названию без учета регистра
+func sortResources(resources []*models.Resource) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		return strings.ToLower(resources[i].Name) < strings.ToLower(resources[j].Name)
+	})
+}
+
+// sortProfiles сортирует профили доступности по ID
+func sortProfiles(profiles []*models.AvailabilityProfile) {
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ID < profiles[j].ID
+	})
+}
+
+// checkNewRefs проверяет, что ресурсы, на которые ссылается event или его
+// измененные экземпляры, существуют (exists сообщает о ресурсе). Ссылки,
+// которые были у прежней версии события previous (nil - события не было),
+// не проверяются: правка описания не зависит от удаленных позже ресурсов
+func checkNewRefs(event, previous *models.Event, exists func(kind models.ResourceKind, id string) (bool, error)) error {
+	for _, item := range append([]*models.Event{event}, event.Overrides...) {
+		for _, kind := range models.ResourceKinds {
+			for _, id := range item.ResourceIDs(kind) {
+				if previous != nil && previous.UsesAnywhere(kind, id) {
+					continue
+				}
+
+				found, err := exists(kind, id)
+				if err != nil {
+					return err
+				}
+				if !found {
+					return models.ValidationError{
+						Field:   string(kind) + "s",
+						Message: fmt.Sprintf("Неизвестный ресурс (%s): %s", kind.Name(), id),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// usingEvents возвращает ID событий, которые ссылаются на ресурс
+func usingEvents(events []*models.Event, kind models.ResourceKind, id string) []string {
+	var used []string
+	for _, event := range events {
+		if event.UsesAnywhere(kind, id) {
+			used = append(used, event.ID)
+		}
+	}
+	sort.Strings(used)
+	return used
+}
+
+// resourceNotFound - ошибка отсутствия ресурса
+func resourceNotFound(kind models.ResourceKind, id string) error {
+	return notFoundError(fmt.Sprintf("ресурс %s с ID %s не найден", kind, id))
+}
+
+// prepareSave проверяет профиль доступности или ссылку для записи перед
+// сохранением целиком поверх версии stored (0 - записи нет): version
+// и updatedAt - поля сохраняемой записи, validate - ее проверка. Назначает
+// записи новую версию
+func prepareSave(version *int64, updatedAt *time.Time, stored int64, validate func() error) error {
+	if *version != stored {
+		return ErrVersionConflict
+	}
+	if err := validate(); err != nil {
+		return err
+	}
+
+	*version = stored + 1
+	*updatedAt = time.Now()
+	return nil
+}
+
+// profileNotFound - ошибка отсутствия профиля доступности
+func profileNotFound(id string) error {
+	return notFoundError(fmt.Sprintf("профиль доступности %s не найден", id))
+}
+
+// sortBookingLinks сортирует ссылки для записи по ID
+func sortBookingLinks(links []*models.BookingLink) {
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].ID < links[j].ID
+	})
+}
+
+// bookingLinkNotFound - ошибка отсутствия ссылки для записи
+func bookingLinkNotFound(id string) error {
+	return notFoundError(fmt.Sprintf("ссылка для записи %s не найдена", id))
+}
+
+// sortReminders сортирует напоминания по сроку, при равном сроке - по ID
+func sortReminders(reminders []*models.Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].Due.Equal(reminders[j].Due) {
+			return reminders[i].Due.Before(reminders[j].Due)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+}
+
+// acknowledge отмечает напоминание подтвержденным, если оно еще не подтверждено
+func acknowledge(reminder *models.Reminder, at time.Time) {
+	if reminder.AcknowledgedAt == nil {
+		reminder.AcknowledgedAt = &at
+	}
+}
+
+// reminderNotFound - ошибка отсутствия напоминания
+func reminderNotFound(id string) error {
+	return notFoundError(fmt.Sprintf("напоминание %s не найдено", id))
+}
+
+// modifyEvent применяет fn к копии события в Modify и проверяет результат
+func modifyEvent(event *models.Event, fn func(event *models.Event) error) error {
+	id := event.ID
+	if err := fn(event); err != nil {
+		return err
+	}
+
+	if event.ID != id {
+		return fmt.Errorf("нельзя изменить ID события %s", id)
+	}
+
+	return event.Validate()
+}
+
+// modifyCopy применяет fn из ModifyAll к копии события previous и проверяет
+// результат; nil - fn не изменила событие
+func modifyCopy(previous *models.Event, fn func(event *models.Event) bool) (*models.Event, error) {
+	event := previous.Clone()
+	if !fn(event) {
+		return nil, nil
+	}
+
+	if event.ID != previous.ID {
+		return nil, fmt.Errorf("нельзя изменить ID события %s", previous.ID)
+	}
+	if err := event.Validate(); err != nil {
+		return nil, fmt.Errorf("событие %s: %w", previous.ID, err)
+	}
+
+	event.Version = previous.Version + 1
+	return event, nil
+}
+
+// cloneEvents возвращает копии событий
+func cloneEvents(events []*models.Event) []*models.Event {
+	clones := make([]*models.Event, len(events))
+	for i, event := range events {
+		clones[i] = event.Clone()
+	}
+	return clones
+}
+
+// cloneSingles заменяет копиями одиночные события в результате разворачивания:
+// экземпляры серий и так создаются заново, а одиночное событие возвращается
+// из Occurrences как есть
+func cloneSingles(events []*models.Event) []*models.Event {
+	for i, event := range events {
+		if event.RecurringEventID == "" {
+			events[i] = event.Clone()
+		}
+	}
+	return events
+}
+
+// sortByStart сортирует события по времени начала
+func sortByStart(events []*models.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTime.Before(events[j].StartTime)
+	})
+}
+
+// matchesQuery проверяет, содержит ли заголовок, описание, место проведения
+// или один из тегов события поисковый запрос
+func matchesQuery(event *models.Event, query string) bool {
+	// Поиск в заголовке, описании и месте проведения
+	if containsIgnoreCase(event.Title, query) ||
+		containsIgnoreCase(event.Description, query) ||
+		containsIgnoreCase(event.Place, query) {
+		return true
+	}
+
+	// Поиск в тегах
+	for _, tag := range event.Tags {
+		if containsIgnoreCase(tag, query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsIgnoreCase проверяет, содержит ли строка подстроку (без учета регистра)
+func containsIgnoreCase(s, substr string) bool {
+	if len(substr) == 0 {
+		return true
+	}
+
+	// Используем стандартную библиотеку для корректной работы с Unicode
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -540,7 +540,9 @@ document.addEventListener('DOMContentLoaded', () => {
 // ================== КОНФИГУРАЦИЯ И СОСТОЯНИЕ ==================
 const CONFIG = {
     API_BASE_URL: '/api',
-    DEBUG: true
+    DEBUG: true,
+    // Часовой пояс браузера: сервер считает границы дня в нем
+    TIME_ZONE: Intl.DateTimeFormat().resolvedOptions().timeZone
 };
 
 const AppState = {
@@ -576,6 +578,26 @@ const utils = {
         });
     },
     
+    // Экранирование пользовательского текста перед вставкой в HTML
+    escapeHtml: (value) => {
+        return String(value ?? '')
+            .replace(/&/g, '&amp;')
+            .replace(/</g, '&lt;')
+            .replace(/>/g, '&gt;')
+            .replace(/"/g, '&quot;')
+            .replace(/'/g, '&#39;');
+    },
+    
+    // Разбор времени события: у событий на весь день сервер присылает дату
+    // без времени (YYYY-MM-DD), которую нужно трактовать как местную полночь
+    parseEventTime: (value) => {
+        if (/^\d{4}-\d{2}-\d{2}$/.test(value)) {
+            const [year, month, day] = value.split('-').map(Number);
+            return new Date(year, month - 1, day);
+        }
+        return new Date(value);
+    },
+    
     formatDateTime: (date) => {
         return `${utils.formatDate(date)}, ${utils.formatTime(date)}`;
     },
@@ -733,8 +755,10 @@ const api = {
     // Получить события по дате
     getEventsByDate: async (date) => {
         try {
-            const dateStr = date.toISOString().split('T')[0];
-            const response = await fetch(`${CONFIG.API_BASE_URL}/events/date/${dateStr}`);
+            // Дата берется по местному времени, а не по UTC
+            const pad = (n) => String(n).padStart(2, '0');
+            const dateStr = `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(date.getDate())}`;
+            const response = await fetch(`${CONFIG.API_BASE_URL}/events/date/${dateStr}?tz=${encodeURIComponent(CONFIG.TIME_ZONE)}`);
             const data = await response.json();
             return data.events || [];
         } catch (error) {
@@ -743,6 +767,18 @@ const api = {
         }
     },
     
+    // Получить карточку события с описанием, преобразованным сервером в HTML
+    getEventDetails: async (id) => {
+        try {
+            const response = await fetch(`${CONFIG.API_BASE_URL}/events/${id}/details`);
+            if (!response.ok) return null;
+            return await response.json();
+        } catch (error) {
+            utils.error('Failed to get event details:', error);
+            return null;
+        }
+    },
+    
     // Создать событие
     createEvent: async (eventData) => {
         try {
@@ -766,13 +802,20 @@ const api = {
         }
     },
     
+    // Заголовок If-Match с версией события, которую видел пользователь:
+    // если событие успели изменить (например, в другой вкладке), сервер ответит 412
+    ifMatch: (version) => ({
+        'If-Match': version === undefined ? '*' : `"${version}"`
+    }),
+    
     // Обновить событие
-    updateEvent: async (id, eventData) => {
+    updateEvent: async (id, eventData, version) => {
         try {
             const response = await fetch(`${CONFIG.API_BASE_URL}/events/${id}`, {
                 method: 'PUT',
                 headers: {
-                    'Content-Type': 'application/json'
+                    'Content-Type': 'application/json',
+                    ...api.ifMatch(version)
                 },
                 body: JSON.stringify(eventData)
             });
@@ -790,10 +833,11 @@ const api = {
     },
     
     // Удалить событие
-    deleteEvent: async (id) => {
+    deleteEvent: async (id, version) => {
         try {
             const response = await fetch(`${CONFIG.API_BASE_URL}/events/${id}`, {
-                method: 'DELETE'
+                method: 'DELETE',
+                headers: api.ifMatch(version)
             });
             
             if (!response.ok) {
@@ -1013,7 +1057,7 @@ const viewManager = {
         const dayEvents = await api.getEventsByDate(AppState.currentDate);
         
         // Отсортировать события по времени начала
-        dayEvents.sort((a, b) => new Date(a.startTime) - new Date(b.startTime));
+        dayEvents.sort((a, b) => utils.parseEventTime(a.startTime) - utils.parseEventTime(b.startTime));
         
         // Определить, сегодня ли это
         const today = new Date();
@@ -1084,8 +1128,8 @@ const viewManager = {
                         <!-- Абсолютно позиционированные события -->
                         <div class="events-overlay">
                             ${dayEvents.map(event => {
-                                const startTime = new Date(event.startTime);
-                                const endTime = new Date(event.endTime);
+                                const startTime = utils.parseEventTime(event.startTime);
+                                const endTime = utils.parseEventTime(event.endTime);
                                 
                                 // Рассчитываем позицию и высоту в пикселях
                                 // 60px на час = 1px на минуту
@@ -1223,7 +1267,7 @@ const viewManager = {
                         
                         ${weekDays.map((day, dayIndex) => {
                             const dayEvents = eventsByDay[dayIndex].events.sort((a, b) => 
-                                new Date(a.startTime) - new Date(b.startTime)
+                                utils.parseEventTime(a.startTime) - utils.parseEventTime(b.startTime)
                             );
                             
                             return `
@@ -1236,8 +1280,8 @@ const viewManager = {
                                     <!-- Абсолютно позиционированные события -->
                                     <div class="day-events-overlay">
                                         ${dayEvents.map(event => {
-                                            const startTime = new Date(event.startTime);
-                                            const endTime = new Date(event.endTime);
+                                            const startTime = utils.parseEventTime(event.startTime);
+                                            const endTime = utils.parseEventTime(event.endTime);
                                             
                                             // Рассчитываем позицию и высоту в пикселях
                                             // 50px на час = 0.833px на минуту
@@ -1321,7 +1365,7 @@ const viewManager = {
         const container = document.getElementById('viewContainer');
         if (!container) return;
         
-        const events = AppState.events.sort((a, b) => new Date(b.startTime) - new Date(a.startTime));
+        const events = AppState.events.sort((a, b) => utils.parseEventTime(b.startTime) - utils.parseEventTime(a.startTime));
         
         container.innerHTML = `
             <div class="list-view">
@@ -1357,8 +1401,8 @@ const viewManager = {
                             </thead>
                             <tbody>
                                 ${events.map(event => {
-                                    const startTime = new Date(event.startTime);
-                                    const endTime = new Date(event.endTime);
+                                    const startTime = utils.parseEventTime(event.startTime);
+                                    const endTime = utils.parseEventTime(event.endTime);
                                     const duration = (endTime - startTime) / (1000 * 60 * 60);
                                     const durationStr = duration >= 1 ? 
                                         `${Math.floor(duration)} ч ${Math.round((duration % 1) * 60)} мин` : 
@@ -1447,6 +1491,55 @@ const viewManager = {
                         </div>
                     </div>
                     
+                    <div class="form-group">
+                        <label>
+                            <input type="checkbox" id="eventAllDay">
+                            Весь день (время начала и окончания не учитывается)
+                        </label>
+                    </div>
+                    
+                    <div class="form-group">
+                        <label for="eventRecurrence">Повторение</label>
+                        <select id="eventRecurrence" class="form-control">
+                            <option value="">Не повторять</option>
+                            <option value="FREQ=DAILY">Каждый день</option>
+                            <option value="FREQ=WEEKLY">Каждую неделю</option>
+                            <option value="FREQ=WEEKLY;INTERVAL=2">Раз в две недели</option>
+                            <option value="FREQ=MONTHLY">Каждый месяц</option>
+                            <option value="FREQ=YEARLY">Каждый год</option>
+                        </select>
+                    </div>
+                    
+                    <div class="form-group">
+                        <label for="eventWeekParity">Недели семестра</label>
+                        <select id="eventWeekParity" class="form-control">
+                            <option value="">Без привязки к семестру</option>
+                            <option value="every">Каждая неделя</option>
+                            <option value="odd">Числитель (нечетные недели)</option>
+                            <option value="even">Знаменатель (четные недели)</option>
+                        </select>
+                    </div>
+                    
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label for="eventLocation">Место проведения</label>
+                            <input type="text" id="eventLocation" class="form-control" 
+                                   placeholder="Например: ауд. 305 или ул. Ленина, 1">
+                        </div>
+                        
+                        <div class="form-group">
+                            <label for="eventUrl">Ссылка</label>
+                            <input type="url" id="eventUrl" class="form-control" 
+                                   placeholder="https://...">
+                        </div>
+                    </div>
+                    
+                    <div class="form-group">
+                        <label for="eventDescription">Описание (поддерживается Markdown)</label>
+                        <textarea id="eventDescription" class="form-control" rows="3" 
+                                  placeholder="Дополнительные детали о событии..."></textarea>
+                    </div>
+                    
                     <div class="form-group">
                         <label>Теги</label>
                         <div class="tags-input-container">
@@ -1502,8 +1595,8 @@ const viewManager = {
         if (!container) return;
         
         const event = AppState.selectedEvent;
-        const startTime = new Date(event.startTime);
-        const endTime = new Date(event.endTime);
+        const startTime = utils.parseEventTime(event.startTime);
+        const endTime = utils.parseEventTime(event.endTime);
         
         // Установить временные теги для редактирования
         AppState.tempTags = [...(event.tags || [])];
@@ -1536,6 +1629,25 @@ const viewManager = {
                         </div>
                     </div>
                     
+                    <div class="form-row">
+                        <div class="form-group">
+                            <label for="eventLocation">Место проведения</label>
+                            <input type="text" id="eventLocation" class="form-control" 
+                                   value="${utils.escapeHtml(event.location)}">
+                        </div>
+                        
+                        <div class="form-group">
+                            <label for="eventUrl">Ссылка</label>
+                            <input type="url" id="eventUrl" class="form-control" 
+                                   value="${utils.escapeHtml(event.url)}">
+                        </div>
+                    </div>
+                    
+                    <div class="form-group">
+                        <label for="eventDescription">Описание (поддерживается Markdown)</label>
+                        <textarea id="eventDescription" class="form-control" rows="3">${utils.escapeHtml(event.description)}</textarea>
+                    </div>
+                    
                     <div class="form-group">
                         <label>Теги</label>
                         <div class="tags-input-container">
@@ -1674,8 +1786,8 @@ const viewManager = {
                 `;
             } else {
                 resultsContent.innerHTML = results.map(event => {
-                    const startTime = new Date(event.startTime);
-                    const endTime = new Date(event.endTime);
+                    const startTime = utils.parseEventTime(event.startTime);
+                    const endTime = utils.parseEventTime(event.endTime);
                     
                     return `
                         <div class="result-item" onclick="eventManager.openEvent('${event.id}')">
@@ -1784,7 +1896,8 @@ const eventManager = {
         }
         
         try {
-            await api.deleteEvent(id);
+            const event = AppState.events.find(e => e.id === id) || AppState.selectedEvent;
+            await api.deleteEvent(id, event?.version);
             utils.log('Event deleted:', id);
             alert('Событие успешно удалено');
             await stateManager.updateEvents();
@@ -1799,6 +1912,12 @@ const eventManager = {
         const title = document.getElementById('eventTitle')?.value.trim();
         const startTime = document.getElementById('eventStart')?.value;
         const endTime = document.getElementById('eventEnd')?.value;
+        const description = document.getElementById('eventDescription')?.value || '';
+        const location = document.getElementById('eventLocation')?.value.trim() || '';
+        const url = document.getElementById('eventUrl')?.value.trim() || '';
+        const recurrence = document.getElementById('eventRecurrence')?.value || '';
+        const allDay = document.getElementById('eventAllDay')?.checked || false;
+        const weekParity = document.getElementById('eventWeekParity')?.value || '';
         
         // Валидация
         const errors = eventManager.validateEvent(title, startTime, endTime);
@@ -1814,7 +1933,14 @@ const eventManager = {
             title: title,
             startTime: start.toISOString(),
             endTime: end.toISOString(),
-            tags: AppState.tempTags
+            tags: AppState.tempTags,
+            description: description,
+            location: location,
+            url: url,
+            allDay: allDay,
+            recurrence: recurrence,
+            weekParity: weekParity,
+            timeZone: CONFIG.TIME_ZONE
         };
         
         try {
@@ -1838,6 +1964,9 @@ const eventManager = {
         const title = document.getElementById('eventTitle')?.value.trim();
         const startTime = document.getElementById('eventStart')?.value;
         const endTime = document.getElementById('eventEnd')?.value;
+        const description = document.getElementById('eventDescription')?.value || '';
+        const location = document.getElementById('eventLocation')?.value.trim() || '';
+        const url = document.getElementById('eventUrl')?.value.trim() || '';
         
         // Валидация
         const errors = eventManager.validateEvent(title, startTime, endTime);
@@ -1853,11 +1982,15 @@ const eventManager = {
             title: title,
             startTime: start.toISOString(),
             endTime: end.toISOString(),
-            tags: AppState.tempTags
+            tags: AppState.tempTags,
+            description: description,
+            location: location,
+            url: url,
+            timeZone: CONFIG.TIME_ZONE
         };
         
         try {
-            await api.updateEvent(id, eventData);
+            await api.updateEvent(id, eventData, AppState.selectedEvent?.version);
             utils.log('Event updated:', id, eventData);
             modalManager.showAlert('Успех', 'Событие успешно обновлено');
             
@@ -1922,8 +2055,8 @@ const eventManager = {
         const event = AppState.events.find(e => e.id === id);
         if (!event) return;
         
-        const startTime = new Date(event.startTime);
-        const endTime = new Date(event.endTime);
+        const startTime = utils.parseEventTime(event.startTime);
+        const endTime = utils.parseEventTime(event.endTime);
         const duration = (endTime - startTime) / (1000 * 60 * 60);
         
         // Создаем модальное окно
@@ -2011,6 +2144,36 @@ const eventManager = {
                     </div>
                 </div>
                 
+                ${event.location ? `
+                    <div class="detail-item">
+                        <i class="fas fa-map-marker-alt"></i>
+                        <div>
+                            <strong>Место:</strong>
+                            <div>${utils.escapeHtml(event.location)}</div>
+                        </div>
+                    </div>
+                ` : ''}
+                
+                ${event.url ? `
+                    <div class="detail-item">
+                        <i class="fas fa-link"></i>
+                        <div>
+                            <strong>Ссылка:</strong>
+                            <div><a href="${utils.escapeHtml(event.url)}" target="_blank" rel="noopener noreferrer">${utils.escapeHtml(event.url)}</a></div>
+                        </div>
+                    </div>
+                ` : ''}
+                
+                ${event.description ? `
+                    <div class="detail-item">
+                        <i class="fas fa-align-left"></i>
+                        <div>
+                            <strong>Описание:</strong>
+                            <div class="event-description">${utils.escapeHtml(event.description)}</div>
+                        </div>
+                    </div>
+                ` : ''}
+                
                 ${event.tags && event.tags.length > 0 ? `
                     <div class="detail-item">
                         <i class="fas fa-tags"></i>
@@ -2059,6 +2222,16 @@ const eventManager = {
         // Добавляем в документ
         document.body.appendChild(modal);
         
+        // Описание в Markdown подгружаем уже размеченным: сервер очищает HTML
+        if (event.description) {
+            api.getEventDetails(id).then(details => {
+                const container = modal.querySelector('.event-description');
+                if (details && container) {
+                    container.innerHTML = details.descriptionHtml;
+                }
+            });
+        }
+        
         // Закрытие при клике вне окна
         const closeOnClickOutside = (e) => {
             if (!modal.contains(e.target)) {